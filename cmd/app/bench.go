@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/worker"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// benchSeed is the default seed of the benchmark workload, so runs on different machines compare.
+const benchSeed = 1
+
+// benchVariant is a pool configuration the benchmark sweeps over.
+type benchVariant struct {
+	name string
+	opts []worker.Option
+}
+
+// benchVariants returns the pool configurations the benchmark compares at every worker count.
+// Each call returns fresh options, so no cache is shared between runs.
+func benchVariants() []benchVariant {
+	return []benchVariant{
+		{name: "plain"},
+		{name: "shared-cache", opts: []worker.Option{worker.WithSharedCache(nil)}},
+	}
+}
+
+// benchResult is the outcome of one run of the benchmark workload.
+type benchResult struct {
+	Variant string `json:"variant"`
+	Workers int    `json:"workers"`
+	Tasks   int    `json:"tasks"`
+	// Throughput is the number of tasks completed per second of wall time.
+	Throughput float64 `json:"throughput"`
+	// LatencyP50 and LatencyP99 are percentiles of the processing time of a single task, in milliseconds.
+	LatencyP50 float64 `json:"latency_p50_ms"`
+	LatencyP99 float64 `json:"latency_p99_ms"`
+}
+
+// runBench implements the bench subcommand: it processes the same seeded workload with every pool
+// configuration at every worker count and writes a table of throughput and latency, or JSON with -json.
+func runBench(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	numTasks := flags.Int("tasks", 2000, "number of tasks of the workload")
+	seed := flags.Int64("seed", benchSeed, "seed of the workload")
+	minValue := flags.Int64("min", defaultMinValue, "smallest task value")
+	maxValue := flags.Int64("max", defaultMaxValue, "largest task value")
+	workerList := flags.String("workers", defaultBenchWorkers(), "comma-separated worker counts to compare")
+	asJSON := flags.Bool("json", false, "write the results as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *numTasks <= 0 {
+		return fmt.Errorf("invalid -tasks %d: must be positive", *numTasks)
+	}
+	if *minValue < 0 || *minValue > *maxValue {
+		return fmt.Errorf("invalid value range [%d, %d]: -min must be non-negative and not greater than -max", *minValue, *maxValue)
+	}
+	workerCounts, err := parseWorkerCounts(*workerList)
+	if err != nil {
+		return err
+	}
+
+	batch := model.Batch{Seed: *seed, NumTasks: *numTasks, Min: *minValue, Max: *maxValue}
+	var results []benchResult
+	for _, workers := range workerCounts {
+		for _, variant := range benchVariants() {
+			result, err := benchRun(batch, workers, variant)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "variant\tworkers\ttasks/s\tp50 ms\tp99 ms\t")
+	for _, r := range results {
+		fmt.Fprintf(table, "%s\t%d\t%.0f\t%.3f\t%.3f\t\n", r.Variant, r.Workers, r.Throughput, r.LatencyP50, r.LatencyP99)
+	}
+	return table.Flush()
+}
+
+// benchRun processes the workload once with the given number of workers and pool configuration.
+// Tasks never time out, so every run does the same work.
+func benchRun(batch model.Batch, workers int, variant benchVariant) (benchResult, error) {
+	var mu sync.Mutex
+	latencies := make([]time.Duration, 0, batch.NumTasks)
+	observe := func(int, model.Task) func(model.Result, time.Duration) {
+		return func(_ model.Result, d time.Duration) {
+			mu.Lock()
+			latencies = append(latencies, d)
+			mu.Unlock()
+		}
+	}
+
+	opts := append([]worker.Option{worker.WithTaskObserver(observe)}, variant.opts...)
+	pool, err := worker.NewPool(worker.Config{Workers: workers, ThresholdFactor: 1e9}, opts...)
+	if err != nil {
+		return benchResult{}, err
+	}
+
+	// Generate the whole workload first, so only the processing is measured.
+	tasks := make(chan model.Task, batch.NumTasks)
+	if err := generator.GenerateSeededTasks(batch, tasks); err != nil {
+		return benchResult{}, err
+	}
+
+	start := time.Now()
+	pool.Start(tasks)
+	for range pool.Unordered() {
+	}
+	elapsed := time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return benchResult{
+		Variant:    variant.name,
+		Workers:    workers,
+		Tasks:      batch.NumTasks,
+		Throughput: float64(batch.NumTasks) / elapsed.Seconds(),
+		LatencyP50: percentileMillis(latencies, 0.50),
+		LatencyP99: percentileMillis(latencies, 0.99),
+	}, nil
+}
+
+// percentileMillis returns the p-th percentile of the sorted durations, in milliseconds.
+func percentileMillis(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p * float64(len(sorted)-1))
+	return float64(sorted[i]) / float64(time.Millisecond)
+}
+
+// defaultBenchWorkers returns the worker counts compared by default: powers of two up to twice the
+// number of CPU cores.
+func defaultBenchWorkers() string {
+	var counts []string
+	for n := 1; n <= 2*runtime.NumCPU(); n *= 2 {
+		counts = append(counts, strconv.Itoa(n))
+	}
+	return strings.Join(counts, ",")
+}
+
+// parseWorkerCounts parses a comma-separated list of positive worker counts.
+func parseWorkerCounts(list string) ([]int, error) {
+	var counts []int
+	for _, field := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid worker count %q in -workers: must be a positive integer", field)
+		}
+		counts = append(counts, n)
+	}
+	return counts, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunBench_JSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := runBench([]string{"-tasks", "50", "-max", "100", "-workers", "1,2", "-json"}, &out); err != nil {
+		t.Fatalf("runBench() returned an error: %v", err)
+	}
+
+	var results []benchResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("runBench() wrote invalid JSON: %v\n%s", err, out.String())
+	}
+	if expected := 2 * len(benchVariants()); len(results) != expected {
+		t.Fatalf("Expected %d results, got %d", expected, len(results))
+	}
+	for _, r := range results {
+		if r.Tasks != 50 || r.Throughput <= 0 || r.LatencyP99 < r.LatencyP50 {
+			t.Errorf("Unexpected result %+v", r)
+		}
+	}
+}
+
+func TestRunBench_Table(t *testing.T) {
+	var out bytes.Buffer
+	if err := runBench([]string{"-tasks", "20", "-max", "50", "-workers", "2"}, &out); err != nil {
+		t.Fatalf("runBench() returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if expected := 1 + len(benchVariants()); len(lines) != expected {
+		t.Fatalf("Expected a header and %d rows, got:\n%s", expected-1, out.String())
+	}
+	if !strings.Contains(lines[0], "tasks/s") || !strings.Contains(lines[1], "plain") {
+		t.Errorf("Unexpected table:\n%s", out.String())
+	}
+}
+
+func TestRunBench_InvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-workers", "0"},
+		{"-workers", "two"},
+		{"-tasks", "0"},
+		{"-min", "10", "-max", "5"},
+	} {
+		if err := runBench(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
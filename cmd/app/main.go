@@ -7,62 +7,293 @@ import (
 		}
 	*/
 	"C"
+	"context"
+	"flag"
 	"fmt"
 	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/metrics"
 	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/sink"
 	"github.com/lipcsei/konstruktor/worker"
+	"io"
 	"log"
-	"math/big"
-	"runtime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
 	"strconv"
-	"sync"
+	"syscall"
+	"time"
 )
 
-// numTasks defines the total number of tasks to be generated and processed.
-const numTasks = 100
+// Default values of the command-line flags, matching the behavior of the program before it had flags.
+const (
+	// defaultNumTasks defines the total number of tasks to be generated and processed.
+	defaultNumTasks = 100
+	// defaultMinValue and defaultMaxValue define the inclusive range of the generated task values.
+	defaultMinValue = 3
+	defaultMaxValue = 1000
+	// defaultGrace is how long the tasks already queued or running may take to finish after an interrupt.
+	defaultGrace = 5 * time.Second
+	// defaultTargetDepth is the queue depth an autoscaling pool aims for.
+	defaultTargetDepth = 10
+)
+
+// The output formats of the results selected with -format.
+const (
+	// formatText logs the even results of a batch as sentences, and writes every result read from
+	// standard input as a line of task ID, value and factorial.
+	formatText = "text"
+	// formatJSON writes the results of a batch to standard output as a JSON array, and every result
+	// read from standard input as a JSON object on a line of its own, see sink.EncodeJSON.
+	formatJSON = "json"
+)
 
 func main() {
+	// The bench subcommand has flags of its own.
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := runBench(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	numTasks := flag.Int("tasks", defaultNumTasks, "number of tasks to generate and process")
+	numWorkers := flag.Int("workers", 0, "number of workers (0 uses the number of CPU cores + 1), or the minimum with -max-workers")
+	maxWorkers := flag.Int("max-workers", 0, "scale the number of workers with the queue depth up to this many (0 disables scaling)")
+	targetDepth := flag.Int("target-depth", defaultTargetDepth, "queue depth a scaling pool aims for")
+	seed := flag.Int64("seed", 0, "seed of the task generator (0 seeds from the current time)")
+	minValue := flag.Int64("min", defaultMinValue, "smallest task value")
+	maxValue := flag.Int64("max", defaultMaxValue, "largest task value")
+	stdin := flag.Bool("stdin", false, "read one task value per line from standard input instead of generating tasks")
+	metricsAddr := flag.String("metrics-addr", "", "serve Prometheus metrics at /metrics on this address, e.g. :9090 (empty disables)")
+	format := flag.String("format", formatText, "output format of the results: text or json")
+	grace := flag.Duration("grace", defaultGrace, "how long queued and running tasks may take to finish after SIGINT or SIGTERM")
+	flag.Parse()
+
+	if *format != formatText && *format != formatJSON {
+		log.Fatalf("invalid -format %q: must be %s or %s", *format, formatText, formatJSON)
+	}
+
+	cfg := poolConfig(*numWorkers, *maxWorkers, *targetDepth)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid worker flags: %v", err)
+	}
+	if *metricsAddr != "" {
+		registry := metrics.NewRegistry()
+		addr, err := serveMetrics(*metricsAddr, registry)
+		if err != nil {
+			log.Fatalf("serving metrics: %v", err)
+		}
+		log.Printf("Serving metrics on http://%s/metrics \n", addr)
+		cfg.TaskObserver = metrics.Observer(registry)
+	}
+
+	if *stdin {
+		if err := runStdin(cfg, *format); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *numTasks < 0 {
+		log.Fatalf("invalid -tasks %d: must not be negative", *numTasks)
+	}
+	if err := generator.ValidateRange(*minValue, *maxValue); err != nil {
+		log.Fatalf("invalid -min and -max: %v", err)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	// Create the tasks channel with a capacity of numTasks.
+	tasks := make(chan model.Task, *numTasks) // The tasks channel is used to send tasks to the pool.
 
-	// Create channels for tasks and results with a capacity of numTasks.
-	tasks := make(chan model.Task, numTasks)     // The tasks channel is used to send tasks to the workers.
-	results := make(chan model.Result, numTasks) // The results channel is for receiving processed tasks from the workers.
+	// Start a goroutine to generate tasks. Every task, and so every result, records the batch it belongs to.
+	batch := model.Batch{Seed: *seed, NumTasks: *numTasks, Min: *minValue, Max: *maxValue}
+	log.Printf("Generating %d tasks in [%d, %d] with seed %d \n", batch.NumTasks, batch.Min, batch.Max, batch.Seed)
+	go generator.GenerateSeededTasks(batch, tasks)
 
-	// quit is a channel used to signal workers to stop processing and exit gracefully.
-	// This is particularly useful for terminating workers once all tasks have been processed.
-	quit := make(chan struct{})
+	// SIGINT and SIGTERM stop the run gracefully instead of killing the program.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 
-	// Start a goroutine to generate tasks
-	go generator.GenerateTasks(numTasks, tasks)
+	results, interrupted, err := runBatch(tasks, cfg, signals, *grace)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *format == formatJSON {
+		if err := sink.WriteJSONArray(os.Stdout, results); err != nil {
+			log.Fatalf("writing the results: %v", err)
+		}
+	} else {
+		printResult(results)
+	}
 
-	// wg is a WaitGroup to wait for all worker goroutines to finish processing.
-	var wg sync.WaitGroup
+	// Report how the sizes of the results were distributed.
+	summary := worker.Summarize(results)
+	log.Printf("%d of %d tasks succeeded, results by number of digits (at least): %v \n", summary.Succeeded, summary.Total, summary.DigitHistogram)
+	log.Printf("Trailing zeros: min %d, max %d, mean %.1f \n", summary.MinTrailingZeros, summary.MaxTrailingZeros, summary.MeanTrailingZeros)
 
-	// numWorkers is a determined the number of workers based on the number of CPU cores + 1.
-	numWorkers := runtime.NumCPU() + 1
-	for workerID := 0; workerID < numWorkers; workerID++ {
-		// Increment the WaitGroup counter for each worker.
-		wg.Add(1)
-		// Initialize a new worker.
-		w := worker.New(workerID, tasks, results, &wg, quit)
-		// Start the worker in a new goroutine.
-		go w.Start()
+	if interrupted != nil {
+		log.Printf("Interrupted by %v after %d of %d tasks \n", interrupted, len(results), *numTasks)
+		os.Exit(exitCode(interrupted))
 	}
+}
 
+// serveMetrics serves the registry at /metrics on addr in the background, for as long as the program
+// runs, and returns the address it listens on. If the server stops serving, the error is logged.
+func serveMetrics(addr string, registry *metrics.Registry) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
 	go func() {
-		wg.Wait()      // Wait for all workers to finish.
-		close(results) // Close the results channel to signal completion of result processing.
-		close(quit)    // Close the quit channel as a final step, signaling any remaining workers to terminate.
+		if err := http.Serve(ln, mux); err != nil {
+			log.Printf("Serving metrics on %v stopped: %v", ln.Addr(), err)
+		}
 	}()
+	return ln.Addr(), nil
+}
+
+// poolConfig returns the configuration of a pool with numWorkers workers. If maxWorkers is positive, the pool
+// instead scales between numWorkers, at least 1, and maxWorkers active workers to keep its queue depth near
+// targetDepth.
+func poolConfig(numWorkers, maxWorkers, targetDepth int) worker.Config {
+	if maxWorkers <= 0 {
+		return worker.Config{Workers: numWorkers}
+	}
+	return worker.Config{
+		AutoscaleMin:    max(numWorkers, 1),
+		AutoscaleMax:    maxWorkers,
+		AutoscaleTarget: targetDepth,
+	}
+}
 
-	printResult(results)
+// runBatch processes the tasks with a pool configured by cfg and returns the results in the order
+// of their task IDs. The first signal received stops the run: no further tasks are accepted, and the
+// tasks already queued or running get the grace period to finish before they are abandoned; a second
+// signal abandons them right away. The results that completed are returned either way, along with the
+// signal that interrupted the run, if any.
+func runBatch(tasks <-chan model.Task, cfg worker.Config, signals <-chan os.Signal, grace time.Duration) ([]model.Result, os.Signal, error) {
+	pool, err := worker.NewPool(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Cancelling ctx makes the pool drop its queued tasks and abandon the running ones.
+	ctx, abandon := context.WithCancel(context.Background())
+	defer abandon()
+
+	// Forward the tasks to the pool until the first signal.
+	accepted := make(chan model.Task)
+	stopAccepting := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		for task := range tasks {
+			select {
+			case accepted <- task:
+			case <-stopAccepting:
+				return
+			}
+		}
+	}()
+	pool.StartContext(ctx, accepted)
+
+	var results []model.Result
+	var interrupted os.Signal
+	var graceExpired <-chan time.Time
+	for done := false; !done; {
+		select {
+		case result, ok := <-pool.Unordered():
+			if !ok {
+				done = true
+				break
+			}
+			results = append(results, result)
+		case sig := <-signals:
+			if interrupted != nil {
+				abandon()
+				break
+			}
+			interrupted = sig
+			close(stopAccepting)
+			graceExpired = time.After(grace)
+		case <-graceExpired:
+			abandon()
+		}
+	}
+
+	// Order the results by task ID; an interrupted run may lack some of them.
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Task.ID < results[j].Task.ID
+	})
+	return results, interrupted, nil
+}
+
+// exitCode returns the conventional exit status of a program stopped by the signal: 128 plus its number.
+func exitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// runStdin computes the factorial of every value read from standard input and writes each result
+// to standard output as soon as it completes, as a line of task ID, value and factorial, or as a line
+// holding a JSON object in the JSON format.
+// It returns the first error of reading the input or writing the output, after the tasks read
+// before the error have been processed.
+func runStdin(cfg worker.Config, format string) error {
+	tasks := make(chan model.Task)
+
+	// Read the tasks while the pool processes them, and keep the outcome for when the results are done.
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- generator.GenerateFromReader(os.Stdin, tasks)
+	}()
+
+	pool, err := worker.NewPool(cfg)
+	if err != nil {
+		return err
+	}
+	pool.Start(tasks)
+
+	var out sink.ResultSink = sink.NewWriterSink(os.Stdout)
+	if format == formatJSON {
+		out = sink.NewQueueSink(linePublisher{os.Stdout}, sink.EncodeJSON)
+	}
+	var writeErr error
+	for result := range pool.Unordered() {
+		if writeErr == nil {
+			writeErr = out.Write([]model.Result{result})
+		}
+	}
+
+	if err := <-readErr; err != nil {
+		return fmt.Errorf("reading tasks: %w", err)
+	}
+	return writeErr
+}
+
+// linePublisher is a sink.Publisher writing every message to w as a line of its own.
+type linePublisher struct {
+	w io.Writer
+}
 
+// Publish writes the message followed by a newline.
+func (p linePublisher) Publish(message []byte) error {
+	_, err := p.w.Write(append(message, '\n'))
+	return err
 }
 
 // printResult collect and print the results.
-func printResult(results chan model.Result) {
-	// SortResults organizes results into their original order based on task ID.
-	for _, result := range worker.SortResults(results, numTasks) {
-		if result.Factorial.Cmp(big.NewInt(0)) != 0 {
+func printResult(results []model.Result) {
+	for _, result := range results {
+		if result.Err == nil {
 			runes := []rune(result.Factorial.String())
 			lastRune := fmt.Sprintf("%c", runes[len(runes)-1])
 			lastDigit, err := strconv.Atoi(lastRune)
@@ -74,7 +305,7 @@ func printResult(results chan model.Result) {
 				log.Printf("%d worker finishe the %d. task: %d! = %d The result is an even number. \n", result.WorkerID, result.Task.ID, result.Task.Value, result.Factorial)
 			}
 		} else {
-			log.Printf("%d. task: %d! != %d The computation failed due to a timeout. \n", result.Task.ID, result.Task.Value, result.Factorial)
+			log.Printf("%d. task: %d! The computation failed: %v \n", result.Task.ID, result.Task.Value, result.Err)
 		}
 	}
 }
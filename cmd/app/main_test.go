@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/metrics"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/sink"
+	"github.com/lipcsei/konstruktor/worker"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunBatch(t *testing.T) {
+	const numTasks = 20
+
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: numTasks - 1 - i, Value: 5}
+	}
+	close(tasks)
+
+	results, interrupted, err := runBatch(tasks, worker.Config{Workers: 4}, make(chan os.Signal), time.Second)
+	if err != nil {
+		t.Fatalf("runBatch() returned an error: %v", err)
+	}
+	if interrupted != nil {
+		t.Errorf("Expected no interruption, got %v", interrupted)
+	}
+	if len(results) != numTasks {
+		t.Fatalf("Expected %d results, got %d", numTasks, len(results))
+	}
+	for i, r := range results {
+		if r.Task.ID != i {
+			t.Errorf("Expected result %d to be task %d, got task %d", i, i, r.Task.ID)
+		}
+	}
+}
+
+func TestRunBatch_Interrupt(t *testing.T) {
+	// The tasks channel is never closed, so only the signal can end the run.
+	tasks := make(chan model.Task)
+	go func() {
+		for i := 0; i < 3; i++ {
+			tasks <- model.Task{ID: i, Value: 5}
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	time.AfterFunc(50*time.Millisecond, func() { signals <- os.Interrupt })
+
+	done := make(chan struct{})
+	var results []model.Result
+	var interrupted os.Signal
+	go func() {
+		defer close(done)
+		results, interrupted, _ = runBatch(tasks, worker.Config{Workers: 2}, signals, time.Second)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("runBatch() did not return after the interrupt")
+	}
+	if interrupted != os.Interrupt {
+		t.Errorf("Expected the run to be interrupted by %v, got %v", os.Interrupt, interrupted)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected the 3 tasks accepted before the interrupt to complete, got %d results", len(results))
+	}
+}
+
+func TestRunBatch_Autoscale(t *testing.T) {
+	const numTasks = 50
+
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 100}
+	}
+	close(tasks)
+
+	results, _, err := runBatch(tasks, poolConfig(1, 4, 5), make(chan os.Signal), time.Second)
+	if err != nil {
+		t.Fatalf("runBatch() returned an error: %v", err)
+	}
+	if len(results) != numTasks {
+		t.Errorf("Expected %d results, got %d", numTasks, len(results))
+	}
+}
+
+func TestPoolConfig(t *testing.T) {
+	tests := []struct {
+		name                           string
+		numWorkers, maxWorkers, target int
+		expected                       worker.Config
+	}{
+		{"fixed", 4, 0, 10, worker.Config{Workers: 4}},
+		{"default workers", 0, 0, 10, worker.Config{}},
+		{"scaling", 2, 8, 10, worker.Config{AutoscaleMin: 2, AutoscaleMax: 8, AutoscaleTarget: 10}},
+		{"scaling from one worker", 0, 8, 5, worker.Config{AutoscaleMin: 1, AutoscaleMax: 8, AutoscaleTarget: 5}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			cfg := poolConfig(test.numWorkers, test.maxWorkers, test.target)
+			if cfg.Workers != test.expected.Workers || cfg.AutoscaleMin != test.expected.AutoscaleMin ||
+				cfg.AutoscaleMax != test.expected.AutoscaleMax || cfg.AutoscaleTarget != test.expected.AutoscaleTarget {
+				t.Errorf("Expected %+v, got %+v", test.expected, cfg)
+			}
+		})
+	}
+
+	// More minimum than maximum workers is rejected.
+	if err := poolConfig(8, 2, 10).Validate(); err == nil {
+		t.Errorf("Expected an error for -workers 8 -max-workers 2")
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.IncProcessed()
+
+	addr, err := serveMetrics("127.0.0.1:0", registry)
+	if err != nil {
+		t.Fatalf("serveMetrics() returned an error: %v", err)
+	}
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatalf("Scraping the metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading the metrics failed: %v", err)
+	}
+	if !strings.Contains(string(body), metrics.NameProcessed+" 1\n") {
+		t.Errorf("Expected 1 processed task in the metrics, got:\n%s", body)
+	}
+}
+
+func TestLinePublisher(t *testing.T) {
+	var out strings.Builder
+	results := []model.Result{
+		{Task: model.Task{ID: 0, Value: 5}, Factorial: big.NewInt(120), WorkerID: 1},
+		{Task: model.Task{ID: 1, Value: 7}, WorkerID: 0, Err: errors.New("rejected")},
+	}
+	if err := sink.NewQueueSink(linePublisher{&out}, sink.EncodeJSON).Write(results); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+
+	expected := `{"id":0,"value":5,"factorial":"120","worker_id":1}` + "\n" +
+		`{"id":1,"value":7,"worker_id":0,"error":"rejected"}` + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := exitCode(syscall.SIGINT); code != 130 {
+		t.Errorf("Expected 130 for SIGINT, got %d", code)
+	}
+	if code := exitCode(syscall.SIGTERM); code != 143 {
+		t.Errorf("Expected 143 for SIGTERM, got %d", code)
+	}
+}
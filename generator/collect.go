@@ -0,0 +1,18 @@
+package generator
+
+import "github.com/lipcsei/konstruktor/model"
+
+// CollectTasks runs a generator for numTasks tasks and returns every task it produced, in order,
+// e.g. to inspect the distribution of the values or to feed the same batch to several pools.
+// The generator must close the channel when it is done, like GenerateTasks does, and receives the
+// given options, e.g. WithIDAllocator. Generators with other parameters can be adapted with a closure.
+func CollectTasks(numTasks int, gen func(int, chan<- model.Task, ...Option), opts ...Option) []model.Task {
+	tasks := make(chan model.Task, numTasks)
+	go gen(numTasks, tasks, opts...)
+
+	collected := make([]model.Task, 0, numTasks)
+	for task := range tasks {
+		collected = append(collected, task)
+	}
+	return collected
+}
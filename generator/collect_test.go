@@ -0,0 +1,38 @@
+package generator
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"math/rand"
+	"testing"
+)
+
+func TestCollectTasks(t *testing.T) {
+	numTasks := 100
+	tasks := CollectTasks(numTasks, GenerateTasks)
+
+	if len(tasks) != numTasks {
+		t.Fatalf("Incorrect number of tasks collected: got %v, want %v", len(tasks), numTasks)
+	}
+	for i, task := range tasks {
+		if task.ID != i {
+			t.Errorf("Task %d has ID %d, want the tasks in the order they were generated", i, task.ID)
+		}
+	}
+}
+
+func TestCollectTasks_Closure(t *testing.T) {
+	numTasks := 50
+	seeded := func(n int, tasks chan<- model.Task, opts ...Option) {
+		GenerateRandomTasks(n, rand.New(rand.NewSource(42)), 10, 20, tasks, opts...)
+	}
+
+	first, second := CollectTasks(numTasks, seeded), CollectTasks(numTasks, seeded)
+	if len(first) != numTasks || len(second) != numTasks {
+		t.Fatalf("Incorrect number of tasks collected: got %v and %v, want %v", len(first), len(second), numTasks)
+	}
+	for i := range first {
+		if first[i].Value != second[i].Value {
+			t.Errorf("Task %d differs between collections of the same seed: %v and %v", i, first[i], second[i])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+	"math/rand"
+	"time"
+)
+
+// GenerateTasksContext is like GenerateRandomTasks with a random source seeded from the current time,
+// but stops early when ctx is cancelled. A negative numTasks keeps generating tasks until then, like
+// GenerateForever does. It returns an error wrapping ErrInvalidRange without generating any task if min
+// is negative or greater than max. The tasks channel is closed in either case.
+func GenerateTasksContext(ctx context.Context, numTasks int, min, max int64, tasks chan<- model.Task, opts ...Option) error {
+	// Signal to processors that there are no more tasks.
+	defer close(tasks)
+
+	if err := ValidateRange(min, max); err != nil {
+		return err
+	}
+
+	generate(ctx, numTasks, rand.New(rand.NewSource(time.Now().UnixNano())), min, max, tasks, newConfig(opts))
+	return nil
+}
+
+// generate sends numTasks tasks with values drawn from r, uniformly between min and max, inclusive,
+// or an unlimited number of them if numTasks is negative, until ctx is cancelled. The IDs come from
+// the allocator of cfg. It neither validates the range nor closes the channel.
+func generate(ctx context.Context, numTasks int, r *rand.Rand, min, max int64, tasks chan<- model.Task, cfg config) {
+	for i := 0; numTasks < 0 || i < numTasks; i++ {
+		// Stop promptly once cancelled, even if the consumer is still reading.
+		if ctx.Err() != nil {
+			return
+		}
+
+		task := model.Task{
+			ID:    cfg.ids.Next(),
+			Value: randomValue(r, min, max),
+		}
+
+		// Wait for room on the channel, but give up as soon as the context is cancelled.
+		select {
+		case tasks <- task:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
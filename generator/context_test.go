@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+)
+
+func TestGenerateTasksContext(t *testing.T) {
+	numTasks := 100
+	tasksChan := make(chan model.Task, numTasks)
+
+	if err := GenerateTasksContext(context.Background(), numTasks, DefaultMin, DefaultMax, tasksChan); err != nil {
+		t.Fatalf("GenerateTasksContext() returned an error: %v", err)
+	}
+
+	generatedTasks := 0
+	for task := range tasksChan {
+		generatedTasks++
+		if task.Value < 3 || task.Value > 1000 {
+			t.Errorf("Task value out of expected range: got %v, want between 3 and 1000", task.Value)
+		}
+	}
+
+	if generatedTasks != numTasks {
+		t.Errorf("Incorrect number of tasks generated: got %v, want %v", generatedTasks, numTasks)
+	}
+}
+
+func TestGenerateTasksContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The channel is unbuffered, so no task can be sent once the context is cancelled.
+	tasksChan := make(chan model.Task)
+	go GenerateTasksContext(ctx, 100, DefaultMin, DefaultMax, tasksChan)
+
+	for task := range tasksChan {
+		t.Errorf("Unexpected task generated after cancellation: %v", task)
+	}
+}
+
+func TestGenerateTasksContext_Invalid(t *testing.T) {
+	tasksChan := make(chan model.Task, 10)
+	err := GenerateTasksContext(context.Background(), 10, 20, 10, tasksChan)
+	if !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange, got %v", err)
+	}
+	// No task is generated, and the channel is closed.
+	if task, ok := <-tasksChan; ok {
+		t.Errorf("Unexpected task generated for an invalid range: %v", task)
+	}
+}
@@ -0,0 +1,17 @@
+package generator
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// GenerateForever keeps sending tasks with random values on the tasks channel until ctx is cancelled,
+// at which point it closes the channel. Each task's value is randomly chosen between 3 and 1000, inclusive.
+// Sends block while the channel is full, so a slow consumer throttles the generator rather than
+// the other way round. Task IDs start at 0 and increase by one, unless an allocator is given with
+// WithIDAllocator; on 64-bit platforms the counter cannot realistically wrap around.
+// See GenerateTasksContext for other bounds.
+func GenerateForever(ctx context.Context, tasks chan<- model.Task, opts ...Option) {
+	// The default bounds are always valid.
+	_ = GenerateTasksContext(ctx, -1, DefaultMin, DefaultMax, tasks, opts...)
+}
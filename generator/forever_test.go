@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+	"time"
+)
+
+func TestGenerateForever(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tasksChan := make(chan model.Task)
+	go GenerateForever(ctx, tasksChan)
+
+	const numTasks = 1000
+	for i := 0; i < numTasks; i++ {
+		task := <-tasksChan
+		if task.ID != i {
+			t.Errorf("Task ID out of sequence: got %v, want %v", task.ID, i)
+		}
+		if task.Value < 3 || task.Value > 1000 {
+			t.Errorf("Task value out of expected range: got %v, want between 3 and 1000", task.Value)
+		}
+	}
+
+	cancel()
+
+	// The generator may have one more task in flight, but must close the channel after cancellation.
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-tasksChan:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Tasks channel was not closed after the context was cancelled")
+		}
+	}
+}
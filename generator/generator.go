@@ -1,28 +1,99 @@
 package generator
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/lipcsei/konstruktor/model"
 	"math/rand"
 	"time"
 )
 
-// GenerateTasks generates a specified number of tasks with random values and sends them on a channel
-// Each task's value is randomly chosen between 3 and 1000, inclusive.
-func GenerateTasks(numTasks int, tasks chan<- model.Task) {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	for i := 0; i < numTasks; i++ {
-		// Generate a random number between 3 and 1000
-		randomNumber := int64(rand.Intn(998) + 3)
-
-		// Create a task
-		task := model.Task{
-			ID:    i,
-			Value: randomNumber,
-		}
-		// Send the new task
-		tasks <- task
+// DefaultMin and DefaultMax are the bounds of the task values generated by GenerateTasks.
+const (
+	DefaultMin = 3
+	DefaultMax = 1000
+)
+
+// ErrInvalidRange is returned for task value bounds that are negative or out of order.
+var ErrInvalidRange = errors.New("invalid task value range")
+
+// GenerateTasks generates a specified number of tasks with random values and sends them on a channel.
+// Each task's value is randomly chosen between 3 and 1000, inclusive. The values are drawn from a random
+// source of its own, seeded from the current time, so concurrent generators do not share any state.
+// The task IDs count up from 0, unless an allocator is given with WithIDAllocator.
+// See GenerateRandomTasks for other bounds.
+func GenerateTasks(numTasks int, tasks chan<- model.Task, opts ...Option) {
+	// The default bounds are always valid.
+	_ = GenerateRandomTasks(numTasks, rand.New(rand.NewSource(time.Now().UnixNano())), DefaultMin, DefaultMax, tasks, opts...)
+}
+
+// ValidateRange checks that min and max are valid bounds for task values: min must not be negative,
+// and must not be greater than max.
+func ValidateRange(min, max int64) error {
+	if min < 0 {
+		return fmt.Errorf("%w: min %d must not be negative", ErrInvalidRange, min)
 	}
+	if min > max {
+		return fmt.Errorf("%w: min %d is greater than max %d", ErrInvalidRange, min, max)
+	}
+	return nil
+}
+
+// randomValue draws a value from r, uniformly between min and max, inclusive. min must not be negative,
+// so the range only overflows if it spans every non-negative int64.
+func randomValue(r *rand.Rand, min, max int64) int64 {
+	if span := max - min + 1; span > 0 {
+		return min + r.Int63n(span)
+	}
+	return r.Int63()
+}
+
+// GenerateRandomTasks generates a specified number of tasks and sends them on a channel.
+// Each task's value is drawn from r, uniformly between min and max, inclusive. It returns an error wrapping
+// ErrInvalidRange without generating any task if min is negative or greater than max. The tasks channel is
+// closed in either case.
+func GenerateRandomTasks(numTasks int, r *rand.Rand, min, max int64, tasks chan<- model.Task, opts ...Option) error {
+	// Signal to processors that there are no more tasks.
+	defer close(tasks)
 
-	// Signal to processors that there are no more tasks
-	close(tasks)
+	if err := ValidateRange(min, max); err != nil {
+		return err
+	}
+
+	generate(context.Background(), numTasks, r, min, max, tasks, newConfig(opts))
+	return nil
+}
+
+// GenerateTasksWithSeed is like GenerateTasks, but draws the values from a random source seeded with seed,
+// so the same seed always yields the same tasks. This makes a workload reproducible across runs.
+func GenerateTasksWithSeed(numTasks int, seed int64, tasks chan<- model.Task, opts ...Option) {
+	// The default bounds are always valid.
+	_ = GenerateRandomTasks(numTasks, rand.New(rand.NewSource(seed)), DefaultMin, DefaultMax, tasks, opts...)
+}
+
+// GenerateSeededTasks generates the tasks of a batch and sends them on a channel. The values are drawn
+// like GenerateRandomTasks does from a random source seeded with batch.Seed, so the same batch always
+// yields the same tasks. Every task refers to the batch, which lets a result be traced back to the seed
+// that produced it. It returns an error wrapping ErrInvalidRange without generating any task if the
+// bounds of the batch are invalid. The tasks channel is closed in either case. The seed determines
+// the values only; the IDs count up from 0, unless an allocator is given with WithIDAllocator.
+func GenerateSeededTasks(batch model.Batch, tasks chan<- model.Task, opts ...Option) error {
+	// Signal to processors that there are no more tasks.
+	defer close(tasks)
+
+	if err := ValidateRange(batch.Min, batch.Max); err != nil {
+		return err
+	}
+
+	cfg := newConfig(opts)
+	r := rand.New(rand.NewSource(batch.Seed))
+	for i := 0; i < batch.NumTasks; i++ {
+		tasks <- model.Task{
+			ID:    cfg.ids.Next(),
+			Value: randomValue(r, batch.Min, batch.Max),
+			Batch: &batch,
+		}
+	}
+	return nil
 }
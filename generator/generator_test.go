@@ -1,7 +1,11 @@
 package generator
 
 import (
+	"errors"
+	"fmt"
 	"github.com/lipcsei/konstruktor/model"
+	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -23,3 +27,199 @@ func TestGenerateTasks(t *testing.T) {
 		t.Errorf("Incorrect number of tasks generated: got %v, want %v", generatedTasks, numTasks)
 	}
 }
+
+func TestGenerateRandomTasks_Ranges(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int64
+	}{
+		{"small range", 10, 20},
+		{"single value", 7, 7},
+		{"from zero", 0, 2},
+		{"large factorials", 5000, 10000},
+		{"every non-negative value", 0, math.MaxInt64},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			numTasks := 100
+			tasksChan := make(chan model.Task, numTasks)
+			if err := GenerateRandomTasks(numTasks, rand.New(rand.NewSource(42)), test.min, test.max, tasksChan); err != nil {
+				t.Fatalf("GenerateRandomTasks() returned an error: %v", err)
+			}
+
+			generatedTasks := 0
+			for task := range tasksChan {
+				generatedTasks++
+				if task.Value < test.min || task.Value > test.max {
+					t.Errorf("Task value out of expected range: got %v, want between %v and %v", task.Value, test.min, test.max)
+				}
+			}
+			if generatedTasks != numTasks {
+				t.Errorf("Incorrect number of tasks generated: got %v, want %v", generatedTasks, numTasks)
+			}
+		})
+	}
+}
+
+func TestGenerateRandomTasks(t *testing.T) {
+	numTasks := 100
+	generate := func(seed int64) []model.Task {
+		tasksChan := make(chan model.Task, numTasks)
+		if err := GenerateRandomTasks(numTasks, rand.New(rand.NewSource(seed)), 10, 20, tasksChan); err != nil {
+			t.Fatalf("GenerateRandomTasks() returned an error: %v", err)
+		}
+
+		var tasks []model.Task
+		for task := range tasksChan {
+			if task.Value < 10 || task.Value > 20 {
+				t.Errorf("Task value out of expected range: got %v, want between 10 and 20", task.Value)
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks
+	}
+
+	first, second := generate(42), generate(42)
+	if len(first) != numTasks {
+		t.Fatalf("Incorrect number of tasks generated: got %v, want %v", len(first), numTasks)
+	}
+	for i := range first {
+		if first[i].ID != second[i].ID || first[i].Value != second[i].Value {
+			t.Errorf("Task %d differs between runs with the same seed: %v and %v", i, first[i], second[i])
+		}
+	}
+}
+
+func TestGenerateRandomTasks_FullRange(t *testing.T) {
+	numTasks := 100
+	tasksChan := make(chan model.Task, numTasks)
+	if err := GenerateRandomTasks(numTasks, rand.New(rand.NewSource(42)), 0, math.MaxInt64, tasksChan); err != nil {
+		t.Fatalf("GenerateRandomTasks() returned an error: %v", err)
+	}
+
+	generatedTasks := 0
+	for task := range tasksChan {
+		generatedTasks++
+		if task.Value < 0 {
+			t.Errorf("Task value out of expected range: got %v, want between 0 and %v", task.Value, int64(math.MaxInt64))
+		}
+	}
+	if generatedTasks != numTasks {
+		t.Errorf("Incorrect number of tasks generated: got %v, want %v", generatedTasks, numTasks)
+	}
+}
+
+func TestGenerateRandomTasks_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int64
+	}{
+		{"negative min", -1, 10},
+		{"min above max", 20, 10},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			tasksChan := make(chan model.Task, 10)
+			err := GenerateRandomTasks(10, rand.New(rand.NewSource(42)), test.min, test.max, tasksChan)
+			if !errors.Is(err, ErrInvalidRange) {
+				t.Errorf("Expected ErrInvalidRange, got %v", err)
+			}
+			// No task is generated, and the channel is closed.
+			if task, ok := <-tasksChan; ok {
+				t.Errorf("Unexpected task generated for an invalid range: %v", task)
+			}
+		})
+	}
+}
+
+func TestGenerateTasksWithSeed(t *testing.T) {
+	numTasks := 100
+	generate := func(seed int64) []model.Task {
+		tasksChan := make(chan model.Task, numTasks)
+		GenerateTasksWithSeed(numTasks, seed, tasksChan)
+
+		var tasks []model.Task
+		for task := range tasksChan {
+			if task.Value < DefaultMin || task.Value > DefaultMax {
+				t.Errorf("Task value out of expected range: got %v, want between %v and %v", task.Value, DefaultMin, DefaultMax)
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks
+	}
+
+	first, second, other := generate(42), generate(42), generate(43)
+	if len(first) != numTasks || len(other) != numTasks {
+		t.Fatalf("Incorrect number of tasks generated: got %v and %v, want %v", len(first), len(other), numTasks)
+	}
+	differs := false
+	for i := range first {
+		if first[i].ID != second[i].ID || first[i].Value != second[i].Value {
+			t.Errorf("Task %d differs between runs with the same seed: %v and %v", i, first[i], second[i])
+		}
+		differs = differs || first[i].Value != other[i].Value
+	}
+	if !differs {
+		t.Errorf("Seeds 42 and 43 generated the same tasks")
+	}
+}
+
+func TestGenerateSeededTasks(t *testing.T) {
+	batch := model.Batch{Seed: 42, NumTasks: 50, Min: 10, Max: 20}
+
+	seededChan := make(chan model.Task, batch.NumTasks)
+	if err := GenerateSeededTasks(batch, seededChan); err != nil {
+		t.Fatalf("GenerateSeededTasks() returned an error: %v", err)
+	}
+	randomChan := make(chan model.Task, batch.NumTasks)
+	if err := GenerateRandomTasks(batch.NumTasks, rand.New(rand.NewSource(batch.Seed)), batch.Min, batch.Max, randomChan); err != nil {
+		t.Fatalf("GenerateRandomTasks() returned an error: %v", err)
+	}
+
+	generatedTasks := 0
+	for task := range seededChan {
+		generatedTasks++
+		if task.Batch == nil || *task.Batch != batch {
+			t.Errorf("Task %d refers to batch %v, want %v", task.ID, task.Batch, batch)
+		}
+		if expected := <-randomChan; task.Value != expected.Value {
+			t.Errorf("Task %d has value %d, want %d like GenerateRandomTasks with the same seed", task.ID, task.Value, expected.Value)
+		}
+	}
+
+	if generatedTasks != batch.NumTasks {
+		t.Errorf("Incorrect number of tasks generated: got %v, want %v", generatedTasks, batch.NumTasks)
+	}
+}
+
+func TestGenerateSeededTasks_FullRange(t *testing.T) {
+	batch := model.Batch{Seed: 42, NumTasks: 100, Min: 0, Max: math.MaxInt64}
+	tasksChan := make(chan model.Task, batch.NumTasks)
+	if err := GenerateSeededTasks(batch, tasksChan); err != nil {
+		t.Fatalf("GenerateSeededTasks() returned an error: %v", err)
+	}
+
+	generatedTasks := 0
+	for task := range tasksChan {
+		generatedTasks++
+		if task.Value < 0 {
+			t.Errorf("Task value out of expected range: got %v, want between 0 and %v", task.Value, batch.Max)
+		}
+	}
+	if generatedTasks != batch.NumTasks {
+		t.Errorf("Incorrect number of tasks generated: got %v, want %v", generatedTasks, batch.NumTasks)
+	}
+}
+
+func TestGenerateSeededTasks_Invalid(t *testing.T) {
+	batch := model.Batch{Seed: 42, NumTasks: 10, Min: 20, Max: 10}
+	tasksChan := make(chan model.Task, batch.NumTasks)
+	if err := GenerateSeededTasks(batch, tasksChan); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange, got %v", err)
+	}
+	if task, ok := <-tasksChan; ok {
+		t.Errorf("Unexpected task generated for an invalid range: %v", task)
+	}
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"math/rand"
+	"sync/atomic"
+)
+
+// IDAllocator hands out task IDs. Producers that feed the same pool must share an allocator,
+// so no two of their tasks get the same ID. Implementations must be safe for concurrent use.
+type IDAllocator interface {
+	// Next returns an ID that has not been returned before.
+	Next() int
+}
+
+// CounterAllocator is an IDAllocator that counts up from 0 without gaps, which keeps the IDs usable
+// as indexes into the results, as SortResults does. It is the default IDAllocator.
+type CounterAllocator struct {
+	next atomic.Int64
+}
+
+// NewCounterAllocator returns a CounterAllocator whose first ID is 0.
+func NewCounterAllocator() *CounterAllocator {
+	return &CounterAllocator{}
+}
+
+// Next returns the next ID of the counter.
+func (a *CounterAllocator) Next() int {
+	return int(a.next.Add(1) - 1)
+}
+
+// Option configures a generator.
+type Option func(*config)
+
+// config holds the settings of a generator.
+type config struct {
+	// ids assigns the IDs of the generated tasks.
+	ids IDAllocator
+}
+
+// newConfig applies the options on top of the defaults: a new CounterAllocator, so the IDs of the
+// tasks of every generator count up from 0 unless the generator is given an allocator.
+func newConfig(opts []Option) config {
+	cfg := config{ids: NewCounterAllocator()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithIDAllocator makes a generator take the IDs of its tasks from ids, so the tasks of several
+// generators, Submitters and pools sharing the allocator never get the same ID. A nil ids is ignored.
+func WithIDAllocator(ids IDAllocator) Option {
+	return func(c *config) {
+		if ids != nil {
+			c.ids = ids
+		}
+	}
+}
+
+// Submitter sends tasks with IDs from an IDAllocator on a channel, so several producers, each
+// with its own Submitter over the same allocator or all sharing one, can feed the same pool
+// without their IDs colliding. It never closes the channel; that is left to its owner once
+// every producer is done. It is safe for concurrent use.
+type Submitter struct {
+	ids   IDAllocator
+	tasks chan<- model.Task
+}
+
+// NewSubmitter returns a Submitter sending on tasks. A nil ids selects a new CounterAllocator.
+func NewSubmitter(ids IDAllocator, tasks chan<- model.Task) *Submitter {
+	if ids == nil {
+		ids = NewCounterAllocator()
+	}
+	return &Submitter{ids: ids, tasks: tasks}
+}
+
+// Submit sends a task for the value with the next ID and returns it. It blocks until the task is sent.
+func (s *Submitter) Submit(value int64) model.Task {
+	task := model.Task{ID: s.ids.Next(), Value: value}
+	s.tasks <- task
+	return task
+}
+
+// GenerateRandom submits numTasks tasks with values drawn from r, uniformly between min and max,
+// inclusive, like GenerateRandomTasks does. It returns an error wrapping ErrInvalidRange without
+// submitting any task if min is negative or greater than max.
+func (s *Submitter) GenerateRandom(numTasks int, r *rand.Rand, min, max int64) error {
+	if err := ValidateRange(min, max); err != nil {
+		return err
+	}
+	for i := 0; i < numTasks; i++ {
+		s.Submit(randomValue(r, min, max))
+	}
+	return nil
+}
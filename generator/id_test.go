@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCounterAllocator_Concurrent(t *testing.T) {
+	const numProducers = 8
+	const perProducer = 1000
+
+	ids := NewCounterAllocator()
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+	for p := 0; p < numProducers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				id := ids.Next()
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("ID %d allocated more than once", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The IDs are exactly 0 to n-1, so they can index the results.
+	for id := 0; id < numProducers*perProducer; id++ {
+		if !seen[id] {
+			t.Errorf("ID %d was never allocated", id)
+		}
+	}
+}
+
+func TestSubmitter_MultipleProducers(t *testing.T) {
+	const numTasks = 100
+
+	tasks := make(chan model.Task, 3*numTasks)
+	ids := NewCounterAllocator()
+
+	// Two generators and an interactive producer feed the same channel.
+	var wg sync.WaitGroup
+	for seed := int64(1); seed <= 2; seed++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			if err := NewSubmitter(ids, tasks).GenerateRandom(numTasks, rand.New(rand.NewSource(seed)), 3, 1000); err != nil {
+				t.Errorf("GenerateRandom() returned an error: %v", err)
+			}
+		}(seed)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		submitter := NewSubmitter(ids, tasks)
+		for i := 0; i < numTasks; i++ {
+			if task := submitter.Submit(5); task.Value != 5 {
+				t.Errorf("Submit(5) returned a task with value %d", task.Value)
+			}
+		}
+	}()
+	wg.Wait()
+	close(tasks)
+
+	seen := make(map[int]bool)
+	for task := range tasks {
+		if seen[task.ID] {
+			t.Errorf("Task ID %d submitted more than once", task.ID)
+		}
+		seen[task.ID] = true
+	}
+	if len(seen) != 3*numTasks {
+		t.Errorf("Received %d tasks, want %d", len(seen), 3*numTasks)
+	}
+}
+
+func TestSubmitter_GenerateRandom_Range(t *testing.T) {
+	tasks := make(chan model.Task, 100)
+	submitter := NewSubmitter(nil, tasks)
+
+	// Every non-negative value is a valid range.
+	if err := submitter.GenerateRandom(50, rand.New(rand.NewSource(42)), 0, math.MaxInt64); err != nil {
+		t.Fatalf("GenerateRandom() returned an error: %v", err)
+	}
+	if err := submitter.GenerateRandom(50, rand.New(rand.NewSource(42)), 20, 10); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange, got %v", err)
+	}
+	close(tasks)
+
+	submitted := 0
+	for task := range tasks {
+		submitted++
+		if task.Value < 0 {
+			t.Errorf("Task value out of expected range: got %v, want between 0 and %v", task.Value, int64(math.MaxInt64))
+		}
+	}
+	if submitted != 50 {
+		t.Errorf("Incorrect number of tasks submitted: got %v, want %v", submitted, 50)
+	}
+}
+
+func TestWithIDAllocator_Generators(t *testing.T) {
+	const numTasks = 50
+
+	ids := NewCounterAllocator()
+	generators := []func(chan<- model.Task){
+		func(tasks chan<- model.Task) { GenerateTasks(numTasks, tasks, WithIDAllocator(ids)) },
+		func(tasks chan<- model.Task) { GenerateTasksWithSeed(numTasks, 42, tasks, WithIDAllocator(ids)) },
+		func(tasks chan<- model.Task) {
+			GenerateSeededTasks(model.Batch{Seed: 42, NumTasks: numTasks, Min: 3, Max: 10}, tasks, WithIDAllocator(ids))
+		},
+		func(tasks chan<- model.Task) {
+			GenerateFromReader(strings.NewReader(strings.Repeat("5\n", numTasks)), tasks, WithIDAllocator(ids))
+		},
+	}
+
+	// Every generator closes its own channel; the IDs are unique across all of them.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	for _, generate := range generators {
+		tasks := make(chan model.Task)
+		go generate(tasks)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				mu.Lock()
+				if seen[task.ID] {
+					t.Errorf("Task ID %d generated more than once", task.ID)
+				}
+				seen[task.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != len(generators)*numTasks {
+		t.Errorf("Generated %d distinct IDs, want %d", len(seen), len(generators)*numTasks)
+	}
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GenerateFromReader reads one task value per line from r and sends a task for each of them on
+// the tasks channel, with IDs counting up from 0 unless an allocator is given with WithIDAllocator.
+// Surrounding whitespace and blank lines are ignored. It stops at the end of the input, or at the
+// first line that is not a non-negative integer, in which case it returns an error naming the line.
+// The tasks channel is closed in either case.
+func GenerateFromReader(r io.Reader, tasks chan<- model.Task, opts ...Option) error {
+	// Signal to processors that there are no more tasks.
+	defer close(tasks)
+
+	cfg := newConfig(opts)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid task value %q: %w", line, text, err)
+		}
+		if value < 0 {
+			return fmt.Errorf("line %d: task value %d must not be negative", line, value)
+		}
+
+		tasks <- model.Task{ID: cfg.ids.Next(), Value: value}
+	}
+	return scanner.Err()
+}
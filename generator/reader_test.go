@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// failingReader returns its error after the data has been read.
+type failingReader struct {
+	data string
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestGenerateFromReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []int64
+		wantErr  bool
+	}{
+		{"values", "5\n10\n20\n", []int64{5, 10, 20}, false},
+		{"no trailing newline", "5\n10", []int64{5, 10}, false},
+		{"whitespace and blank lines", "  5 \n\n\t10\r\n\n", []int64{5, 10}, false},
+		{"empty input", "", nil, false},
+		{"not a number", "5\nten\n20\n", []int64{5}, true},
+		{"negative value", "5\n-3\n", []int64{5}, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			tasks := make(chan model.Task, 10)
+			err := GenerateFromReader(strings.NewReader(test.input), tasks)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Expected error %t, got %v", test.wantErr, err)
+			}
+
+			var values []int64
+			for task := range tasks {
+				if task.ID != len(values) {
+					t.Errorf("Expected task ID %d, got %d", len(values), task.ID)
+				}
+				values = append(values, task.Value)
+			}
+			if fmt.Sprint(values) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected values %v, got %v", test.expected, values)
+			}
+		})
+	}
+}
+
+func TestGenerateFromReader_ErrorNamesLine(t *testing.T) {
+	tasks := make(chan model.Task, 10)
+	err := GenerateFromReader(strings.NewReader("5\n\nx\n"), tasks)
+
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected a parse error for line 3, got %v", err)
+	}
+}
+
+func TestGenerateFromReader_ReadError(t *testing.T) {
+	errRead := errors.New("read failed")
+	tasks := make(chan model.Task, 10)
+	err := GenerateFromReader(&failingReader{data: "5\n", err: errRead}, tasks)
+	if !errors.Is(err, errRead) {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+	if task, ok := <-tasks; !ok || task.Value != 5 {
+		t.Errorf("Expected the task read before the error, got %v", task)
+	}
+}
@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/metrics"
+	"github.com/lipcsei/konstruktor/model"
+	"os"
+	"time"
+)
+
+// promRecorder stands in for an adapter onto the collectors of the Prometheus client library.
+// With github.com/prometheus/client_golang, it would hold a prometheus.Counter, a prometheus.Histogram
+// and a prometheus.Gauge registered with prometheus.MustRegister, and IncProcessed would call
+// counter.Inc(), ObserveDuration histogram.Observe(d.Seconds()) and SetBusyWorkers gauge.Set(float64(n)),
+// with promhttp.Handler() serving /metrics.
+type promRecorder struct {
+	processed int
+	seconds   float64
+	busy      int
+}
+
+func (p *promRecorder) IncProcessed()                   { p.processed++ }
+func (p *promRecorder) ObserveDuration(d time.Duration) { p.seconds += d.Seconds() }
+func (p *promRecorder) SetBusyWorkers(n int)            { p.busy = n }
+
+func Example() {
+	rec := &promRecorder{}
+	observe := metrics.Observer(rec)
+
+	// A pool installs the observer with metrics.WithMetrics(rec); here a worker reports a single task.
+	done := observe(0, model.Task{ID: 0, Value: 5})
+	fmt.Printf("busy workers: %d\n", rec.busy)
+	done(model.Result{}, 250*time.Millisecond)
+	fmt.Printf("processed: %d, seconds: %g, busy workers: %d\n", rec.processed, rec.seconds, rec.busy)
+	// Output:
+	// busy workers: 1
+	// processed: 1, seconds: 0.25, busy workers: 0
+}
+
+func ExampleRegistry() {
+	registry := metrics.NewRegistry(0.1, 1)
+	registry.IncProcessed()
+	registry.ObserveDuration(250 * time.Millisecond)
+
+	// A server would serve the registry with http.Handle("/metrics", registry).
+	registry.WriteTo(os.Stdout)
+	// Output:
+	// # HELP konstruktor_tasks_processed_total Number of tasks processed, whether they succeeded or failed.
+	// # TYPE konstruktor_tasks_processed_total counter
+	// konstruktor_tasks_processed_total 1
+	// # HELP konstruktor_task_duration_seconds Time it took to process a task.
+	// # TYPE konstruktor_task_duration_seconds histogram
+	// konstruktor_task_duration_seconds_bucket{le="0.1"} 0
+	// konstruktor_task_duration_seconds_bucket{le="1"} 1
+	// konstruktor_task_duration_seconds_bucket{le="+Inf"} 1
+	// konstruktor_task_duration_seconds_sum 0.25
+	// konstruktor_task_duration_seconds_count 1
+	// # HELP konstruktor_busy_workers Number of workers processing a task.
+	// # TYPE konstruktor_busy_workers gauge
+	// konstruktor_busy_workers 0
+}
@@ -0,0 +1,181 @@
+// Package metrics reports the processing of the tasks of a worker.Pool to a metrics backend, such as
+// Prometheus. It depends only on the small Recorder interface below rather than on a Prometheus client,
+// keeping the core packages free of metrics dependencies. Registry implements Recorder and serves the
+// metrics in the Prometheus text exposition format, so it can be scraped without any client library.
+// The package provides no adapter for a Prometheus client: one of a few lines that maps Recorder onto
+// the collectors of a client is shown in example_test.go only, as it would add the client dependency.
+package metrics
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/worker"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The names of the metrics served by a Registry.
+const (
+	NameProcessed   = "konstruktor_tasks_processed_total"
+	NameDuration    = "konstruktor_task_duration_seconds"
+	NameBusyWorkers = "konstruktor_busy_workers"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the duration histogram of a Registry.
+// They are those of the Prometheus client's default histogram.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Recorder receives the metrics of a pool. Its methods are called from the worker goroutines, so
+// it must be safe for concurrent use.
+type Recorder interface {
+	// IncProcessed counts a processed task, whether it succeeded or failed.
+	IncProcessed()
+	// ObserveDuration records how long processing a task took.
+	ObserveDuration(d time.Duration)
+	// SetBusyWorkers reports the number of workers processing a task right now. Unlike
+	// worker.Pool.ActiveWorkers, it does not count the idle workers of a pool.
+	SetBusyWorkers(n int)
+}
+
+// Observer returns a worker.TaskObserver that reports every task to the recorder: the number of busy
+// workers when a worker starts and finishes processing it, and once it has been processed, its duration
+// and a processed count. The busy workers are counted across every pool sharing the observer.
+func Observer(rec Recorder) worker.TaskObserver {
+	var mu sync.Mutex
+	busy := 0
+	// setBusy changes the busy count and reports it while holding the lock, so the reports of
+	// concurrent workers cannot overtake each other.
+	setBusy := func(delta int) {
+		mu.Lock()
+		defer mu.Unlock()
+		busy += delta
+		rec.SetBusyWorkers(busy)
+	}
+
+	return func(workerID int, task model.Task) func(model.Result, time.Duration) {
+		setBusy(1)
+		return func(r model.Result, duration time.Duration) {
+			rec.ObserveDuration(duration)
+			rec.IncProcessed()
+			setBusy(-1)
+		}
+	}
+}
+
+// WithMetrics returns a pool option that reports every task to the recorder with Observer.
+// It adds to any other task observer of the pool, such as one installed by the tracing package.
+func WithMetrics(rec Recorder) worker.Option {
+	return worker.WithTaskObserver(Observer(rec))
+}
+
+// Registry is a Recorder that keeps the metrics in memory and serves them over HTTP in the Prometheus
+// text exposition format: a counter of processed tasks, a histogram of their durations and a gauge of
+// the busy workers. It is safe for concurrent use.
+type Registry struct {
+	mu sync.Mutex
+	// processed counts the processed tasks.
+	processed uint64
+	// buckets are the upper bounds of the histogram, and counts the number of durations in each of
+	// them, not cumulated. sum and count add up every duration.
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+	// busy is the number of workers processing a task.
+	busy int
+}
+
+// NewRegistry returns an empty Registry whose duration histogram has the given bucket upper bounds in
+// seconds, which must be sorted in increasing order. No buckets select DefaultBuckets.
+func NewRegistry(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return &Registry{
+		buckets: append([]float64(nil), buckets...),
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// IncProcessed counts a processed task.
+func (r *Registry) IncProcessed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processed++
+}
+
+// ObserveDuration adds the duration to the histogram.
+func (r *Registry) ObserveDuration(d time.Duration) {
+	seconds := d.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			r.counts[i]++
+			break
+		}
+	}
+	r.sum += seconds
+	r.count++
+}
+
+// SetBusyWorkers sets the gauge of the busy workers.
+func (r *Registry) SetBusyWorkers(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.busy = n
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	fmt.Fprintf(cw, "# HELP %s Number of tasks processed, whether they succeeded or failed.\n", NameProcessed)
+	fmt.Fprintf(cw, "# TYPE %s counter\n", NameProcessed)
+	fmt.Fprintf(cw, "%s %d\n", NameProcessed, r.processed)
+
+	fmt.Fprintf(cw, "# HELP %s Time it took to process a task.\n", NameDuration)
+	fmt.Fprintf(cw, "# TYPE %s histogram\n", NameDuration)
+	var cumulative uint64
+	for i, bound := range r.buckets {
+		cumulative += r.counts[i]
+		fmt.Fprintf(cw, "%s_bucket{le=\"%g\"} %d\n", NameDuration, bound, cumulative)
+	}
+	fmt.Fprintf(cw, "%s_bucket{le=\"+Inf\"} %d\n", NameDuration, r.count)
+	fmt.Fprintf(cw, "%s_sum %g\n", NameDuration, r.sum)
+	fmt.Fprintf(cw, "%s_count %d\n", NameDuration, r.count)
+
+	fmt.Fprintf(cw, "# HELP %s Number of workers processing a task.\n", NameBusyWorkers)
+	fmt.Fprintf(cw, "# TYPE %s gauge\n", NameBusyWorkers)
+	fmt.Fprintf(cw, "%s %d\n", NameBusyWorkers, r.busy)
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics for a Prometheus scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// countingWriter counts the bytes written to w and keeps the first error, so a sequence of writes
+// can be checked once at the end.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
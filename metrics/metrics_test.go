@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/worker"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingRecorder records every call of the Recorder methods.
+type recordingRecorder struct {
+	mu        sync.Mutex
+	processed int
+	durations []time.Duration
+	busy      []int
+}
+
+func (r *recordingRecorder) IncProcessed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processed++
+}
+
+func (r *recordingRecorder) ObserveDuration(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations = append(r.durations, d)
+}
+
+func (r *recordingRecorder) SetBusyWorkers(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.busy = append(r.busy, n)
+}
+
+func TestObserver(t *testing.T) {
+	rec := &recordingRecorder{}
+	observe := Observer(rec)
+
+	// Two workers overlap, then the first finishes before the second.
+	task := model.Task{ID: 1, Value: 5}
+	first := observe(0, task)
+	second := observe(1, task)
+	first(model.Result{Task: task}, 3*time.Millisecond)
+	second(model.Result{Task: task}, 5*time.Millisecond)
+
+	if rec.processed != 2 {
+		t.Errorf("Expected 2 processed tasks, got %d", rec.processed)
+	}
+	if len(rec.durations) != 2 || rec.durations[0] != 3*time.Millisecond || rec.durations[1] != 5*time.Millisecond {
+		t.Errorf("Expected durations [3ms 5ms], got %v", rec.durations)
+	}
+	if expected := []int{1, 2, 1, 0}; !reflect.DeepEqual(rec.busy, expected) {
+		t.Errorf("Expected the busy workers %v, got %v", expected, rec.busy)
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	const numTasks = 20
+	rec := &recordingRecorder{}
+	pool, err := worker.NewPool(worker.Config{Workers: 4, ThresholdFactor: 1e9}, WithMetrics(rec))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 50}
+	}
+	close(tasks)
+	pool.Start(tasks)
+	for range pool.Results() {
+	}
+
+	// Every task is reported once, and the gauge stays within the pool size and ends at 0.
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.processed != numTasks || len(rec.durations) != numTasks {
+		t.Errorf("Expected %d processed tasks and durations, got %d and %d", numTasks, rec.processed, len(rec.durations))
+	}
+	for _, n := range rec.busy {
+		if n < 0 || n > 4 {
+			t.Errorf("Expected between 0 and 4 busy workers, got %d", n)
+		}
+	}
+	if last := rec.busy[len(rec.busy)-1]; last != 0 {
+		t.Errorf("Expected no busy workers at the end, got %d", last)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(0.01, 0.1, 1)
+	r.IncProcessed()
+	r.IncProcessed()
+	r.IncProcessed()
+	r.ObserveDuration(5 * time.Millisecond)
+	r.ObserveDuration(50 * time.Millisecond)
+	r.ObserveDuration(2 * time.Second)
+	r.SetBusyWorkers(3)
+
+	var out strings.Builder
+	n, err := r.WriteTo(&out)
+	if err != nil {
+		t.Fatalf("WriteTo() returned an error: %v", err)
+	}
+	if n != int64(out.Len()) {
+		t.Errorf("WriteTo() reported %d bytes, wrote %d", n, out.Len())
+	}
+
+	expected := `# HELP konstruktor_tasks_processed_total Number of tasks processed, whether they succeeded or failed.
+# TYPE konstruktor_tasks_processed_total counter
+konstruktor_tasks_processed_total 3
+# HELP konstruktor_task_duration_seconds Time it took to process a task.
+# TYPE konstruktor_task_duration_seconds histogram
+konstruktor_task_duration_seconds_bucket{le="0.01"} 1
+konstruktor_task_duration_seconds_bucket{le="0.1"} 2
+konstruktor_task_duration_seconds_bucket{le="1"} 2
+konstruktor_task_duration_seconds_bucket{le="+Inf"} 3
+konstruktor_task_duration_seconds_sum 2.055
+konstruktor_task_duration_seconds_count 3
+# HELP konstruktor_busy_workers Number of workers processing a task.
+# TYPE konstruktor_busy_workers gauge
+konstruktor_busy_workers 3
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.IncProcessed()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format, got content type %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "konstruktor_tasks_processed_total 1\n") ||
+		!strings.Contains(body, `konstruktor_task_duration_seconds_bucket{le="10"} 0`) {
+		t.Errorf("Unexpected body with the default buckets:\n%s", body)
+	}
+}
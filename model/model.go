@@ -9,14 +9,49 @@ type Task struct {
 	ID int
 	// Value specifies the number for which the factorial is to be calculated.
 	Value int64
+	// DependsOn lists the IDs of the tasks that must produce their results before this task may start.
+	DependsOn []int
+	// GroupID assigns the task to a group of related tasks whose results are combined, see
+	// worker.GroupCollector. It is empty for tasks that belong to no group.
+	GroupID string
+	// Priority orders the queued tasks of a pool that schedules by priority, see worker.WithTaskPriority:
+	// tasks with a higher priority are dispatched first. It is ignored by the default FIFO scheduling.
+	Priority int
+	// Batch describes the seeded batch the task was generated in, so the task can be generated again.
+	// It is nil for tasks that were not generated from a seed.
+	Batch *Batch
+}
+
+// Batch records everything needed to regenerate a batch of seeded random tasks exactly.
+// It travels with every task of the batch, and so with every result.
+type Batch struct {
+	// Seed is the seed of the random source the task values were drawn from.
+	Seed int64
+	// NumTasks is the number of tasks in the batch.
+	NumTasks int
+	// Min and Max are the inclusive range of the task values.
+	Min, Max int64
 }
 
 // Result represents the outcome of processing a Task, including its factorial result.
 type Result struct {
 	// Task is the original task that was processed.
 	Task Task
-	// Factorial is the calculated factorial of the task's value.
+	// Factorial is the calculated factorial of the task's value. It is nil if Err is set.
 	Factorial *big.Int
 	// WorkerID identifies the worker that completed processing the task.
 	WorkerID int
+	// Err is set if the task failed: it exceeded the processing time limit, its deadline or its bit
+	// budget, its value was negative, its result was rejected by a validation hook, or its processing
+	// panicked. Factorial is nil then.
+	Err error
+	// Factorization maps every prime factor of the factorial to its exponent.
+	// It is only set if the pool was configured to compute it, and only for successful results.
+	Factorization map[int64]int64
+	// Retried reports whether the task exceeded the processing time limit and was recomputed
+	// with an extended time allowance.
+	Retried bool
+	// Attempts is the number of times the task was processed: 1, plus the retries after failures, see
+	// worker.WithRetries. It is 0 for a result that was not processed by a worker.
+	Attempts int
 }
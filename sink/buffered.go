@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+	"time"
+)
+
+// BufferedSink accumulates results and writes them to an inner sink in batches, reducing the number
+// of writes to file or network sinks. A batch is flushed as soon as it holds n results, and at least
+// every interval while results are pending, whichever comes first.
+type BufferedSink struct {
+	inner ResultSink
+	n     int
+
+	mu sync.Mutex
+	// buf holds the results that have not been flushed yet.
+	buf []model.Result
+	// err is the error of a flush triggered by the timer, reported by the next Write or Close.
+	err error
+
+	// stop terminates the timer goroutine, done is closed once it has exited.
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBufferedSink returns a BufferedSink flushing to inner every n results or every interval.
+// An n of 1 or less flushes every result immediately; an interval of 0 or less disables the
+// timer, so results are only flushed by count and on Close.
+func NewBufferedSink(inner ResultSink, n int, interval time.Duration) *BufferedSink {
+	s := &BufferedSink{
+		inner: inner,
+		n:     n,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	if interval <= 0 {
+		close(s.done)
+		return s
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.mu.Lock()
+				if err := s.flush(); err != nil && s.err == nil {
+					s.err = err
+				}
+				s.mu.Unlock()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Write buffers the results, flushing them to the inner sink once n results are pending.
+// It returns the error of the flush, or of an earlier flush triggered by the timer.
+func (s *BufferedSink) Write(results []model.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.takeErr(); err != nil {
+		return err
+	}
+
+	s.buf = append(s.buf, results...)
+	if len(s.buf) >= s.n {
+		return s.flush()
+	}
+	return nil
+}
+
+// Close stops the timer, flushes the remaining results and closes the inner sink.
+func (s *BufferedSink) Close() error {
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.takeErr()
+	if flushErr := s.flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := s.inner.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// flush writes the buffered results to the inner sink. The caller must hold the lock.
+func (s *BufferedSink) flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	batch := s.buf
+	s.buf = nil
+	return s.inner.Write(batch)
+}
+
+// takeErr returns and clears the error of a flush triggered by the timer. The caller must hold the lock.
+func (s *BufferedSink) takeErr() error {
+	err := s.err
+	s.err = nil
+	return err
+}
@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink records the size of every batch written to it.
+type recordingSink struct {
+	mu      sync.Mutex
+	batches []int
+	closed  bool
+}
+
+func (s *recordingSink) Write(results []model.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, len(results))
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *recordingSink) snapshot() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.batches...)
+}
+
+func TestBufferedSink_CountTrigger(t *testing.T) {
+	inner := &recordingSink{}
+	s := NewBufferedSink(inner, 3, 0)
+
+	for i := 0; i < 7; i++ {
+		if err := s.Write([]model.Result{{Task: model.Task{ID: i}}}); err != nil {
+			t.Fatalf("Write() returned an error: %v", err)
+		}
+	}
+
+	if batches := inner.snapshot(); len(batches) != 2 || batches[0] != 3 || batches[1] != 3 {
+		t.Errorf("Batches before Close = %v, want [3 3]", batches)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() returned an error: %v", err)
+	}
+	if batches := inner.snapshot(); len(batches) != 3 || batches[2] != 1 {
+		t.Errorf("Batches after Close = %v, want [3 3 1]", batches)
+	}
+	if !inner.closed {
+		t.Errorf("Close() did not close the inner sink")
+	}
+}
+
+func TestBufferedSink_TimeTrigger(t *testing.T) {
+	inner := &recordingSink{}
+	s := NewBufferedSink(inner, 100, 10*time.Millisecond)
+	defer s.Close()
+
+	if err := s.Write([]model.Result{{}, {}}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(inner.snapshot()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if batches := inner.snapshot(); len(batches) != 1 || batches[0] != 2 {
+		t.Errorf("Batches = %v, want the 2 pending results flushed by the timer", batches)
+	}
+}
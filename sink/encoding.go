@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"encoding/json"
+	"github.com/lipcsei/konstruktor/model"
+	"io"
+)
+
+// Encoder serializes a single result into a message.
+type Encoder func(r model.Result) ([]byte, error)
+
+// jsonResult is the JSON representation of a result. The factorial is a decimal string, since
+// most JSON consumers cannot represent numbers that large, and the error is its message.
+type jsonResult struct {
+	ID        int    `json:"id"`
+	Value     int64  `json:"value"`
+	Factorial string `json:"factorial,omitempty"`
+	WorkerID  int    `json:"worker_id"`
+	Retried   bool   `json:"retried,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// EncodeJSON is an Encoder that serializes a result as a JSON object with the task ID, the task value,
+// the worker ID and, if set, the factorial as a decimal string, the retried flag and the error message.
+func EncodeJSON(r model.Result) ([]byte, error) {
+	encoded := jsonResult{
+		ID:       r.Task.ID,
+		Value:    r.Task.Value,
+		WorkerID: r.WorkerID,
+		Retried:  r.Retried,
+	}
+	if r.Factorial != nil {
+		encoded.Factorial = r.Factorial.String()
+	}
+	if r.Err != nil {
+		encoded.Error = r.Err.Error()
+	}
+	return json.Marshal(encoded)
+}
+
+// WriteJSONArray writes the results to w as a JSON array of the objects EncodeJSON produces, one per line,
+// with a single call to w. No results are written as an empty array.
+func WriteJSONArray(w io.Writer, results []model.Result) error {
+	buf := []byte("[")
+	for i, r := range results {
+		encoded, err := EncodeJSON(r)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, "\n  "...)
+		buf = append(buf, encoded...)
+	}
+	if len(results) > 0 {
+		buf = append(buf, '\n')
+	}
+	buf = append(buf, "]\n"...)
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,82 @@
+package sink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"strings"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   model.Result
+		expected string
+	}{
+		{"success", model.Result{Task: model.Task{ID: 1, Value: 5}, Factorial: utils.CalcFactorial(5), WorkerID: 2},
+			`{"id":1,"value":5,"factorial":"120","worker_id":2}`},
+		{"retried", model.Result{Task: model.Task{ID: 2, Value: 3}, Factorial: utils.CalcFactorial(3), WorkerID: 0, Retried: true},
+			`{"id":2,"value":3,"factorial":"6","worker_id":0,"retried":true}`},
+		{"failure", model.Result{Task: model.Task{ID: 3, Value: 500}, WorkerID: 1, Err: errors.New("rejected")},
+			`{"id":3,"value":500,"worker_id":1,"error":"rejected"}`},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			encoded, err := EncodeJSON(test.result)
+			if err != nil {
+				t.Fatalf("EncodeJSON() returned an error: %v", err)
+			}
+			if string(encoded) != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, encoded)
+			}
+		})
+	}
+}
+
+func TestWriteJSONArray(t *testing.T) {
+	// 30! exceeds the precision of a float64, so it must survive as a decimal string.
+	results := []model.Result{
+		{Task: model.Task{ID: 0, Value: 30}, Factorial: utils.CalcFactorial(30), WorkerID: 1},
+		{Task: model.Task{ID: 1, Value: 1000}, WorkerID: 0, Err: errors.New("task exceeded the processing time limit")},
+	}
+
+	var out strings.Builder
+	if err := WriteJSONArray(&out, results); err != nil {
+		t.Fatalf("WriteJSONArray() returned an error: %v", err)
+	}
+
+	var decoded []struct {
+		ID        int    `json:"id"`
+		Value     int64  `json:"value"`
+		Factorial string `json:"factorial"`
+		WorkerID  int    `json:"worker_id"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatalf("The output is not a JSON array: %v\n%s", err, out.String())
+	}
+	if len(decoded) != len(results) {
+		t.Fatalf("Expected %d objects, got %d", len(results), len(decoded))
+	}
+	if first := decoded[0]; first.ID != 0 || first.Value != 30 || first.WorkerID != 1 || first.Error != "" ||
+		first.Factorial != "265252859812191058636308480000000" {
+		t.Errorf("Unexpected first object %+v", first)
+	}
+	if second := decoded[1]; second.ID != 1 || second.Factorial != "" || second.Error != results[1].Err.Error() {
+		t.Errorf("Unexpected second object %+v", second)
+	}
+}
+
+func TestWriteJSONArray_Empty(t *testing.T) {
+	var out strings.Builder
+	if err := WriteJSONArray(&out, nil); err != nil {
+		t.Fatalf("WriteJSONArray() returned an error: %v", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", out.String())
+	}
+}
@@ -0,0 +1,53 @@
+package sink
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"io"
+)
+
+// Publisher sends messages to an external queue or message broker, e.g. a Kafka topic or a NATS subject.
+// Implementations wrap the client of the broker, which stays out of this package. If a Publisher is
+// also an io.Closer, the QueueSink closes it.
+type Publisher interface {
+	// Publish sends a single message. It returns once the broker has accepted it, or with an error.
+	Publish(message []byte) error
+}
+
+// QueueSink publishes every result as a separate message to an external queue, so downstream consumers
+// receive the results directly. Results are published in the order they are written.
+type QueueSink struct {
+	publisher Publisher
+	encode    Encoder
+}
+
+// NewQueueSink returns a QueueSink publishing the results encoded by encode, or by EncodeJSON if it is nil.
+func NewQueueSink(publisher Publisher, encode Encoder) *QueueSink {
+	if encode == nil {
+		encode = EncodeJSON
+	}
+	return &QueueSink{publisher: publisher, encode: encode}
+}
+
+// Write encodes and publishes the results one by one. It stops at the first result that cannot be
+// encoded or published and returns the error, so the results after it are not published.
+func (s *QueueSink) Write(results []model.Result) error {
+	for _, r := range results {
+		message, err := s.encode(r)
+		if err != nil {
+			return fmt.Errorf("encoding the result of task %d: %w", r.Task.ID, err)
+		}
+		if err := s.publisher.Publish(message); err != nil {
+			return fmt.Errorf("publishing the result of task %d: %w", r.Task.ID, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the publisher if it is an io.Closer.
+func (s *QueueSink) Close() error {
+	if c, ok := s.publisher.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package sink
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"math/big"
+	"testing"
+)
+
+// fakeQueue is an in-memory Publisher that fails once it holds limit messages.
+type fakeQueue struct {
+	messages []string
+	limit    int
+	closed   bool
+}
+
+func (q *fakeQueue) Publish(message []byte) error {
+	if len(q.messages) >= q.limit {
+		return errors.New("queue full")
+	}
+	q.messages = append(q.messages, string(message))
+	return nil
+}
+
+func (q *fakeQueue) Close() error {
+	q.closed = true
+	return nil
+}
+
+func TestQueueSink(t *testing.T) {
+	queue := &fakeQueue{limit: 10}
+	s := NewQueueSink(queue, nil)
+
+	results := []model.Result{
+		{Task: model.Task{ID: 0, Value: 3}, Factorial: big.NewInt(6), WorkerID: 2},
+		{Task: model.Task{ID: 1, Value: 500}, WorkerID: 1, Retried: true, Err: errors.New("rejected")},
+	}
+	if err := s.Write(results); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() returned an error: %v", err)
+	}
+
+	expected := []string{
+		`{"id":0,"value":3,"factorial":"6","worker_id":2}`,
+		`{"id":1,"value":500,"worker_id":1,"retried":true,"error":"rejected"}`,
+	}
+	if len(queue.messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d", len(expected), len(queue.messages))
+	}
+	for i, message := range queue.messages {
+		if message != expected[i] {
+			t.Errorf("Expected message %d to be %s, got %s", i, expected[i], message)
+		}
+	}
+	if !queue.closed {
+		t.Errorf("Expected Close() to close the publisher")
+	}
+}
+
+func TestQueueSink_PublishError(t *testing.T) {
+	queue := &fakeQueue{limit: 1}
+	s := NewQueueSink(queue, nil)
+
+	results := []model.Result{
+		{Task: model.Task{ID: 0, Value: 3}, Factorial: big.NewInt(6)},
+		{Task: model.Task{ID: 1, Value: 4}, Factorial: big.NewInt(24)},
+		{Task: model.Task{ID: 2, Value: 5}, Factorial: big.NewInt(120)},
+	}
+	if err := s.Write(results); err == nil {
+		t.Fatalf("Expected Write() to return the error of the full queue")
+	}
+	if len(queue.messages) != 1 {
+		t.Errorf("Expected publishing to stop at the first error, got %d messages", len(queue.messages))
+	}
+}
+
+func TestQueueSink_CustomEncoder(t *testing.T) {
+	queue := &fakeQueue{limit: 10}
+	encode := func(r model.Result) ([]byte, error) { return []byte(r.Factorial.String()), nil }
+	s := NewQueueSink(queue, encode)
+
+	if err := s.Write([]model.Result{{Task: model.Task{Value: 5}, Factorial: big.NewInt(120)}}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if len(queue.messages) != 1 || queue.messages[0] != "120" {
+		t.Errorf("Expected the message \"120\", got %v", queue.messages)
+	}
+}
@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"io"
+)
+
+// ResultSink consumes processed results, e.g. by writing them to a file or sending them over the network.
+type ResultSink interface {
+	// Write consumes a batch of results.
+	Write(results []model.Result) error
+	// Close flushes anything still pending and releases the sink's resources.
+	Close() error
+}
+
+// WriterSink writes every result as a line of text to an io.Writer.
+// Each line holds the task ID, the task value and the factorial, separated by spaces.
+// A failed result, which has no factorial, is written with a factorial of 0.
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink returns a WriterSink writing to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write writes the batch of results with a single call to the underlying writer.
+func (s *WriterSink) Write(results []model.Result) error {
+	var buf []byte
+	for _, r := range results {
+		if r.Factorial == nil {
+			buf = fmt.Appendf(buf, "%d %d 0\n", r.Task.ID, r.Task.Value)
+			continue
+		}
+		buf = fmt.Appendf(buf, "%d %d %s\n", r.Task.ID, r.Task.Value, r.Factorial)
+	}
+	_, err := s.w.Write(buf)
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *WriterSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package sink
+
+import (
+	"bytes"
+	"github.com/lipcsei/konstruktor/model"
+	"math/big"
+	"testing"
+)
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewWriterSink(&buf)
+
+	results := []model.Result{
+		{Task: model.Task{ID: 0, Value: 3}, Factorial: big.NewInt(6)},
+		{Task: model.Task{ID: 1, Value: 5}, Factorial: big.NewInt(120)},
+	}
+	if err := s.Write(results); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() returned an error: %v", err)
+	}
+
+	expected := "0 3 6\n1 5 120\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
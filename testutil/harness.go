@@ -0,0 +1,60 @@
+// Package testutil provides helpers for writing fast, deterministic tests against a worker.Pool.
+package testutil
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/worker"
+	"time"
+)
+
+// Harness runs the tasks of a pool inline on the test goroutine against a fake clock, so tests can
+// assert the exact order and timing of the results without sleeping or racing goroutines.
+//
+// The processing time history that drives the adaptive time limit is that of the pool's first worker,
+// which processes every task and keeps its history between calls of Run, so tests relying on timeouts
+// should seed it with tasks of a known cost first.
+type Harness struct {
+	// Clock is the fake clock of the pool's workers.
+	Clock *FakeClock
+	// Pool is the pool under test. It is only run through Run, never started.
+	Pool *worker.Pool
+	// Cost returns how long processing a task takes. Every computation of the task, including
+	// a retry, advances the clock by its cost. Nil means every task is instantaneous.
+	Cost func(model.Task) time.Duration
+}
+
+// NewHarness creates a Harness around a pool built from cfg and opts. The pool's clock is replaced
+// with a fake clock, and a task hook that advances it by the cost of each task is installed, so
+// cfg must not rely on its own Clock or TaskHook.
+func NewHarness(cfg worker.Config, opts ...worker.Option) (*Harness, error) {
+	h := &Harness{Clock: NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))}
+
+	cfg.Clock = h.Clock
+	opts = append(opts, worker.WithTaskHook(func(task model.Task) {
+		if h.Cost != nil {
+			h.Clock.Advance(h.Cost(task))
+		}
+	}))
+
+	pool, err := worker.NewPool(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	h.Pool = pool
+	return h, nil
+}
+
+// Run processes the tasks synchronously and returns their results in the order they were processed.
+// It may be called repeatedly; the pool's scheduler, statistics and cache carry over between calls.
+func (h *Harness) Run(tasks ...model.Task) []model.Result {
+	return h.Pool.RunInline(tasks)
+}
+
+// Tasks returns one task per value, with IDs counting up from 0.
+func Tasks(values ...int64) []model.Task {
+	tasks := make([]model.Task, len(values))
+	for i, v := range values {
+		tasks[i] = model.Task{ID: i, Value: v}
+	}
+	return tasks
+}
@@ -0,0 +1,90 @@
+package testutil
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"github.com/lipcsei/konstruktor/worker"
+	"testing"
+	"time"
+)
+
+// priorityScheduler dispatches the task with the smallest value first.
+type priorityScheduler struct {
+	tasks []model.Task
+}
+
+func (s *priorityScheduler) Push(task model.Task) {
+	s.tasks = append(s.tasks, task)
+}
+
+func (s *priorityScheduler) Pop() (model.Task, bool) {
+	if len(s.tasks) == 0 {
+		return model.Task{}, false
+	}
+	next := 0
+	for i, task := range s.tasks {
+		if task.Value < s.tasks[next].Value {
+			next = i
+		}
+	}
+	task := s.tasks[next]
+	s.tasks = append(s.tasks[:next], s.tasks[next+1:]...)
+	return task, true
+}
+
+func TestHarness_SchedulingOrder(t *testing.T) {
+	h, err := NewHarness(worker.Config{}, worker.WithScheduler(&priorityScheduler{}))
+	if err != nil {
+		t.Fatalf("NewHarness() returned an error: %v", err)
+	}
+
+	results := h.Run(Tasks(12, 3, 7, 5)...)
+
+	expected := []int64{3, 5, 7, 12}
+	for i, v := range expected {
+		if results[i].Task.Value != v {
+			t.Errorf("Result %d is for %d!, want %d!", i, results[i].Task.Value, v)
+		}
+		if results[i].Factorial.Cmp(utils.CalcFactorial(v)) != 0 {
+			t.Errorf("%d! = %v, want %v", v, results[i].Factorial, utils.CalcFactorial(v))
+		}
+	}
+}
+
+func TestHarness_TimeoutAndRetry(t *testing.T) {
+	h, err := NewHarness(worker.Config{}, worker.WithSlowTaskRetry(3))
+	if err != nil {
+		t.Fatalf("NewHarness() returned an error: %v", err)
+	}
+	// Every task takes a millisecond per unit of its value.
+	h.Cost = func(task model.Task) time.Duration {
+		return time.Duration(task.Value) * time.Millisecond
+	}
+
+	// Seed the processing time history with 20 tasks of 10ms.
+	warmup := make([]int64, 20)
+	for i := range warmup {
+		warmup[i] = 10
+	}
+	h.Run(Tasks(warmup...)...)
+
+	// The threshold is 11ms: 12ms exceeds it but its retry fits into 3 * 11ms, 40ms does not.
+	start := h.Clock.Now()
+	results := h.Run(Tasks(10, 12, 40)...)
+
+	if results[0].Retried || results[0].Factorial.Cmp(utils.CalcFactorial(10)) != 0 {
+		t.Errorf("10! = %v (retried %t), want %v without a retry", results[0].Factorial, results[0].Retried, utils.CalcFactorial(10))
+	}
+	if !results[1].Retried || results[1].Factorial.Cmp(utils.CalcFactorial(12)) != 0 {
+		t.Errorf("12! = %v (retried %t), want %v after a retry", results[1].Factorial, results[1].Retried, utils.CalcFactorial(12))
+	}
+	if !results[2].Retried || results[2].Factorial != nil || !errors.Is(results[2].Err, worker.ErrTaskTimeout) {
+		t.Errorf("40! = %v (retried %t, error %v), want a timeout after a retry", results[2].Factorial, results[2].Retried, results[2].Err)
+	}
+
+	// Every retry costs the task once more: 10 + 2*12 + 2*40.
+	if elapsed := h.Clock.Now().Sub(start); elapsed != 114*time.Millisecond {
+		t.Errorf("The run took %v on the fake clock, want 114ms", elapsed)
+	}
+}
@@ -0,0 +1,39 @@
+package testutil
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// AssertFactorialMonotonic checks the invariant that n! never decreases with n, and strictly increases
+// for n >= 1, across results sorted by task value. Equal values must have equal factorials. This cheaply
+// catches a computation that produced a wrong value, without recomputing any factorial. Failed results,
+// with an error or without a factorial, are skipped. It returns an error describing the first violation,
+// including results that are not sorted by value, or nil if there is none.
+func AssertFactorialMonotonic(results []model.Result) error {
+	var prev *model.Result
+	for i := range results {
+		r := &results[i]
+		if r.Err != nil || r.Factorial == nil {
+			continue
+		}
+		if prev == nil {
+			prev = r
+			continue
+		}
+
+		switch cmp := r.Factorial.Cmp(prev.Factorial); {
+		case r.Task.Value < prev.Task.Value:
+			return fmt.Errorf("result %d (task %d, %d!) follows a larger value, %d!: results are not sorted by value",
+				i, r.Task.ID, r.Task.Value, prev.Task.Value)
+		case r.Task.Value == prev.Task.Value && cmp != 0:
+			return fmt.Errorf("result %d (task %d) has %d! = %v, but task %d has %d! = %v",
+				i, r.Task.ID, r.Task.Value, r.Factorial, prev.Task.ID, prev.Task.Value, prev.Factorial)
+		case cmp < 0 || (r.Task.Value > prev.Task.Value && r.Task.Value > 1 && cmp == 0):
+			return fmt.Errorf("result %d (task %d) has %d! = %v, which is not greater than %d! = %v of task %d",
+				i, r.Task.ID, r.Task.Value, r.Factorial, prev.Task.Value, prev.Factorial, prev.Task.ID)
+		}
+		prev = r
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// factorialResult returns a result of value with the given factorial.
+func factorialResult(id int, value int64, factorial *big.Int) model.Result {
+	return model.Result{Task: model.Task{ID: id, Value: value}, Factorial: factorial}
+}
+
+func TestAssertFactorialMonotonic(t *testing.T) {
+	correct := func(id int, value int64) model.Result {
+		return factorialResult(id, value, utils.CalcFactorial(value))
+	}
+
+	tests := []struct {
+		name      string
+		results   []model.Result
+		violation string // A fragment of the expected error, empty if there is none.
+	}{
+		{"empty", nil, ""},
+		{"correct", []model.Result{correct(0, 0), correct(1, 1), correct(2, 5), correct(3, 5), correct(4, 20)}, ""},
+		{"failed results are skipped", []model.Result{
+			correct(0, 3),
+			factorialResult(1, 4, nil),
+			{Task: model.Task{ID: 2, Value: 5}, Err: errors.New("rejected")},
+			correct(3, 6),
+		}, ""},
+		{"decreasing", []model.Result{correct(0, 5), factorialResult(1, 6, big.NewInt(100))}, "result 1 (task 1) has 6! = 100"},
+		{"not increasing", []model.Result{correct(0, 5), factorialResult(1, 6, big.NewInt(120))}, "not greater than 5! = 120"},
+		{"equal values differ", []model.Result{correct(0, 5), factorialResult(1, 5, big.NewInt(121))}, "but task 0 has 5! = 120"},
+		{"not sorted", []model.Result{correct(0, 6), correct(1, 5)}, "not sorted by value"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			err := AssertFactorialMonotonic(test.results)
+			if test.violation == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if test.violation != "" && (err == nil || !strings.Contains(err.Error(), test.violation)) {
+				t.Errorf("Expected an error containing %q, got %v", test.violation, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/tracing"
+	"github.com/lipcsei/konstruktor/worker"
+)
+
+// stdoutTracer stands in for an OpenTelemetry tracer whose provider exports to standard output.
+// With OpenTelemetry, Start would call otelTracer.Start and wrap the returned trace.Span, mapping
+// SetAttributes to attribute.KeyValue pairs and SetError to span.SetStatus(codes.Error, description).
+type stdoutTracer struct{}
+
+// stdoutSpan prints itself once it ends.
+type stdoutSpan struct {
+	attrs []tracing.Attribute
+	err   string
+}
+
+func (stdoutTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+	return ctx, &stdoutSpan{}
+}
+
+func (s *stdoutSpan) SetAttributes(attrs ...tracing.Attribute) { s.attrs = append(s.attrs, attrs...) }
+
+func (s *stdoutSpan) SetError(description string) { s.err = description }
+
+func (s *stdoutSpan) End() {
+	for _, a := range s.attrs {
+		if a.Key == tracing.AttrTaskID {
+			fmt.Printf("span %s ended: task %v, error %q\n", tracing.SpanName, a.Value, s.err)
+		}
+	}
+}
+
+func Example() {
+	pool, err := worker.NewPool(worker.Config{Workers: 1, ThresholdFactor: 1e9},
+		tracing.WithTracing(context.Background(), stdoutTracer{}))
+	if err != nil {
+		panic(err)
+	}
+
+	tasks := make(chan model.Task, 1)
+	tasks <- model.Task{ID: 0, Value: 5}
+	close(tasks)
+	pool.Start(tasks)
+	for range pool.Results() {
+	}
+	// Output: span konstruktor.task ended: task 0, error ""
+}
@@ -0,0 +1,75 @@
+// Package tracing wraps the processing of every task of a worker.Pool in a span, so pools can take
+// part in traced request flows. It depends only on the small Tracer and Span interfaces below rather
+// than on an OpenTelemetry SDK, keeping the core packages free of tracing dependencies; an adapter of
+// a few lines maps them onto an OpenTelemetry trace.Tracer, see the example.
+package tracing
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/worker"
+	"time"
+)
+
+// SpanName is the name of the span created for every task.
+const SpanName = "konstruktor.task"
+
+// The attribute keys set on every span.
+const (
+	AttrTaskID   = "konstruktor.task.id"
+	AttrValue    = "konstruktor.task.value"
+	AttrWorkerID = "konstruktor.worker.id"
+	AttrDuration = "konstruktor.task.duration_ms"
+)
+
+// Attribute is a key-value pair attached to a span.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts spans, like an OpenTelemetry trace.Tracer.
+type Tracer interface {
+	// Start creates a span that is a child of the span in ctx, if any.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation, like an OpenTelemetry trace.Span.
+type Span interface {
+	// SetAttributes attaches the attributes to the span.
+	SetAttributes(attrs ...Attribute)
+	// SetError marks the span as failed with the given description.
+	SetError(description string)
+	// End completes the span.
+	End()
+}
+
+// Observer returns a worker.TaskObserver that starts a span for every task as a child of the span in
+// ctx, if any. The span carries the task ID, value and worker ID, and once the task has been processed,
+// its duration; it is marked failed if the task produced no factorial.
+func Observer(ctx context.Context, tracer Tracer) worker.TaskObserver {
+	return func(workerID int, task model.Task) func(model.Result, time.Duration) {
+		_, span := tracer.Start(ctx, SpanName)
+		span.SetAttributes(
+			Attribute{Key: AttrTaskID, Value: task.ID},
+			Attribute{Key: AttrValue, Value: task.Value},
+			Attribute{Key: AttrWorkerID, Value: workerID},
+		)
+
+		return func(r model.Result, duration time.Duration) {
+			span.SetAttributes(Attribute{Key: AttrDuration, Value: float64(duration) / float64(time.Millisecond)})
+			switch {
+			case r.Err != nil:
+				span.SetError(r.Err.Error())
+			case r.Factorial == nil:
+				span.SetError("no factorial computed")
+			}
+			span.End()
+		}
+	}
+}
+
+// WithTracing returns a pool option that traces every task with Observer.
+func WithTracing(ctx context.Context, tracer Tracer) worker.Option {
+	return worker.WithTaskObserver(Observer(ctx, tracer))
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+// parentKey marks the context of a parent span.
+type parentKey struct{}
+
+// recordingTracer records every span it starts.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+// recordedSpan is a span of the recordingTracer.
+type recordedSpan struct {
+	parent any
+	attrs  map[string]any
+	err    string
+	ended  bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{parent: ctx.Value(parentKey{}), attrs: make(map[string]any)}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, parentKey{}, span), span
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) SetError(description string) { s.err = description }
+
+func (s *recordedSpan) End() { s.ended = true }
+
+func TestObserver(t *testing.T) {
+	tracer := &recordingTracer{}
+	ctx := context.WithValue(context.Background(), parentKey{}, "request")
+	observe := Observer(ctx, tracer)
+
+	task := model.Task{ID: 7, Value: 5}
+	observe(2, task)(model.Result{Task: task, Factorial: big.NewInt(120)}, 3*time.Millisecond)
+	observe(1, task)(model.Result{Task: task, Err: errors.New("boom")}, time.Millisecond)
+	observe(1, task)(model.Result{Task: task}, time.Millisecond)
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(tracer.spans))
+	}
+	ok := tracer.spans[0]
+	if ok.parent != "request" {
+		t.Errorf("Expected the span to be a child of the incoming context, got parent %v", ok.parent)
+	}
+	if ok.attrs[AttrTaskID] != 7 || ok.attrs[AttrValue] != int64(5) || ok.attrs[AttrWorkerID] != 2 || ok.attrs[AttrDuration] != 3.0 {
+		t.Errorf("Unexpected attributes %v", ok.attrs)
+	}
+	if ok.err != "" || !ok.ended {
+		t.Errorf("Expected an ended, successful span, got error %q, ended %v", ok.err, ok.ended)
+	}
+	if tracer.spans[1].err != "boom" {
+		t.Errorf("Expected the span of a failed task to carry its error, got %q", tracer.spans[1].err)
+	}
+	if tracer.spans[2].err == "" {
+		t.Errorf("Expected the span of a result without a factorial to be marked failed")
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "math/big"
+
+// AlternatingFactorial calculates the alternating factorial af(n) = n! - (n-1)! + (n-2)! - ... ± 1!.
+// It walks FactorialSequence once, using af(k) = k! - af(k-1), so it costs about as much as n! alone.
+// Like CalcFactorial yields 0 for negative inputs, it returns 0 if n is less than 1, for which the
+// alternating factorial is undefined; af(n) itself is never 0.
+func AlternatingFactorial(n int64) *big.Int {
+	if n < 1 {
+		return undefinedZero() // Returns 0 for n < 1 as the alternating factorial is undefined
+	}
+
+	result := new(big.Int)
+	k := int64(0)
+	for factorial := range FactorialSequence(n) {
+		if k > 0 {
+			// af(k) = k! - af(k-1)
+			result.Sub(factorial, result)
+		}
+		k++
+	}
+	return result
+}
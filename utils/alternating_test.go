@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAlternatingFactorial(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int64
+		expected string
+	}{
+		{"af(1)", 1, "1"},
+		{"af(2)", 2, "1"},
+		{"af(3)", 3, "5"},
+		{"af(4)", 4, "19"},
+		{"af(10)", 10, "3301819"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			if result := AlternatingFactorial(test.n); result.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, result.String())
+			}
+		})
+	}
+}
+
+func TestAlternatingFactorial_Invalid(t *testing.T) {
+	for _, n := range []int64{0, -1} {
+		if result := AlternatingFactorial(n); result.Sign() != 0 {
+			t.Errorf("Expected 0 for n = %d, got %v", n, result)
+		}
+	}
+}
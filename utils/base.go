@@ -0,0 +1,16 @@
+package utils
+
+import "fmt"
+
+// FactorialInBase calculates the factorial of n and returns it in the given base, using the digits
+// 0-9, then a-z, then A-Z, like big.Int.Text. The base must be between 2 and 62, inclusive.
+// It returns an error wrapping ErrUndefinedFactorial if n is negative.
+func FactorialInBase(n int64, base int) (string, error) {
+	if base < 2 || base > 62 {
+		return "", fmt.Errorf("invalid base %d: must be between 2 and 62", base)
+	}
+	if n < 0 {
+		return "", undefinedError("%d is negative", n)
+	}
+	return CalcFactorial(n).Text(base), nil
+}
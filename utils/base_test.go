@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFactorialInBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int64
+		base     int
+		expected string
+	}{
+		{"10! in base 16", 10, 16, "375f00"},
+		{"10! in base 10", 10, 10, "3628800"},
+		{"5! in base 2", 5, 2, "1111000"},
+		{"0! in base 62", 0, 62, "1"},
+		{"20! in base 62", 20, 62, "2TIIsbf5FZK"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result, err := FactorialInBase(test.n, test.base)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFactorialInBase_InvalidBase(t *testing.T) {
+	for _, base := range []int{-1, 0, 1, 63} {
+		if _, err := FactorialInBase(10, base); err == nil {
+			t.Errorf("Expected an error for base %d", base)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"math/big"
+	"sort"
+)
+
+// CalcFactorialsBatch calculates the factorial of every value in one ascending pass: the values are
+// sorted, and a single running product is multiplied up to each of them in turn, so the work up to
+// the smaller values is shared rather than repeated, e.g. {100, 200, 300} cost as much as 300! alone.
+// The result maps every distinct value to its factorial; each one is a separate big.Int the caller
+// may keep or modify. Negative values map to 0, like CalcFactorial.
+func CalcFactorialsBatch(values []int64) map[int64]*big.Int {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	results := make(map[int64]*big.Int, len(sorted))
+	product := big.NewInt(1) // The running product, k! after multiplying up to k.
+	factor := new(big.Int)
+	k := int64(0)
+	for _, n := range sorted {
+		if n < 0 {
+			results[n] = undefinedZero() // Factorial is undefined for negative values.
+			continue
+		}
+		for ; k < n; k++ {
+			product.Mul(product, factor.SetInt64(k+1))
+		}
+		if _, ok := results[n]; !ok {
+			results[n] = new(big.Int).Set(product)
+		}
+	}
+	return results
+}
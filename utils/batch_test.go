@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCalcFactorialsBatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+	}{
+		{"empty", nil},
+		{"single value", []int64{10}},
+		{"clustered values", []int64{300, 100, 200}},
+		{"duplicates and zero", []int64{5, 0, 5, 1, 0}},
+		{"negative values", []int64{-3, 4, -1}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			results := CalcFactorialsBatch(test.values)
+
+			distinct := make(map[int64]bool)
+			for _, n := range test.values {
+				distinct[n] = true
+				if expected := CalcFactorial(n); results[n] == nil || results[n].Cmp(expected) != 0 {
+					t.Errorf("Expected %d! = %v, got %v", n, expected, results[n])
+				}
+			}
+			if len(results) != len(distinct) {
+				t.Errorf("Expected %d results, got %d", len(distinct), len(results))
+			}
+		})
+	}
+}
+
+func TestCalcFactorialsBatch_IndependentResults(t *testing.T) {
+	results := CalcFactorialsBatch([]int64{3, 4})
+
+	// Modifying one result must not affect the other.
+	results[3].SetInt64(0)
+	if results[4].Int64() != 24 {
+		t.Errorf("Expected 4! = 24, got %v", results[4])
+	}
+}
+
+// clusteredValues are the values of a batch whose factorials share most of their work.
+var clusteredValues = []int64{4000, 4100, 4200, 4300, 4400, 4500, 4600, 4700, 4800, 4900}
+
+func BenchmarkCalcFactorialsBatch(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		CalcFactorialsBatch(clusteredValues)
+	}
+}
+
+func BenchmarkCalcFactorial_Independent(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, n := range clusteredValues {
+			CalcFactorial(n)
+		}
+	}
+}
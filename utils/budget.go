@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"runtime"
+)
+
+// ErrBitBudgetExceeded is returned when a computation outgrows its bit-length budget.
+var ErrBitBudgetExceeded = errors.New("factorial exceeds the bit-length budget")
+
+// budgetCheckInterval is the number of multiplications between two budget checks.
+// Every multiplication adds at most 64 bits, so a computation overshoots its budget
+// by at most 64*budgetCheckInterval bits before it is aborted.
+const budgetCheckInterval = 128
+
+// contextCheckInterval is the number of multiplications between two checks of the context of a computation.
+const contextCheckInterval = 1000
+
+// yield is called to give up the processor during long computations. It is a variable so tests can observe it.
+var yield = runtime.Gosched
+
+// ComputeOptions controls how CalcFactorialWith performs a computation.
+type ComputeOptions struct {
+	// MaxBits is the bit-length budget of the result. The computation is aborted with
+	// ErrBitBudgetExceeded as soon as the intermediate result grows beyond it.
+	// Zero or less disables the budget.
+	MaxBits int
+	// YieldEvery is the number of multiplications after which the computation yields the processor
+	// with runtime.Gosched, so a single huge factorial does not starve other goroutines on the same
+	// thread. Each yield costs a trip through the scheduler, which slows down a busy computation
+	// slightly; an interval in the thousands keeps that cost negligible. Zero or less never yields.
+	YieldEvery int64
+	// Context aborts the computation with its error once it is cancelled or its deadline passes. It is
+	// checked before the computation and every contextCheckInterval multiplications, so an abort takes
+	// at most that many multiplications. Nil never aborts.
+	Context context.Context
+}
+
+// CalcFactorialBudget calculates the factorial of n like CalcFactorial, but aborts with
+// ErrBitBudgetExceeded as soon as the intermediate result grows beyond maxBits bits.
+// This stops a single enormous computation before it allocates a huge amount of memory.
+// A maxBits of 0 or less disables the budget. A negative n is an error, see CalcFactorialWith.
+func CalcFactorialBudget(n int64, maxBits int) (*big.Int, error) {
+	return CalcFactorialWith(n, ComputeOptions{MaxBits: maxBits})
+}
+
+// CalcFactorialContext calculates the factorial of n like CalcFactorial, but aborts with the error of ctx
+// as soon as it is cancelled or its deadline passes, so a huge computation can be bounded in time.
+// A negative n is an error, see CalcFactorialWith.
+func CalcFactorialContext(ctx context.Context, n int64) (*big.Int, error) {
+	return CalcFactorialWith(n, ComputeOptions{Context: ctx})
+}
+
+// CalcFactorialWith calculates the factorial of n like CalcFactorial, with the bit-length budget
+// the periodic yielding and the context set in opts. Unlike CalcFactorial, it returns an error
+// wrapping ErrUndefinedFactorial for a negative n rather than 0, so a result of 0 is never mistaken
+// for a factorial.
+func CalcFactorialWith(n int64, opts ComputeOptions) (*big.Int, error) {
+	if n < 0 {
+		return nil, undefinedError("%d is negative", n)
+	}
+	if opts.Context != nil && opts.Context.Err() != nil {
+		return nil, opts.Context.Err()
+	}
+
+	result, from := smallFactorial(n)
+	for i := from + 1; i <= n; i++ {
+		result.Mul(result, big.NewInt(i))
+
+		// Checking periodically is enough, BitLen itself is cheap.
+		if opts.MaxBits > 0 && i%budgetCheckInterval == 0 && result.BitLen() > opts.MaxBits {
+			return nil, ErrBitBudgetExceeded
+		}
+		if opts.YieldEvery > 0 && i%opts.YieldEvery == 0 {
+			yield()
+		}
+		if opts.Context != nil && i%contextCheckInterval == 0 && opts.Context.Err() != nil {
+			return nil, opts.Context.Err()
+		}
+	}
+
+	// The final product may have outgrown the budget since the last check.
+	if opts.MaxBits > 0 && result.BitLen() > opts.MaxBits {
+		return nil, ErrBitBudgetExceeded
+	}
+
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCalcFactorialBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int64
+		maxBits int
+		wantErr bool
+	}{
+		{"no budget", 1000, 0, false},
+		{"within budget", 20, 64, false},
+		{"exceeded after the loop", 21, 64, true},
+		{"exceeded mid-computation", 1000000, 1024, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result, err := CalcFactorialBudget(test.n, test.maxBits)
+			if test.wantErr {
+				if !errors.Is(err, ErrBitBudgetExceeded) {
+					t.Errorf("Expected ErrBitBudgetExceeded, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if expected := CalcFactorial(test.n); result.Cmp(expected) != 0 {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+		})
+	}
+}
+
+func TestCalcFactorialWith_Negative(t *testing.T) {
+	// A negative value is an error rather than a result of 0 that looks like a factorial.
+	if result, err := CalcFactorialWith(-1, ComputeOptions{}); result != nil || !errors.Is(err, ErrUndefinedFactorial) {
+		t.Errorf("CalcFactorialWith(-1) = %v, %v, want nil and ErrUndefinedFactorial", result, err)
+	}
+}
+
+func TestCalcFactorialWith_Yield(t *testing.T) {
+	yields := 0
+	yield = func() { yields++ }
+	defer func() { yield = runtime.Gosched }()
+
+	tests := []struct {
+		name     string
+		n        int64
+		every    int64
+		expected int
+	}{
+		{"disabled", 1000, 0, 0},
+		{"every 100", 1000, 100, 10},
+		{"interval larger than n", 50, 100, 0},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			yields = 0
+			result, err := CalcFactorialWith(test.n, ComputeOptions{YieldEvery: test.every})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if expected := CalcFactorial(test.n); result.Cmp(expected) != 0 {
+				t.Errorf("Expected %s, got %s", expected, result)
+			}
+			if yields != test.expected {
+				t.Errorf("Expected %d yields, got %d", test.expected, yields)
+			}
+		})
+	}
+}
+
+func TestCalcFactorialContext(t *testing.T) {
+	result, err := CalcFactorialContext(context.Background(), 1500)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := CalcFactorial(1500); result.Cmp(expected) != 0 {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	// A cancelled context aborts even a computation too small to reach a periodic check.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CalcFactorialContext(cancelled, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// A deadline cuts a computation of many seconds short.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := CalcFactorialContext(ctx, 1000000); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Computation was aborted after %v, want shortly after the 20ms deadline", elapsed)
+	}
+}
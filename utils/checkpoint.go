@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+)
+
+// ErrCheckpointMismatch is returned when a checkpoint belongs to the factorial of another number.
+var ErrCheckpointMismatch = errors.New("checkpoint belongs to a different factorial")
+
+// checkpointHeaderSize is the size of the fixed part of a checkpoint: n and the index, 8 bytes each.
+const checkpointHeaderSize = 16
+
+// CalcFactorialCheckpointed calculates the factorial of n like CalcFactorial, but saves its progress
+// to the file at path every interval multiplications, so that a computation interrupted by a crash
+// can continue where it left off instead of starting over. If the file holds a checkpoint of n!,
+// the computation resumes from it. The file is removed once the computation has completed.
+//
+// A checkpoint holds n and the index of the last multiplier, as big-endian 64-bit integers, followed
+// by the big-endian bytes of the partial product. It is written to a temporary file first and then
+// renamed, so a crash while saving leaves the previous checkpoint intact.
+// Returns ErrCheckpointMismatch if the file holds a checkpoint for a different n, and an error wrapping
+// ErrUndefinedFactorial if n is negative.
+func CalcFactorialCheckpointed(n int64, path string, interval int64) (*big.Int, error) {
+	if n < 0 {
+		return nil, undefinedError("%d is negative", n)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("checkpoint interval must be positive, got %d", interval)
+	}
+
+	result, index, err := loadCheckpoint(path, n)
+	if err != nil {
+		return nil, err
+	}
+
+	factor := new(big.Int)
+	for i := index + 1; i <= n; i++ {
+		result.Mul(result, factor.SetInt64(i))
+
+		if i%interval == 0 && i < n {
+			if err := saveCheckpoint(path, n, i, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadCheckpoint reads the checkpoint of n! at path and returns its partial product and the index
+// of its last multiplier. If there is no checkpoint, it returns the starting point 0! = 1.
+func loadCheckpoint(path string, n int64) (*big.Int, int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return big.NewInt(1), 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(data) < checkpointHeaderSize {
+		return nil, 0, fmt.Errorf("checkpoint %s is truncated", path)
+	}
+	checkpointN := int64(binary.BigEndian.Uint64(data[0:8]))
+	index := int64(binary.BigEndian.Uint64(data[8:16]))
+	if checkpointN != n {
+		return nil, 0, fmt.Errorf("%w: %s holds %d!, not %d!", ErrCheckpointMismatch, path, checkpointN, n)
+	}
+	if index < 0 || index > n {
+		return nil, 0, fmt.Errorf("checkpoint %s has invalid index %d", path, index)
+	}
+
+	return new(big.Int).SetBytes(data[checkpointHeaderSize:]), index, nil
+}
+
+// saveCheckpoint atomically replaces the checkpoint at path with the partial product of n! up to index.
+func saveCheckpoint(path string, n, index int64, product *big.Int) error {
+	data := make([]byte, checkpointHeaderSize, checkpointHeaderSize+(product.BitLen()+7)/8)
+	binary.BigEndian.PutUint64(data[0:8], uint64(n))
+	binary.BigEndian.PutUint64(data[8:16], uint64(index))
+	data = append(data, product.Bytes()...)
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalcFactorialCheckpointed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "factorial.ckpt")
+
+	result, err := CalcFactorialCheckpointed(1000, path, 100)
+	if err != nil {
+		t.Fatalf("CalcFactorialCheckpointed() returned an error: %v", err)
+	}
+	if expected := CalcFactorial(1000); result.Cmp(expected) != 0 {
+		t.Errorf("Expected 1000! with %d bits, got %d bits", expected.BitLen(), result.BitLen())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Checkpoint file still exists after the computation completed: %v", err)
+	}
+}
+
+func TestCalcFactorialCheckpointed_Resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "factorial.ckpt")
+
+	// Leave a checkpoint as an interrupted computation of 1000! would have after 600 multiplications.
+	if err := saveCheckpoint(path, 1000, 600, CalcFactorial(600)); err != nil {
+		t.Fatalf("saveCheckpoint() returned an error: %v", err)
+	}
+
+	result, err := CalcFactorialCheckpointed(1000, path, 100)
+	if err != nil {
+		t.Fatalf("CalcFactorialCheckpointed() returned an error: %v", err)
+	}
+	if expected := CalcFactorial(1000); result.Cmp(expected) != 0 {
+		t.Errorf("Expected 1000! with %d bits, got %d bits", expected.BitLen(), result.BitLen())
+	}
+}
+
+func TestCalcFactorialCheckpointed_ResumeUsesCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "factorial.ckpt")
+
+	// A checkpoint claiming the product up to 9 is 1 proves the computation resumes from it:
+	// the result is 10! / 9! = 10 instead of 10!.
+	if err := saveCheckpoint(path, 10, 9, big.NewInt(1)); err != nil {
+		t.Fatalf("saveCheckpoint() returned an error: %v", err)
+	}
+
+	result, err := CalcFactorialCheckpointed(10, path, 100)
+	if err != nil {
+		t.Fatalf("CalcFactorialCheckpointed() returned an error: %v", err)
+	}
+	if result.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("Expected 10, got %v", result)
+	}
+}
+
+func TestCalcFactorialCheckpointed_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	mismatched := filepath.Join(dir, "mismatched.ckpt")
+	if err := saveCheckpoint(mismatched, 500, 100, CalcFactorial(100)); err != nil {
+		t.Fatalf("saveCheckpoint() returned an error: %v", err)
+	}
+	if _, err := CalcFactorialCheckpointed(1000, mismatched, 100); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("Expected ErrCheckpointMismatch, got %v", err)
+	}
+
+	truncated := filepath.Join(dir, "truncated.ckpt")
+	if err := os.WriteFile(truncated, []byte{1, 2, 3}, 0o644); err != nil {
+		t.Fatalf("WriteFile() returned an error: %v", err)
+	}
+	if _, err := CalcFactorialCheckpointed(1000, truncated, 100); err == nil {
+		t.Errorf("Expected an error for a truncated checkpoint, got none")
+	}
+
+	if _, err := CalcFactorialCheckpointed(1000, filepath.Join(dir, "unused.ckpt"), 0); err == nil {
+		t.Errorf("Expected an error for a zero interval, got none")
+	}
+}
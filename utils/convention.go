@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrUndefinedFactorial is returned under ConventionStandard for inputs the function is not defined for,
+// and wrapped by every helper of this package that returns an error for such inputs.
+var ErrUndefinedFactorial = errors.New("factorial is undefined for this input")
+
+// FactorialConvention selects how the factorial helpers treat inputs outside their domain,
+// i.e. negative numbers. The helpers of this package without a convention parameter follow the one
+// that fits their signature: those returning an error follow ConventionStandard, those returning only
+// a *big.Int follow ConventionZero, and those returning neither yield the zero value of their result,
+// e.g. "" or nil.
+// Inside the domain, every convention follows the mathematical standard: 0! = 1, 0!! = (-1)!! = 1
+// and !0 = 1, as required for the counting identities these functions take part in.
+type FactorialConvention int
+
+const (
+	// ConventionStandard treats undefined inputs as errors, returning ErrUndefinedFactorial.
+	// It is the zero value and the mathematical standard.
+	ConventionStandard FactorialConvention = iota
+	// ConventionZero returns 0 for undefined inputs, without an error. This is the convention
+	// CalcFactorial and the other helpers returning only a *big.Int follow.
+	ConventionZero
+	// ConventionNil returns nil for undefined inputs, without an error, much like NaN for floats.
+	ConventionNil
+)
+
+// undefined returns the outcome of an undefined input under the convention.
+func (c FactorialConvention) undefined() (*big.Int, error) {
+	switch c {
+	case ConventionZero:
+		return big.NewInt(0), nil
+	case ConventionNil:
+		return nil, nil
+	default:
+		return nil, ErrUndefinedFactorial
+	}
+}
+
+// undefinedZero returns the outcome of an undefined input for the helpers following ConventionZero.
+func undefinedZero() *big.Int {
+	result, _ := ConventionZero.undefined()
+	return result
+}
+
+// undefinedError returns the outcome of an undefined input for the helpers following ConventionStandard:
+// ErrUndefinedFactorial, wrapped with a description of the input.
+func undefinedError(format string, args ...any) error {
+	_, err := ConventionStandard.undefined()
+	return fmt.Errorf("%w: "+format, append([]any{err}, args...)...)
+}
+
+// Factorial returns n! = 1 * 2 * ... * n. It is undefined for negative n.
+func (c FactorialConvention) Factorial(n int64) (*big.Int, error) {
+	if n < 0 {
+		return c.undefined()
+	}
+	return CalcFactorial(n), nil
+}
+
+// DoubleFactorial returns n!! = n * (n-2) * (n-4) * ..., the product of every number down to 1 or 2
+// with the same parity as n. It is 1 for 0 and -1, and undefined for n < -1.
+func (c FactorialConvention) DoubleFactorial(n int64) (*big.Int, error) {
+	if n < -1 {
+		return c.undefined()
+	}
+
+	result := big.NewInt(1)
+	factor := new(big.Int)
+	for i := n; i > 1; i -= 2 {
+		result.Mul(result, factor.SetInt64(i))
+	}
+	return result, nil
+}
+
+// Subfactorial returns !n, the number of permutations of n elements that leave no element in place.
+// It follows the recurrence !n = n * !(n-1) + (-1)^n, starting at !0 = 1. It is undefined for negative n.
+func (c FactorialConvention) Subfactorial(n int64) (*big.Int, error) {
+	if n < 0 {
+		return c.undefined()
+	}
+
+	result := big.NewInt(1)
+	factor := new(big.Int)
+	for i := int64(1); i <= n; i++ {
+		result.Mul(result, factor.SetInt64(i))
+		if i%2 == 0 {
+			result.Add(result, big.NewInt(1))
+		} else {
+			result.Sub(result, big.NewInt(1))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestFactorialConvention(t *testing.T) {
+	conventions := []FactorialConvention{ConventionStandard, ConventionZero, ConventionNil}
+	functions := []struct {
+		name string
+		f    func(FactorialConvention, int64) (*big.Int, error)
+	}{
+		{"Factorial", FactorialConvention.Factorial},
+		{"DoubleFactorial", FactorialConvention.DoubleFactorial},
+		{"Subfactorial", FactorialConvention.Subfactorial},
+	}
+
+	tests := []struct {
+		name     string
+		function int
+		n        int64
+		expected int64
+	}{
+		{"0!", 0, 0, 1},
+		{"1!", 0, 1, 1},
+		{"5!", 0, 5, 120},
+		{"(-1)!!", 1, -1, 1},
+		{"0!!", 1, 0, 1},
+		{"1!!", 1, 1, 1},
+		{"7!!", 1, 7, 105},
+		{"8!!", 1, 8, 384},
+		{"!0", 2, 0, 1},
+		{"!1", 2, 1, 0},
+		{"!2", 2, 2, 1},
+		{"!5", 2, 5, 44},
+	}
+
+	// Values inside the domain are the same under every convention.
+	for i, test := range tests {
+		for _, convention := range conventions {
+			t.Run(fmt.Sprintf("%d_%s_convention_%d", i, test.name, convention), func(t *testing.T) {
+				result, err := functions[test.function].f(convention, test.n)
+				if err != nil || result.Cmp(big.NewInt(test.expected)) != 0 {
+					t.Errorf("Expected %d, got %v with error %v", test.expected, result, err)
+				}
+			})
+		}
+	}
+
+	// Values outside the domain follow the convention.
+	undefined := map[string]int64{"Factorial": -1, "DoubleFactorial": -2, "Subfactorial": -1}
+	for _, function := range functions {
+		n := undefined[function.name]
+		t.Run(fmt.Sprintf("%s(%d)", function.name, n), func(t *testing.T) {
+			if result, err := function.f(ConventionStandard, n); result != nil || !errors.Is(err, ErrUndefinedFactorial) {
+				t.Errorf("ConventionStandard: expected ErrUndefinedFactorial, got %v with error %v", result, err)
+			}
+			if result, err := function.f(ConventionZero, n); err != nil || result == nil || result.Sign() != 0 {
+				t.Errorf("ConventionZero: expected 0, got %v with error %v", result, err)
+			}
+			if result, err := function.f(ConventionNil, n); result != nil || err != nil {
+				t.Errorf("ConventionNil: expected nil, got %v with error %v", result, err)
+			}
+		})
+	}
+}
+
+func TestFactorialConvention_ZeroMatchesCalcFactorial(t *testing.T) {
+	for n := int64(-3); n <= 20; n++ {
+		result, err := ConventionZero.Factorial(n)
+		if err != nil || result.Cmp(CalcFactorial(n)) != 0 {
+			t.Errorf("ConventionZero.Factorial(%d) = %v with error %v, want %v", n, result, err, CalcFactorial(n))
+		}
+	}
+}
+
+func TestFactorialConvention_Helpers(t *testing.T) {
+	// Helpers returning an error follow ConventionStandard.
+	errorHelpers := map[string]func() error{
+		"CalcFactorialWith": func() error { _, err := CalcFactorialWith(-1, ComputeOptions{}); return err },
+		"CalcFactorialCheckpointed": func() error {
+			_, err := CalcFactorialCheckpointed(-1, t.TempDir()+"/checkpoint", 1)
+			return err
+		},
+		"CalcFactorialMod": func() error { _, err := CalcFactorialMod(-1, 7); return err },
+		"FactorialInBase":  func() error { _, err := FactorialInBase(-1, 10); return err },
+		"SumFactorials":    func() error { _, err := SumFactorials(-1, 3); return err },
+	}
+	for name, helper := range errorHelpers {
+		t.Run(name, func(t *testing.T) {
+			if err := helper(); !errors.Is(err, ErrUndefinedFactorial) {
+				t.Errorf("Expected ErrUndefinedFactorial, got %v", err)
+			}
+		})
+	}
+
+	// Helpers returning only a *big.Int follow ConventionZero.
+	zeroHelpers := map[string]func() *big.Int{
+		"CalcFactorial":        func() *big.Int { return CalcFactorial(-1) },
+		"CalcFactorialTree":    func() *big.Int { return CalcFactorialTree(-1, 0) },
+		"CalcFactorialsBatch":  func() *big.Int { return CalcFactorialsBatch([]int64{-1})[-1] },
+		"AlternatingFactorial": func() *big.Int { return AlternatingFactorial(0) },
+		"RisingFactorial":      func() *big.Int { return RisingFactorial(3, -1) },
+		"FallingFactorial":     func() *big.Int { return FallingFactorial(3, -1) },
+	}
+	for name, helper := range zeroHelpers {
+		t.Run(name, func(t *testing.T) {
+			if result := helper(); result == nil || result.Sign() != 0 {
+				t.Errorf("Expected 0, got %v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "math"
+
+// FactorialDigits returns the number of decimal digits of n! without computing it.
+// The digit count is floor(log10(n!)) + 1, where log10(n!) = lgamma(n+1) / ln(10). This is exact
+// as long as the fractional part of log10(n!) is not within about 1e-9 of an integer, which is
+// far below what any realistic n runs into, since n! is a power of ten only for n <= 1.
+// Returns 0 if n is negative as factorial is undefined.
+func FactorialDigits(n int64) int64 {
+	if n < 0 {
+		return 0
+	}
+	if n <= 1 {
+		return 1
+	}
+
+	logGamma, _ := math.Lgamma(float64(n) + 1)
+	return int64(math.Floor(logGamma/math.Ln10)) + 1
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFactorialDigits(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int64
+		expected int64
+	}{
+		{"negative", -1, 0},
+		{"0!", 0, 1},
+		{"1!", 1, 1},
+		{"3!", 3, 1},
+		{"4!", 4, 2},
+		{"10!", 10, 7},
+		{"100!", 100, 158},
+		{"1000!", 1000, 2568},
+		{"100000!", 100000, 456574},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result := FactorialDigits(test.n)
+			if result != test.expected {
+				t.Errorf("Expected %d, got %d", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFactorialDigits_MatchesCalcFactorial(t *testing.T) {
+	for n := int64(0); n <= 2000; n++ {
+		if expected := int64(len(CalcFactorial(n).String())); FactorialDigits(n) != expected {
+			t.Errorf("FactorialDigits(%d) = %d, want %d", n, FactorialDigits(n), expected)
+		}
+	}
+}
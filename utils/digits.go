@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// LastKDigits returns the last k significant digits of n!, i.e. the last k digits before the trailing zeros.
+// It works modulo 10^k without ever computing the full factorial: factors of 2 and 5 are stripped
+// from every multiplier and counted separately, then the surplus factors of 2 (there are always at least
+// as many 2s as 5s) are multiplied back in. The result always has k characters, padded with leading zeros
+// if the window reaches beyond the most significant digit of n!.
+// Returns an empty string if n is negative or k is not positive.
+func LastKDigits(n, k int64) string {
+	if n < 0 || k <= 0 {
+		return ""
+	}
+
+	modulus := new(big.Int).Exp(big.NewInt(10), big.NewInt(k), nil)
+
+	product := big.NewInt(1)
+	var twos, fives int64
+	factor := new(big.Int)
+	for i := int64(2); i <= n; i++ {
+		x := i
+		// Strip and count the factors of 2 and 5, which together make up the trailing zeros.
+		for x%2 == 0 {
+			x /= 2
+			twos++
+		}
+		for x%5 == 0 {
+			x /= 5
+			fives++
+		}
+		product.Mul(product, factor.SetInt64(x))
+		product.Mod(product, modulus)
+	}
+
+	// Every pair of 2 and 5 is a trailing zero, only the surplus 2s are significant.
+	surplus := new(big.Int).Exp(big.NewInt(2), big.NewInt(twos-fives), modulus)
+	product.Mul(product, surplus)
+	product.Mod(product, modulus)
+
+	return fmt.Sprintf("%0*s", k, product.String())
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// bruteForceLastKDigits strips the trailing zeros of the full factorial and returns its last k digits,
+// padded with leading zeros to k characters.
+func bruteForceLastKDigits(n, k int64) string {
+	digits := strings.TrimRight(CalcFactorial(n).String(), "0")
+	if int64(len(digits)) > k {
+		digits = digits[int64(len(digits))-k:]
+	}
+	return strings.Repeat("0", int(k)-len(digits)) + digits
+}
+
+func TestLastKDigits(t *testing.T) {
+	tests := []struct {
+		name     string
+		n, k     int64
+		expected string
+	}{
+		{"0! last digit", 0, 1, "1"},
+		{"5! last 2 digits", 5, 2, "12"},
+		{"5! last 3 digits", 5, 3, "012"},
+		{"10! last 3 digits", 10, 3, "288"},
+		{"25! last 5 digits", 25, 5, "85984"},
+		{"negative n", -1, 3, ""},
+		{"zero k", 10, 0, ""},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result := LastKDigits(test.n, test.k)
+			if result != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestLastKDigits_BruteForce(t *testing.T) {
+	for n := int64(0); n <= 100; n++ {
+		for k := int64(1); k <= 10; k++ {
+			expected := bruteForceLastKDigits(n, k)
+			if result := LastKDigits(n, k); result != expected {
+				t.Errorf("LastKDigits(%d, %d) = %q, want %q", n, k, result, expected)
+			}
+		}
+	}
+}
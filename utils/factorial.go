@@ -4,13 +4,15 @@ import "math/big"
 
 // CalcFactorial calculates the factorial of a non-negative integer n
 // using the big.Int type to handle large numbers.
+// Negative inputs yield 0 under ConventionZero, see FactorialConvention for helpers that let the caller choose.
+// Factorials up to 20! are taken from a table, and larger ones continue multiplying from 20!.
 func CalcFactorial(n int64) *big.Int {
 	if n < 0 {
-		return big.NewInt(0) // Returns 0 for negative inputs as factorial is undefined
+		return undefinedZero() // Returns 0 for negative inputs as factorial is undefined
 	}
 
-	result := big.NewInt(1) // Initializes the result as 1, the factorial of 0
-	for i := int64(1); i <= n; i++ {
+	result, from := smallFactorial(n) // Initializes the result from the table of small factorials
+	for i := from + 1; i <= n; i++ {
 		// Multiplies the result by i for each iteration
 		result.Mul(result, big.NewInt(i))
 	}
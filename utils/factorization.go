@@ -0,0 +1,44 @@
+package utils
+
+// FactorialFactorization returns the prime factorization of n! as a map from every prime
+// up to n to its exponent. The exponents follow from Legendre's formula, see PrimePower.
+// This is far more compact than n! itself, e.g. 100000! has 456574 digits, but fewer
+// than 10000 prime factors.
+// Returns an empty map for 0 and 1, and nil if n is negative as factorial is undefined.
+func FactorialFactorization(n int64) map[int64]int64 {
+	if n < 0 {
+		return nil
+	}
+
+	factorization := make(map[int64]int64)
+	for _, p := range primesUpTo(n) {
+		factorization[p] = PrimePower(n, p)
+	}
+	return factorization
+}
+
+// PrimePower returns the exponent of the prime p in n!, its p-adic valuation, using Legendre's
+// formula: the sum of n/p^k over k >= 1. It neither checks that p is prime nor computes n!.
+// Returns 0 if n is negative or p is less than 2.
+func PrimePower(n, p int64) int64 {
+	if n < 0 || p < 2 {
+		return 0
+	}
+
+	var exponent int64
+	// Count the multiples of p, p^2, p^3, ... up to n; stop before p^k overflows.
+	for power := p; power <= n; power *= p {
+		exponent += n / power
+		if power > n/p {
+			break
+		}
+	}
+	return exponent
+}
+
+// TrailingZeros returns the number of trailing zeros of n! in decimal. Every zero takes a factor
+// 2 and a factor 5, and n! has fewer fives than twos, so this is the exponent of 5 in n!.
+// Returns 0 if n is negative.
+func TrailingZeros(n int64) int64 {
+	return PrimePower(n, 5)
+}
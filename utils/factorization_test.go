@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFactorialFactorization(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int64
+		expected map[int64]int64
+	}{
+		{"negative", -1, nil},
+		{"0!", 0, map[int64]int64{}},
+		{"1!", 1, map[int64]int64{}},
+		{"5!", 5, map[int64]int64{2: 3, 3: 1, 5: 1}},
+		// 10! = 3628800 = 2^8 * 3^4 * 5^2 * 7
+		{"10!", 10, map[int64]int64{2: 8, 3: 4, 5: 2, 7: 1}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result := FactorialFactorization(test.n)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFactorialFactorization_MatchesCalcFactorial(t *testing.T) {
+	for _, n := range []int64{20, 97, 500} {
+		product := big.NewInt(1)
+		for p, exponent := range FactorialFactorization(n) {
+			product.Mul(product, new(big.Int).Exp(big.NewInt(p), big.NewInt(exponent), nil))
+		}
+		if expected := CalcFactorial(n); product.Cmp(expected) != 0 {
+			t.Errorf("The factorization of %d! multiplies to %v, want %v", n, product, expected)
+		}
+	}
+}
+
+func TestPrimePower(t *testing.T) {
+	tests := []struct {
+		name     string
+		n, p     int64
+		expected int64
+	}{
+		{"negative n", -1, 2, 0},
+		{"p below 2", 10, 1, 0},
+		{"2 in 10!", 10, 2, 8},
+		{"3 in 10!", 10, 3, 4},
+		{"5 in 100!", 100, 5, 24},
+		{"prime above n", 10, 11, 0},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			if result := PrimePower(test.n, test.p); result != test.expected {
+				t.Errorf("Expected %d, got %d", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestTrailingZeros_MatchesCalcFactorial(t *testing.T) {
+	for n := int64(0); n <= 200; n++ {
+		digits := CalcFactorial(n).String()
+		expected := int64(len(digits) - len(strings.TrimRight(digits, "0")))
+		if result := TrailingZeros(n); result != expected {
+			t.Errorf("TrailingZeros(%d) = %d, want %d", n, result, expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import "math"
+
+// lanczosG is the g parameter of the Lanczos approximation the coefficients below were computed for.
+const lanczosG = 7
+
+// lanczosCoefficients are the coefficients of the Lanczos approximation for g = 7 and n = 9.
+var lanczosCoefficients = [...]float64{
+	0.99999999999980993,
+	676.5203681218851,
+	-1259.1392167224028,
+	771.32342877765313,
+	-176.61502916214059,
+	12.507343278686905,
+	-0.13857109526572012,
+	9.9843695780195716e-6,
+	1.5056327351493116e-7,
+}
+
+// GammaFactorial returns x! for a real x, defined as Γ(x+1), so that it agrees with CalcFactorial
+// for non-negative integers and extends it in between, e.g. 2.5! = Γ(3.5) ≈ 3.3234.
+// It uses the Lanczos approximation, which is accurate to about 15 significant digits. For x < -0.5
+// it applies the reflection formula Γ(z)Γ(1-z) = π / sin(πz), so negative non-integers are supported
+// as well; the accuracy there drops near the poles.
+// Returns NaN for negative integers, where the gamma function has poles, and +Inf once the result
+// overflows a float64 (from about 170.6!).
+func GammaFactorial(x float64) float64 {
+	return gamma(x + 1)
+}
+
+// gamma computes Γ(z) with the Lanczos approximation.
+func gamma(z float64) float64 {
+	if z <= 0 && z == math.Floor(z) {
+		// Γ has a pole at zero and every negative integer.
+		return math.NaN()
+	}
+
+	if z < 0.5 {
+		// Reflect into the half-plane the approximation is accurate in.
+		return math.Pi / (math.Sin(math.Pi*z) * gamma(1-z))
+	}
+
+	z--
+	sum := lanczosCoefficients[0]
+	for i := 1; i < len(lanczosCoefficients); i++ {
+		sum += lanczosCoefficients[i] / (z + float64(i))
+	}
+
+	t := z + lanczosG + 0.5
+	// Split t^(z+0.5) in two halves, so it does not overflow before it is multiplied by e^-t.
+	half := math.Pow(t, (z+0.5)/2)
+	return math.Sqrt(2*math.Pi) * half * (half * math.Exp(-t)) * sum
+}
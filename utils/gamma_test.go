@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestGammaFactorial(t *testing.T) {
+	tests := []struct {
+		name     string
+		x        float64
+		expected float64
+	}{
+		{"0!", 0, 1},
+		{"1!", 1, 1},
+		{"5!", 5, 120},
+		{"20!", 20, 2432902008176640000},
+		{"(-0.5)! is Γ(0.5)", -0.5, math.Sqrt(math.Pi)},
+		{"0.5! is Γ(1.5)", 0.5, math.Sqrt(math.Pi) / 2},
+		{"2.5! is Γ(3.5)", 2.5, 15 * math.Sqrt(math.Pi) / 8},
+		{"(-1.5)! is Γ(-0.5)", -1.5, -2 * math.Sqrt(math.Pi)},
+		{"(-2.5)! is Γ(-1.5)", -2.5, 4 * math.Sqrt(math.Pi) / 3},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result := GammaFactorial(test.x)
+			if math.Abs(result-test.expected) > 1e-12*math.Abs(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestGammaFactorial_MatchesCalcFactorial(t *testing.T) {
+	for n := int64(0); n <= 170; n++ {
+		expected, _ := new(big.Float).SetInt(CalcFactorial(n)).Float64()
+		result := GammaFactorial(float64(n))
+		if math.Abs(result-expected) > 1e-12*expected {
+			t.Errorf("GammaFactorial(%d) = %v, want %v", n, result, expected)
+		}
+	}
+}
+
+func TestGammaFactorial_Poles(t *testing.T) {
+	for _, x := range []float64{-1, -2, -10} {
+		if result := GammaFactorial(x); !math.IsNaN(result) {
+			t.Errorf("GammaFactorial(%v) = %v, want NaN", x, result)
+		}
+	}
+	if result := GammaFactorial(200); !math.IsInf(result, 1) {
+		t.Errorf("GammaFactorial(200) = %v, want +Inf", result)
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// CalcFactorialMod calculates n! modulo m without big.Int, so its memory use does not grow with n.
+// The products are taken modulo m after every multiplication, in 128-bit intermediates, so any positive
+// int64 modulus works. If n >= m, m is one of the factors and the result is 0 without any multiplication;
+// otherwise the loop stops as soon as the product becomes a multiple of m.
+// It returns an error wrapping ErrUndefinedFactorial if n is negative, and an error if m is not positive.
+func CalcFactorialMod(n, m int64) (int64, error) {
+	if n < 0 {
+		return 0, undefinedError("invalid n %d: the factorial is defined for n >= 0", n)
+	}
+	if m <= 0 {
+		return 0, fmt.Errorf("invalid modulus %d: must be positive", m)
+	}
+	if n >= m {
+		return 0, nil
+	}
+
+	product := uint64(1) % uint64(m)
+	for i := int64(2); i <= n && product != 0; i++ {
+		product = mulMod(product, uint64(i), uint64(m))
+	}
+	return int64(product), nil
+}
+
+// mulMod returns a*b mod m without overflowing. a and b must be less than m.
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestCalcFactorialMod(t *testing.T) {
+	tests := []struct {
+		name     string
+		n, m     int64
+		expected int64
+	}{
+		{"zero", 0, 7, 1},
+		{"modulus one", 5, 1, 0},
+		{"small", 5, 7, 1},        // 120 = 17*7 + 1
+		{"wilson", 12, 13, 12},    // (p-1)! ≡ -1 (mod p)
+		{"n equal to m", 7, 7, 0}, // m is a factor
+		{"n above m", 100, 97, 0}, // m is a factor
+		{"composite m", 5, 12, 0}, // 12 divides 3*4, before reaching 12
+		{"large prime", 20, 1000000007, 146326063},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result, err := CalcFactorialMod(test.n, test.m)
+			if err != nil {
+				t.Fatalf("CalcFactorialMod(%d, %d) returned an error: %v", test.n, test.m, err)
+			}
+			if result != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalcFactorialMod_MatchesCalcFactorial(t *testing.T) {
+	// Moduli close to the int64 limit overflow a plain 64-bit multiplication.
+	for _, m := range []int64{2, 10, 1000000007, math.MaxInt64, math.MaxInt64 - 24} {
+		for _, n := range []int64{1, 10, 25, 500} {
+			expected := new(big.Int).Mod(CalcFactorial(n), big.NewInt(m)).Int64()
+			if result, err := CalcFactorialMod(n, m); err != nil || result != expected {
+				t.Errorf("CalcFactorialMod(%d, %d) = %v, %v, want %v", n, m, result, err, expected)
+			}
+		}
+	}
+}
+
+func TestCalcFactorialMod_Invalid(t *testing.T) {
+	for _, args := range [][2]int64{{-1, 7}, {5, 0}, {5, -3}} {
+		if _, err := CalcFactorialMod(args[0], args[1]); err == nil {
+			t.Errorf("CalcFactorialMod(%d, %d) returned no error", args[0], args[1])
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// parallelThreshold is the smallest n CalcFactorialParallel splits into chunks.
+// Below it the goroutines cost more than they save.
+const parallelThreshold = 2048
+
+// parallelChunks is the number of chunks CalcFactorialParallel splits the range [1, n] into.
+const parallelChunks = 16
+
+// factorialSemaphore bounds the number of chunks multiplied at the same time by every
+// CalcFactorialParallel call of the process together.
+var factorialSemaphore = make(chan struct{}, runtime.NumCPU())
+
+// factorialSemaphoreLock synchronizes access to factorialSemaphore.
+var factorialSemaphoreLock sync.Mutex
+
+// chunkHook is a global variable that is called while a chunk holds its slot of the semaphore.
+// It can be set to a function that observes or delays the chunks, typically used for testing.
+var chunkHook func()
+
+// SetMaxFactorialParallelism limits the number of chunks all CalcFactorialParallel calls together
+// multiply at the same time to n, so a pool of many workers computing large values does not
+// start far more goroutines than there are cores. A value of 0 or less restores the default,
+// the number of CPU cores. Calls already running keep the limit they started with.
+func SetMaxFactorialParallelism(n int) {
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+
+	factorialSemaphoreLock.Lock()
+	defer factorialSemaphoreLock.Unlock()
+	factorialSemaphore = make(chan struct{}, n)
+}
+
+// CalcFactorialParallel calculates the factorial of n like CalcFactorial, but splits the range [1, n]
+// into chunks that are multiplied in their own goroutines and combines their products with a balanced
+// product tree. The chunks of every call acquire a slot of the shared semaphore configured with
+// SetMaxFactorialParallelism. Small values are computed sequentially.
+func CalcFactorialParallel(n int64) *big.Int {
+	if n < parallelThreshold {
+		return CalcFactorial(n)
+	}
+
+	factorialSemaphoreLock.Lock()
+	semaphore := factorialSemaphore
+	factorialSemaphoreLock.Unlock()
+
+	products := make([]*big.Int, parallelChunks)
+	size := n / parallelChunks
+
+	var wg sync.WaitGroup
+	for i := range products {
+		from := int64(i)*size + 1
+		to := from + size - 1
+		if i == parallelChunks-1 {
+			// The last chunk takes the remainder of the division.
+			to = n
+		}
+
+		wg.Add(1)
+		go func(i int, from, to int64) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if chunkHook != nil {
+				chunkHook()
+			}
+			products[i] = rangeProduct(from, to)
+		}(i, from, to)
+	}
+	wg.Wait()
+
+	return productTree(products)
+}
+
+// rangeProduct returns the product of the integers in [from, to].
+func rangeProduct(from, to int64) *big.Int {
+	result := big.NewInt(1)
+	factor := new(big.Int)
+	for i := from; i <= to; i++ {
+		result.Mul(result, factor.SetInt64(i))
+	}
+	return result
+}
+
+// productTree multiplies the numbers pairwise until one is left, so the operands of
+// every multiplication are about the same size.
+func productTree(numbers []*big.Int) *big.Int {
+	for len(numbers) > 1 {
+		next := make([]*big.Int, 0, (len(numbers)+1)/2)
+		for i := 0; i+1 < len(numbers); i += 2 {
+			next = append(next, new(big.Int).Mul(numbers[i], numbers[i+1]))
+		}
+		if len(numbers)%2 == 1 {
+			next = append(next, numbers[len(numbers)-1])
+		}
+		numbers = next
+	}
+	return numbers[0]
+}
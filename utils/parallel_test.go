@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCalcFactorialParallel(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int64
+	}{
+		{"negative", -1},
+		{"zero", 0},
+		{"below the threshold", 100},
+		{"at the threshold", parallelThreshold},
+		{"not divisible by the chunks", 5003},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			expected := CalcFactorial(test.n)
+			result := CalcFactorialParallel(test.n)
+			if result.Cmp(expected) != 0 {
+				t.Errorf("Expected %d! with %d bits, got %d bits", test.n, expected.BitLen(), result.BitLen())
+			}
+		})
+	}
+}
+
+func TestSetMaxFactorialParallelism(t *testing.T) {
+	const limit = 3
+	SetMaxFactorialParallelism(limit)
+	defer SetMaxFactorialParallelism(0)
+
+	// Record the largest number of chunks that hold a slot at the same time.
+	var active, peak atomic.Int64
+	chunkHook = func() {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+	}
+	defer func() { chunkHook = nil }()
+
+	// Several concurrent calls, as if made by the workers of a pool.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			CalcFactorialParallel(parallelThreshold)
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > limit {
+		t.Errorf("%d chunks ran at the same time, want at most %d", peak.Load(), limit)
+	}
+	if peak.Load() < 2 {
+		t.Errorf("%d chunks ran at the same time, want the calls to run in parallel", peak.Load())
+	}
+}
+
+// BenchmarkCalcFactorialParallel compares CalcFactorialParallel with CalcFactorial from below
+// parallelThreshold up to n = 50000, to show where splitting the range starts to pay off.
+func BenchmarkCalcFactorialParallel(b *testing.B) {
+	for _, n := range []int64{1000, 2500, 5000, 10000, 25000, 50000} {
+		b.Run(fmt.Sprintf("n_%d/sequential", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CalcFactorial(n)
+			}
+		})
+		b.Run(fmt.Sprintf("n_%d/parallel", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CalcFactorialParallel(n)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "math/big"
+
+// RisingFactorial calculates the rising factorial x·(x+1)···(x+n-1), the Pochhammer symbol x^(n).
+// Any x is allowed; the empty product for n = 0 is 1, and RisingFactorial(1, n) equals n!.
+// Like CalcFactorial yields 0 for negative inputs, it returns 0 if n is negative.
+func RisingFactorial(x, n int64) *big.Int {
+	if n < 0 {
+		return undefinedZero() // Returns 0 for negative n as the rising factorial is undefined
+	}
+	return stepProduct(x, n, 1)
+}
+
+// FallingFactorial calculates the falling factorial x·(x-1)···(x-n+1), the Pochhammer symbol x_(n).
+// Any x is allowed; the empty product for n = 0 is 1, FallingFactorial(n, n) equals n!, and the product
+// is 0 if it passes through 0, i.e. for 0 <= x < n.
+// Like CalcFactorial yields 0 for negative inputs, it returns 0 if n is negative.
+func FallingFactorial(x, n int64) *big.Int {
+	if n < 0 {
+		return undefinedZero() // Returns 0 for negative n as the falling factorial is undefined
+	}
+	return stepProduct(x, n, -1)
+}
+
+// stepProduct returns the product of the n factors x, x+step, x+2·step, ...
+func stepProduct(x, n, step int64) *big.Int {
+	result := big.NewInt(1)
+	factor := new(big.Int)
+	for i := int64(0); i < n; i++ {
+		result.Mul(result, factor.SetInt64(x+i*step))
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestRisingFactorial(t *testing.T) {
+	tests := []struct {
+		name     string
+		x, n     int64
+		expected string
+	}{
+		{"rising(2, 3)", 2, 3, "24"},
+		{"empty product", 7, 0, "1"},
+		{"rising(1, n) is n!", 1, 10, "3628800"},
+		{"through zero", -2, 3, "0"},
+		{"negative x", -5, 2, "20"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			if result := RisingFactorial(test.x, test.n); result.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, result.String())
+			}
+		})
+	}
+}
+
+func TestFallingFactorial(t *testing.T) {
+	tests := []struct {
+		name     string
+		x, n     int64
+		expected string
+	}{
+		{"falling(5, 3)", 5, 3, "60"},
+		{"empty product", 7, 0, "1"},
+		{"falling(n, n) is n!", 10, 10, "3628800"},
+		{"through zero", 2, 4, "0"},
+		{"negative x", -2, 3, "-24"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			if result := FallingFactorial(test.x, test.n); result.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, result.String())
+			}
+		})
+	}
+}
+
+func TestPochhammer_Relations(t *testing.T) {
+	// x^(n) = (x+n-1)_(n), and x_(n) = x! / (x-n)! for x >= n.
+	for x := int64(1); x <= 20; x++ {
+		for n := int64(0); n <= x; n++ {
+			rising := RisingFactorial(x, n)
+			falling := FallingFactorial(x+n-1, n)
+			if rising.Cmp(falling) != 0 {
+				t.Errorf("RisingFactorial(%d, %d) = %v, want FallingFactorial(%d, %d) = %v", x, n, rising, x+n-1, n, falling)
+			}
+
+			falling = FallingFactorial(x, n)
+			expected := new(big.Int).Quo(CalcFactorial(x), CalcFactorial(x-n))
+			if falling.Cmp(expected) != 0 {
+				t.Errorf("FallingFactorial(%d, %d) = %v, want %v", x, n, falling, expected)
+			}
+		}
+	}
+}
+
+func TestPochhammer_Invalid(t *testing.T) {
+	if result := RisingFactorial(3, -1); result.Sign() != 0 {
+		t.Errorf("Expected 0 for a negative n of RisingFactorial, got %v", result)
+	}
+	if result := FallingFactorial(3, -1); result.Sign() != 0 {
+		t.Errorf("Expected 0 for a negative n of FallingFactorial, got %v", result)
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "math"
+
+// FactorialScientific returns n! in scientific notation, as a mantissa in [1, 10) and a decimal
+// exponent, so that n! ≈ mantissa × 10^exponent, without computing n!. Like FactorialDigits it takes
+// log10(n!) from lgamma: the exponent is its integer part and the mantissa 10 to its fractional part.
+// The exponent is exact wherever FactorialDigits is, but the mantissa only carries the precision left
+// in the fractional part of a float64 whose integer part is the exponent: about 14 significant digits
+// for 100!, 9 for 1000000! and none at all once the exponent approaches 10^16, around n = 10^15.
+// Returns (0, 0) if n is negative as factorial is undefined.
+func FactorialScientific(n int64) (mantissa float64, exponent int64) {
+	if n < 0 {
+		return 0, 0
+	}
+	if n <= 1 {
+		return 1, 0
+	}
+
+	logGamma, _ := math.Lgamma(float64(n) + 1)
+	log10 := logGamma / math.Ln10
+	whole, fraction := math.Modf(log10)
+	mantissa, exponent = math.Pow(10, fraction), int64(whole)
+	if mantissa >= 10 {
+		// Rounding pushed the fraction up to 1.
+		mantissa, exponent = mantissa/10, exponent+1
+	}
+	return mantissa, exponent
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFactorialScientific(t *testing.T) {
+	tests := []struct {
+		name             string
+		n                int64
+		expectedMantissa float64
+		expectedExponent int64
+	}{
+		{"negative", -1, 0, 0},
+		{"0!", 0, 1, 0},
+		{"1!", 1, 1, 0},
+		{"10!", 10, 3.6288, 6},
+		{"50!", 50, 3.0414093201713376, 64},
+		{"100!", 100, 9.332621544394415, 157},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			mantissa, exponent := FactorialScientific(test.n)
+			if exponent != test.expectedExponent || math.Abs(mantissa-test.expectedMantissa) > 1e-10 {
+				t.Errorf("Expected %ve%d, got %ve%d", test.expectedMantissa, test.expectedExponent, mantissa, exponent)
+			}
+		})
+	}
+}
+
+func TestFactorialScientific_MatchesFactorialDigits(t *testing.T) {
+	for n := int64(0); n <= 2000; n++ {
+		mantissa, exponent := FactorialScientific(n)
+		if exponent+1 != FactorialDigits(n) || mantissa < 1 || mantissa >= 10 {
+			t.Errorf("FactorialScientific(%d) = %ve%d, want a mantissa in [1, 10) and exponent %d", n, mantissa, exponent, FactorialDigits(n)-1)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "math/big"
+
+// FactorialSequence emits 0!, 1!, ..., n! in order on the returned channel and closes it after n!.
+// Every factorial is computed from the previous one with a single multiplication, so the whole
+// sequence costs no more than computing n! alone. Every emitted value is a separate big.Int the
+// receiver may keep or modify. The channel is closed right away if n is negative.
+// The sequence is produced by a goroutine that only ends once the channel has been drained.
+func FactorialSequence(n int64) <-chan *big.Int {
+	sequence := make(chan *big.Int)
+
+	go func() {
+		defer close(sequence)
+		if n < 0 {
+			return
+		}
+
+		current := big.NewInt(1)
+		sequence <- new(big.Int).Set(current)
+		factor := new(big.Int)
+		for k := int64(1); k <= n; k++ {
+			current.Mul(current, factor.SetInt64(k))
+			sequence <- new(big.Int).Set(current)
+		}
+	}()
+	return sequence
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestFactorialSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int64
+		expected []int64
+	}{
+		{"negative", -1, nil},
+		{"zero", 0, []int64{1}},
+		{"five", 5, []int64{1, 1, 2, 6, 24, 120}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			var result []int64
+			for f := range FactorialSequence(test.n) {
+				result = append(result, f.Int64())
+			}
+			if fmt.Sprint(result) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFactorialSequence_MatchesCalcFactorial(t *testing.T) {
+	var previous *big.Int
+	k := int64(0)
+	for f := range FactorialSequence(300) {
+		if f.Cmp(CalcFactorial(k)) != 0 {
+			t.Errorf("Term %d is %v, want %v", k, f, CalcFactorial(k))
+		}
+		// The ratio of consecutive terms is k.
+		if previous != nil && new(big.Int).Div(f, previous).Int64() != k {
+			t.Errorf("Term %d divided by term %d is not %d", k, k-1, k)
+		}
+		previous = new(big.Int).Set(f)
+
+		// Modifying a received term must not affect the following ones.
+		f.SetInt64(-1)
+		k++
+	}
+	if k != 301 {
+		t.Errorf("Received %d terms, want 301", k)
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+)
+
+// minSieveLimit is the smallest limit the shared sieve grows to, so small requests do not
+// make it grow in tiny steps.
+const minSieveLimit = 1 << 10
+
+// sharedSieve caches the primes found so far for every caller of primesUpTo. It only grows,
+// at least doubling its limit each time, so the cost of sieving is amortized over all calls.
+// The primes slice is never modified after it is published, only replaced, so callers may keep
+// the slices they received while the sieve grows.
+var sharedSieve struct {
+	mu sync.RWMutex
+	// limit is the number up to which the primes are known.
+	limit int64
+	// primes holds every prime up to limit in ascending order.
+	primes []int64
+}
+
+// ResetPrimeSieve discards the primes cached by the shared sieve to free their memory.
+// The next call that needs primes sieves them again.
+func ResetPrimeSieve() {
+	sharedSieve.mu.Lock()
+	defer sharedSieve.mu.Unlock()
+	sharedSieve.limit = 0
+	sharedSieve.primes = nil
+}
+
+// primesUpTo returns the primes up to and including n in ascending order. They are taken from the
+// shared sieve, which grows if it does not reach n yet. The returned slice is shared and must not
+// be modified.
+func primesUpTo(n int64) []int64 {
+	if n < 2 {
+		return nil
+	}
+
+	sharedSieve.mu.RLock()
+	limit, primes := sharedSieve.limit, sharedSieve.primes
+	sharedSieve.mu.RUnlock()
+
+	if n > limit {
+		sharedSieve.mu.Lock()
+		// Another caller may have grown the sieve while the lock was released.
+		if n > sharedSieve.limit {
+			newLimit := max(n, 2*sharedSieve.limit, minSieveLimit)
+			sharedSieve.primes = sievePrimes(newLimit)
+			sharedSieve.limit = newLimit
+		}
+		primes = sharedSieve.primes
+		sharedSieve.mu.Unlock()
+	}
+
+	// Cut the cached primes off after n.
+	end := sort.Search(len(primes), func(i int) bool { return primes[i] > n })
+	return primes[:end:end]
+}
+
+// sievePrimes returns the primes up to and including n in ascending order,
+// found with the sieve of Eratosthenes.
+func sievePrimes(n int64) []int64 {
+	if n < 2 {
+		return nil
+	}
+
+	composite := make([]bool, n+1)
+	var primes []int64
+	for i := int64(2); i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j <= n; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
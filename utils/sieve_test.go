@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestPrimesUpTo(t *testing.T) {
+	ResetPrimeSieve()
+	defer ResetPrimeSieve()
+
+	tests := []struct {
+		name     string
+		n        int64
+		expected []int64
+	}{
+		{"below the first prime", 1, nil},
+		{"first prime", 2, []int64{2}},
+		{"prime limit", 13, []int64{2, 3, 5, 7, 11, 13}},
+		{"composite limit", 20, []int64{2, 3, 5, 7, 11, 13, 17, 19}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result := primesUpTo(test.n)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPrimesUpTo_GrowsAndResets(t *testing.T) {
+	ResetPrimeSieve()
+	defer ResetPrimeSieve()
+
+	small := primesUpTo(100)
+	large := primesUpTo(5 * minSieveLimit)
+	if !reflect.DeepEqual(large, sievePrimes(5*minSieveLimit)) {
+		t.Errorf("primesUpTo() after growing the sieve differs from a fresh sieve")
+	}
+	// Growing the sieve must leave the slices handed out earlier intact.
+	if !reflect.DeepEqual(small, sievePrimes(100)) {
+		t.Errorf("primesUpTo(100) changed after the sieve grew: %v", small)
+	}
+
+	ResetPrimeSieve()
+	if sharedSieve.limit != 0 || sharedSieve.primes != nil {
+		t.Errorf("ResetPrimeSieve() kept %d primes up to %d", len(sharedSieve.primes), sharedSieve.limit)
+	}
+	if !reflect.DeepEqual(primesUpTo(100), small) {
+		t.Errorf("primesUpTo(100) after a reset differs from before")
+	}
+}
+
+func TestPrimesUpTo_Concurrent(t *testing.T) {
+	ResetPrimeSieve()
+	defer ResetPrimeSieve()
+
+	var wg sync.WaitGroup
+	for i := int64(1); i <= 16; i++ {
+		wg.Add(1)
+		go func(n int64) {
+			defer wg.Done()
+			if result, expected := primesUpTo(n), sievePrimes(n); !reflect.DeepEqual(result, expected) {
+				t.Errorf("primesUpTo(%d) returned %d primes, want %d", n, len(result), len(expected))
+			}
+		}(i * 1000)
+	}
+	wg.Wait()
+}
+
+func BenchmarkFactorialFactorization_FreshSieve(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ResetPrimeSieve()
+		FactorialFactorization(1000000)
+	}
+}
+
+func BenchmarkFactorialFactorization_SharedSieve(b *testing.B) {
+	ResetPrimeSieve()
+	FactorialFactorization(1000000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FactorialFactorization(1000000)
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import "math/big"
+
+// maxSmallFactorial is the largest n whose factorial fits in a uint64.
+const maxSmallFactorial = 20
+
+// smallFactorials holds n! for every n up to maxSmallFactorial.
+var smallFactorials = [maxSmallFactorial + 1]uint64{
+	1,
+	1,
+	2,
+	6,
+	24,
+	120,
+	720,
+	5040,
+	40320,
+	362880,
+	3628800,
+	39916800,
+	479001600,
+	6227020800,
+	87178291200,
+	1307674368000,
+	20922789888000,
+	355687428096000,
+	6402373705728000,
+	121645100408832000,
+	2432902008176640000,
+}
+
+// smallFactorial returns a new big.Int holding the factorial of the largest m <= n covered by the table,
+// along with m, from which a computation of n! continues multiplying. n must not be negative.
+func smallFactorial(n int64) (*big.Int, int64) {
+	m := min(n, maxSmallFactorial)
+	return new(big.Int).SetUint64(smallFactorials[m]), m
+}
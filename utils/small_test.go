@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// loopFactorial computes n! with the plain multiplication loop, without the table of small factorials.
+func loopFactorial(n int64) *big.Int {
+	result := big.NewInt(1)
+	for i := int64(2); i <= n; i++ {
+		result.Mul(result, big.NewInt(i))
+	}
+	return result
+}
+
+func TestSmallFactorials(t *testing.T) {
+	for n := int64(0); n <= maxSmallFactorial; n++ {
+		if expected := loopFactorial(n); new(big.Int).SetUint64(smallFactorials[n]).Cmp(expected) != 0 {
+			t.Errorf("Table holds %d! = %d, want %v", n, smallFactorials[n], expected)
+		}
+	}
+}
+
+func TestCalcFactorial_AcrossTable(t *testing.T) {
+	// Around the end of the table, where the computation switches to continuing from it.
+	for n := int64(maxSmallFactorial - 2); n <= maxSmallFactorial+3; n++ {
+		expected := loopFactorial(n)
+		if result := CalcFactorial(n); result.Cmp(expected) != 0 {
+			t.Errorf("CalcFactorial(%d) = %v, want %v", n, result, expected)
+		}
+		if result, err := CalcFactorialWith(n, ComputeOptions{}); err != nil || result.Cmp(expected) != 0 {
+			t.Errorf("CalcFactorialWith(%d) = %v, %v, want %v", n, result, err, expected)
+		}
+	}
+
+	// Every result is a value of its own, so modifying it leaves the table untouched.
+	CalcFactorial(5).SetInt64(7)
+	if result := CalcFactorial(5); result.Int64() != 120 {
+		t.Errorf("CalcFactorial(5) = %v after modifying an earlier result, want 120", result)
+	}
+}
+
+// BenchmarkCalcFactorial_Small compares the table lookup of small factorials with the loop it replaces.
+func BenchmarkCalcFactorial_Small(b *testing.B) {
+	for _, n := range []int64{3, 10, 20} {
+		b.Run(fmt.Sprintf("table_%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				CalcFactorial(n)
+			}
+		})
+		b.Run(fmt.Sprintf("loop_%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				loopFactorial(n)
+			}
+		})
+	}
+}
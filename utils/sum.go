@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// SumFactorials calculates the sum of k! for every k in the inclusive range [a, b].
+// Each term is derived from the previous one by a single multiplication, so the whole
+// sum costs about as much as computing b! once.
+// It returns an error wrapping ErrUndefinedFactorial if either bound is negative, and an error
+// if a is greater than b.
+func SumFactorials(a, b int64) (*big.Int, error) {
+	if a < 0 || b < 0 {
+		return nil, undefinedError("invalid range [%d, %d]: bounds must be non-negative", a, b)
+	}
+	if a > b {
+		return nil, fmt.Errorf("invalid range [%d, %d]: lower bound is greater than upper bound", a, b)
+	}
+
+	term := CalcFactorial(a) // The first term, a!
+	sum := new(big.Int).Set(term)
+	for k := a + 1; k <= b; k++ {
+		// k! = (k-1)! * k
+		term.Mul(term, big.NewInt(k))
+		sum.Add(sum, term)
+	}
+
+	return sum, nil
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSumFactorials(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     int64
+		expected string
+	}{
+		{"0!..0!", 0, 0, "1"},
+		{"0!..3!", 0, 3, "10"},
+		{"3!..5!", 3, 5, "150"},
+		{"10!..10!", 10, 10, "3628800"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result, err := SumFactorials(test.a, test.b)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, result.String())
+			}
+		})
+	}
+}
+
+func TestSumFactorials_InvalidRange(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b int64
+	}{
+		{"negative lower bound", -1, 3},
+		{"negative upper bound", 0, -3},
+		{"reversed bounds", 5, 3},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			if _, err := SumFactorials(test.a, test.b); err == nil {
+				t.Errorf("Expected an error for range [%d, %d]", test.a, test.b)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// defaultLeafWords is the number of packed words CalcFactorialTree multiplies sequentially per leaf.
+const defaultLeafWords = 32
+
+// CalcFactorialTree calculates the factorial of n like CalcFactorial, but shaped around how big.Int
+// multiplies. Multiplying a growing product by one small factor at a time, as CalcFactorial does,
+// costs a full pass over the product per factor and never lets big.Int use Karatsuba, which only pays
+// off for operands of similar size. Instead, consecutive factors are first packed into machine words,
+// as many as fit into 64 bits, which divides the number of big multiplications by the number of factors
+// per word. Runs of leafWords packed words are then multiplied sequentially into leaves, where operands
+// are small and schoolbook multiplication is fastest, and the leaves are combined pairwise with a
+// balanced product tree, whose equally sized operands are where big.Int switches to Karatsuba.
+// A leafWords of 0 or less selects the default. Negative inputs yield 0, like CalcFactorial.
+func CalcFactorialTree(n int64, leafWords int) *big.Int {
+	if n < 0 {
+		return undefinedZero()
+	}
+	if leafWords <= 0 {
+		leafWords = defaultLeafWords
+	}
+
+	var leaves []*big.Int
+	leaf := big.NewInt(1)
+	word := new(big.Int)
+	words := 0
+	flush := func(w uint64) {
+		leaf.Mul(leaf, word.SetUint64(w))
+		words++
+		if words == leafWords {
+			leaves = append(leaves, leaf)
+			leaf = big.NewInt(1)
+			words = 0
+		}
+	}
+
+	packed := uint64(1)
+	for k := uint64(2); k <= uint64(n); k++ {
+		if hi, lo := bits.Mul64(packed, k); hi == 0 {
+			packed = lo
+			continue
+		}
+		// The word is full; start the next one with k.
+		flush(packed)
+		packed = k
+	}
+	flush(packed)
+	leaves = append(leaves, leaf)
+
+	return productTree(leaves)
+}
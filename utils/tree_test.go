@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCalcFactorialTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int64
+		leafWords int
+	}{
+		{"negative", -1, 0},
+		{"zero", 0, 0},
+		{"one", 1, 0},
+		{"a single word", 20, 0},
+		{"default leaves", 3000, 0},
+		{"one word per leaf", 1000, 1},
+		{"a single leaf", 1000, 1 << 20},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			expected := CalcFactorial(test.n)
+			if result := CalcFactorialTree(test.n, test.leafWords); result.Cmp(expected) != 0 {
+				t.Errorf("Expected %d! with %d bits, got %d bits", test.n, expected.BitLen(), result.BitLen())
+			}
+		})
+	}
+}
+
+func TestCalcFactorialTree_MatchesCalcFactorial(t *testing.T) {
+	for n := int64(0); n <= 300; n++ {
+		if result := CalcFactorialTree(n, 2); result.Cmp(CalcFactorial(n)) != 0 {
+			t.Errorf("CalcFactorialTree(%d) = %v, want %v", n, result, CalcFactorial(n))
+		}
+	}
+}
+
+// benchmarkFactorialN is the value of the multiplication benchmarks, large enough for Karatsuba to matter.
+const benchmarkFactorialN = 20000
+
+func BenchmarkCalcFactorial_Sequential(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		CalcFactorial(benchmarkFactorialN)
+	}
+}
+
+func BenchmarkCalcFactorialTree(b *testing.B) {
+	for _, leafWords := range []int{1, 8, 32, 128} {
+		b.Run(fmt.Sprintf("leaf_%d", leafWords), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CalcFactorialTree(benchmarkFactorialN, leafWords)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// FactorialTruncated returns n! shortened for display to its leading head and trailing tail digits,
+// e.g. "40238...00000" for 1000! with head and tail 5, along with the total number of digits of n!.
+// Neither part materializes n!: the leading digits are enclosed between a lower and an upper bound of
+// n!, both computed in floating point with just enough precision for them to agree, and the trailing
+// digits follow from computing n! modulo 10^tail. The trailing digits include the trailing zeros.
+// Both parts take time linear in n. If n! has no more than head+tail digits, it is computed and
+// returned in full. Returns an empty string and 0 if n is negative, or head or tail is negative.
+func FactorialTruncated(n int64, head, tail int) (string, int64) {
+	if n < 0 || head < 0 || tail < 0 {
+		return "", 0
+	}
+
+	digits := FactorialDigits(n)
+	if digits <= int64(head+tail) {
+		return CalcFactorial(n).String(), digits
+	}
+
+	return leadingFactorialDigits(n, head, digits) + "..." + trailingFactorialDigits(n, tail), digits
+}
+
+// leadingFactorialDigits returns the first count digits of n!, which has the given number of digits,
+// more than count. It computes a lower and an upper bound of n! and doubles their precision until
+// their leading digits agree, which then are those of n! as well. Should the digits after the first
+// count ones all be zeros, the bounds never agree; once the precision would hold n! in full, it is
+// computed exactly instead.
+func leadingFactorialDigits(n int64, count int, digits int64) string {
+	if count == 0 {
+		return ""
+	}
+
+	exactBits := uint(float64(digits)*math.Log2(10)) + 64
+	for prec := uint(128 + 4*count); prec < exactBits; prec *= 2 {
+		lower, lowerExp := factorialMantissa(n, prec, big.ToZero)
+		upper, upperExp := factorialMantissa(n, prec, big.AwayFromZero)
+		if lowerExp != upperExp {
+			continue
+		}
+		if leading := leadingMantissaDigits(lower, count); leading == leadingMantissaDigits(upper, count) {
+			return leading
+		}
+	}
+	return CalcFactorial(n).String()[:count]
+}
+
+// factorialMantissa returns m and e with n! = m * 10^e and 1 <= m < 10, computed with the given
+// precision. Every step rounds with the given mode, so big.ToZero yields a lower bound of m and
+// big.AwayFromZero an upper one. Consecutive factors are multiplied in a uint64 first, as long as
+// their product fits, which halves the work for large n.
+func factorialMantissa(n int64, prec uint, mode big.RoundingMode) (*big.Float, int64) {
+	m := new(big.Float).SetPrec(prec).SetMode(mode).SetInt64(1)
+	var exp int64
+	scale := new(big.Float).SetFloat64(1e16)
+	factor := new(big.Float)
+
+	chunk := uint64(1)
+	flush := func() {
+		m.Mul(m, factor.SetUint64(chunk))
+		chunk = 1
+		for m.Cmp(scale) >= 0 {
+			m.Quo(m, scale)
+			exp += 16
+		}
+	}
+	for i := uint64(2); i <= uint64(n); i++ {
+		if chunk > math.MaxUint64/i {
+			flush()
+		}
+		chunk *= i
+	}
+	flush()
+
+	ten := new(big.Float).SetInt64(10)
+	for m.Cmp(ten) >= 0 {
+		m.Quo(m, ten)
+		exp++
+	}
+	return m, exp
+}
+
+// leadingMantissaDigits returns the first count digits of a mantissa m between 1 and 10, rounding the
+// scaling like m itself was rounded.
+func leadingMantissaDigits(m *big.Float, count int) string {
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(count-1)), nil))
+	scaled := new(big.Float).SetPrec(m.Prec()).SetMode(m.Mode()).Mul(m, scale)
+	leading, _ := scaled.Int(nil)
+	return leading.String()
+}
+
+// trailingFactorialDigits returns the last count digits of n!, including trailing zeros,
+// padded with leading zeros to count characters.
+func trailingFactorialDigits(n int64, count int) string {
+	if count == 0 {
+		return ""
+	}
+
+	modulus := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(count)), nil)
+	product := big.NewInt(1)
+	factor := new(big.Int)
+	for i := int64(2); i <= n; i++ {
+		product.Mul(product, factor.SetInt64(i))
+		product.Mod(product, modulus)
+		if product.Sign() == 0 {
+			// Every further product is a multiple of the modulus as well.
+			return strings.Repeat("0", count)
+		}
+	}
+	return fmt.Sprintf("%0*s", count, product.String())
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestFactorialTruncated(t *testing.T) {
+	tests := []struct {
+		name       string
+		n          int64
+		head, tail int
+		expected   string
+		digits     int64
+	}{
+		{"short enough to show in full", 10, 4, 3, "3628800", 7},
+		{"20!", 20, 3, 3, "243...000", 19},
+		{"25!", 25, 4, 8, "1551...84000000", 26},
+		{"1000!", 1000, 5, 5, "40238...00000", 2568},
+		{"no head", 1000, 0, 3, "...000", 2568},
+		{"no tail", 1000, 3, 0, "402...", 2568},
+		{"negative n", -1, 3, 3, "", 0},
+		{"negative head", 10, -1, 3, "", 0},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			result, digits := FactorialTruncated(test.n, test.head, test.tail)
+			if result != test.expected || digits != test.digits {
+				t.Errorf("Expected %q with %d digits, got %q with %d digits", test.expected, test.digits, result, digits)
+			}
+		})
+	}
+}
+
+func TestFactorialTruncated_MatchesCalcFactorial(t *testing.T) {
+	const head, tail = 8, 12
+	for n := int64(0); n <= 1500; n++ {
+		full := CalcFactorial(n).String()
+		expected := full
+		if len(full) > head+tail {
+			expected = full[:head] + "..." + full[len(full)-tail:]
+		}
+
+		result, digits := FactorialTruncated(n, head, tail)
+		if result != expected || digits != int64(len(full)) {
+			t.Errorf("FactorialTruncated(%d) = %q with %d digits, want %q with %d digits", n, result, digits, expected, len(full))
+		}
+	}
+}
+
+func TestFactorialTruncated_LongHead(t *testing.T) {
+	// The leading digits are exact however many are requested, not just those a float64 can hold.
+	for _, head := range []int{15, 40} {
+		full := big.NewInt(1)
+		for n := int64(1); n <= 3000; n++ {
+			full.Mul(full, big.NewInt(n))
+			digits := full.String()
+			if len(digits) <= head+1 {
+				continue
+			}
+			if result, _ := FactorialTruncated(n, head, 1); result[:head] != digits[:head] {
+				t.Errorf("FactorialTruncated(%d, %d, 1) starts with %q, want %q", n, head, result[:head], digits[:head])
+			}
+		}
+	}
+}
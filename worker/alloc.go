@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// AllocStats describes the memory allocation behavior of a Pool run.
+// The figures are derived from process-wide runtime.MemStats samples taken when the run starts
+// and when its last worker finishes, so they are approximate: allocations and garbage collections
+// caused by anything else running in the process during the run are included as well.
+type AllocStats struct {
+	// TotalAlloc is the number of bytes allocated on the heap during the run.
+	TotalAlloc uint64
+	// Mallocs is the number of heap objects allocated during the run.
+	Mallocs uint64
+	// NumGC is the number of completed garbage collection cycles during the run.
+	NumGC uint32
+	// PauseTotal is the cumulative stop-the-world pause time of those garbage collections.
+	PauseTotal time.Duration
+}
+
+// allocSampler holds the runtime.MemStats samples taken around a run.
+type allocSampler struct {
+	mu     sync.Mutex
+	before runtime.MemStats
+	after  *runtime.MemStats
+}
+
+// start takes the sample at the start of the run.
+func (s *allocSampler) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runtime.ReadMemStats(&s.before)
+}
+
+// finish takes the sample at the end of the run.
+func (s *allocSampler) finish() {
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.after = &after
+}
+
+// stats returns the difference between the two samples. If the run has not finished yet,
+// the current state of the runtime is used instead of the end sample.
+func (s *allocSampler) stats() AllocStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	after := s.after
+	if after == nil {
+		after = new(runtime.MemStats)
+		runtime.ReadMemStats(after)
+	}
+
+	return AllocStats{
+		TotalAlloc: after.TotalAlloc - s.before.TotalAlloc,
+		Mallocs:    after.Mallocs - s.before.Mallocs,
+		NumGC:      after.NumGC - s.before.NumGC,
+		PauseTotal: time.Duration(after.PauseTotalNs - s.before.PauseTotalNs),
+	}
+}
+
+// AllocStats reports the heap allocations and garbage collections that happened since Start,
+// up to the moment the last worker finished. While the pool is still running it reports the
+// figures so far. The numbers are approximate, see AllocStats for details.
+func (p *Pool) AllocStats() AllocStats {
+	return p.alloc.stats()
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+)
+
+func TestPool_AllocStats(t *testing.T) {
+	values := []int64{500, 1000, 1500}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+	pool.Ordered(len(values))
+
+	stats := pool.AllocStats()
+	if stats.TotalAlloc == 0 {
+		t.Errorf("TotalAlloc = 0, want the bytes allocated by the factorials")
+	}
+	if stats.Mallocs < uint64(len(values)) {
+		t.Errorf("Mallocs = %d, want at least %d", stats.Mallocs, len(values))
+	}
+
+	// Once the run has finished the figures must not change any more.
+	if again := pool.AllocStats(); again != stats {
+		t.Errorf("AllocStats() changed after the run finished: %+v, then %+v", stats, again)
+	}
+}
@@ -0,0 +1,142 @@
+package worker
+
+import "time"
+
+// autoscaleInterval is how often the autoscaler samples the queue depth.
+// It is a variable so tests can speed the autoscaler up.
+var autoscaleInterval = 100 * time.Millisecond
+
+// autoscaleSustain is the number of consecutive samples the queue depth must stay outside the
+// target band before the autoscaler adds or retires a worker, which damps oscillation.
+const autoscaleSustain = 3
+
+// defaultAutoscaleTarget is the target queue depth of Autoscale for a pool configured without one.
+const defaultAutoscaleTarget = 10
+
+// WithAutoscale makes the pool adjust its number of active workers between min and max to keep the
+// queue depth, see QueueDepth, near targetDepth. The pool creates max workers and starts with min of
+// them active; the others are retired, waiting without taking tasks. Every sample the autoscaler takes,
+// a queue deeper than twice targetDepth counts towards activating another worker, and a queue no deeper
+// than half of it towards retiring one. A worker is only added or retired once the queue has stayed
+// outside that band for several samples in a row, and one at a time, so short bursts and the band
+// itself keep the worker count from oscillating. A retired worker finishes its current task first.
+// Autoscaling overrides the Workers field of the Config. See Autoscale to enable it on an existing pool.
+func WithAutoscale(min, max int, targetDepth int) Option {
+	return func(c *Config) {
+		c.AutoscaleMin = min
+		c.AutoscaleMax = max
+		c.AutoscaleTarget = targetDepth
+	}
+}
+
+// Autoscale enables autoscaling on a pool that has not been started yet, like WithAutoscale does when
+// the pool is created: the pool gets exactly max workers, of which min are active at the start. The
+// target queue depth is the one configured for the pool, or defaultAutoscaleTarget if there is none.
+// An error is returned, and the pool left unchanged, if min and max are not valid bounds.
+// Autoscale must not be called once the pool has been started.
+func (p *Pool) Autoscale(min, max int) error {
+	cfg := p.cfg
+	cfg.AutoscaleMin, cfg.AutoscaleMax = min, max
+	if cfg.AutoscaleTarget == 0 {
+		cfg.AutoscaleTarget = defaultAutoscaleTarget
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	cfg.Workers = max
+	p.cfg = cfg
+
+	// None of the workers has been started, so surplus ones can simply be dropped.
+	if len(p.workers) > max {
+		p.workers = p.workers[:max]
+		if p.cfg.ShardedResults {
+			p.workerResults = p.workerResults[:max]
+		}
+	}
+	for workerID := len(p.workers); workerID < max; workerID++ {
+		p.workers = append(p.workers, p.newWorker(workerID))
+	}
+	for _, w := range p.workers {
+		w.active = p.workerActive
+	}
+
+	p.scaleLock.Lock()
+	p.active = min
+	p.scaleLock.Unlock()
+	return nil
+}
+
+// ActiveWorkers returns the number of workers taking tasks. Without autoscaling, that is every worker.
+func (p *Pool) ActiveWorkers() int {
+	if p.cfg.AutoscaleMax == 0 {
+		return len(p.workers)
+	}
+	p.scaleLock.Lock()
+	defer p.scaleLock.Unlock()
+	return p.active
+}
+
+// workerActive reports whether the worker with the given ID may take tasks, along with a channel that
+// is closed the next time the number of active workers changes.
+func (p *Pool) workerActive(id int) (bool, <-chan struct{}) {
+	p.scaleLock.Lock()
+	defer p.scaleLock.Unlock()
+	return id < p.active, p.rescaled
+}
+
+// setActive changes the number of active workers and wakes every worker to check whether it is still
+// active. It has no effect once scaling has been finished.
+func (p *Pool) setActive(n int) {
+	p.scaleLock.Lock()
+	defer p.scaleLock.Unlock()
+	if p.scalingFinished || n == p.active {
+		return
+	}
+	p.active = n
+	close(p.rescaled)
+	p.rescaled = make(chan struct{})
+}
+
+// finishScaling activates every worker for good, so the retired ones see that no tasks are left and exit.
+func (p *Pool) finishScaling() {
+	p.setActive(len(p.workers))
+	p.scaleLock.Lock()
+	p.scalingFinished = true
+	p.scaleLock.Unlock()
+}
+
+// autoscale samples the queue depth every interval and adjusts the number of active workers,
+// until the pool quits.
+func (p *Pool) autoscale(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	high, low := 2*p.cfg.AutoscaleTarget, p.cfg.AutoscaleTarget/2
+	// pressure counts the consecutive samples above the band, positive, or below it, negative.
+	pressure := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.quit:
+			return
+		}
+
+		switch depth := p.QueueDepth(); {
+		case depth > high:
+			pressure = max(pressure, 0) + 1
+		case depth <= low:
+			pressure = min(pressure, 0) - 1
+		default:
+			pressure = 0
+		}
+
+		active := p.ActiveWorkers()
+		if pressure >= autoscaleSustain && active < p.cfg.AutoscaleMax {
+			p.setActive(active + 1)
+			pressure = 0
+		} else if pressure <= -autoscaleSustain && active > p.cfg.AutoscaleMin {
+			p.setActive(active - 1)
+			pressure = 0
+		}
+	}
+}
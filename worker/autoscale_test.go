@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_Autoscale(t *testing.T) {
+	const minWorkers, maxWorkers = 1, 4
+
+	autoscaleInterval = time.Millisecond
+	defer func() { autoscaleInterval = 100 * time.Millisecond }()
+
+	// Every task takes a couple of milliseconds, so a backlog builds up.
+	simulateDelay = func() { time.Sleep(2 * time.Millisecond) }
+	defer func() { simulateDelay = nil }()
+
+	pool, err := NewPool(Config{ThresholdFactor: 1e9}, WithAutoscale(minWorkers, maxWorkers, 2))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if active := pool.ActiveWorkers(); active != minWorkers {
+		t.Errorf("Expected %d active workers before the run, got %d", minWorkers, active)
+	}
+
+	// The tasks channel stays open after the backlog, so the pool goes idle without finishing.
+	tasks := make(chan model.Task, 200)
+	for i := 0; i < cap(tasks); i++ {
+		tasks <- model.Task{ID: i, Value: 10}
+	}
+	pool.Start(tasks)
+
+	// Sample the number of active workers throughout the run.
+	var lowest, highest atomic.Int64
+	lowest.Store(maxWorkers)
+	stopSampling := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		for {
+			active := int64(pool.ActiveWorkers())
+			lowest.Store(min(lowest.Load(), active))
+			highest.Store(max(highest.Load(), active))
+			select {
+			case <-stopSampling:
+				return
+			case <-time.After(100 * time.Microsecond):
+			}
+		}
+	}()
+
+	waitForActive := func(want int, what string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for pool.ActiveWorkers() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the pool to %s to %d workers, it has %d", what, want, pool.ActiveWorkers())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	received := 0
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for range pool.Unordered() {
+			received++
+		}
+	}()
+
+	waitForActive(maxWorkers, "scale up under the backlog")
+	waitForActive(minWorkers, "scale down once idle")
+
+	close(tasks)
+	<-collected
+	close(stopSampling)
+	<-sampled
+
+	if received != cap(tasks) {
+		t.Errorf("Expected %d results, got %d", cap(tasks), received)
+	}
+	if lowest.Load() < minWorkers || highest.Load() > maxWorkers {
+		t.Errorf("Expected between %d and %d active workers, saw %d to %d", minWorkers, maxWorkers, lowest.Load(), highest.Load())
+	}
+}
+
+func TestPool_Autoscale_RetiredWorkersExit(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	pool, err := NewPool(Config{}, WithAutoscale(1, 3, 100))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	tasks := make(chan model.Task, 3)
+	for i := 0; i < cap(tasks); i++ {
+		tasks <- model.Task{ID: i, Value: 5}
+	}
+	close(tasks)
+	pool.Start(tasks)
+
+	// The two retired workers must not keep the pool from finishing.
+	for range pool.Unordered() {
+	}
+	select {
+	case <-pool.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Pool did not finish with retired workers:\n%s", pool.DumpState())
+	}
+}
+
+func TestPool_AutoscaleMethod(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	tests := []struct {
+		name     string
+		workers  int
+		min, max int
+	}{
+		{"adds workers", 2, 1, 4},
+		{"drops surplus workers", 6, 2, 3},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			pool, err := NewPool(Config{Workers: test.workers})
+			if err != nil {
+				t.Fatalf("NewPool() returned an error: %v", err)
+			}
+			if err := pool.Autoscale(test.min, test.max); err != nil {
+				t.Fatalf("Autoscale() returned an error: %v", err)
+			}
+			if active := pool.ActiveWorkers(); active != test.min {
+				t.Errorf("Expected %d active workers before the run, got %d", test.min, active)
+			}
+			if workers := len(pool.Stats().Workers); workers != test.max {
+				t.Errorf("Expected %d workers, got %d", test.max, workers)
+			}
+
+			tasks := make(chan model.Task, 10)
+			for i := 0; i < cap(tasks); i++ {
+				tasks <- model.Task{ID: i, Value: 5}
+			}
+			close(tasks)
+			pool.Start(tasks)
+
+			received := 0
+			for range pool.Unordered() {
+				received++
+			}
+			if received != cap(tasks) {
+				t.Errorf("Expected %d results, got %d", cap(tasks), received)
+			}
+		})
+	}
+}
+
+func TestPool_AutoscaleMethod_Invalid(t *testing.T) {
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := pool.Autoscale(3, 2); err == nil {
+		t.Errorf("Expected an error for a maximum below the minimum")
+	}
+	// The pool is left unchanged.
+	if active := pool.ActiveWorkers(); active != 2 {
+		t.Errorf("Expected %d active workers, got %d", 2, active)
+	}
+}
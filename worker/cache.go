@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+// Cache stores computed factorials by their input, so a value is only computed once.
+// Implementations must be safe for concurrent use, as every worker of a pool shares the same cache.
+// Cached values are handed out as they are and must not be modified.
+type Cache interface {
+	// Get returns the cached factorial of n, if any.
+	Get(n int64) (*big.Int, bool)
+	// Put stores the factorial of n.
+	Put(n int64, factorial *big.Int)
+}
+
+// CacheStats describes how effective a cache has been.
+type CacheStats struct {
+	// Hits is the number of lookups that found a cached factorial.
+	Hits uint64
+	// Misses is the number of lookups that found nothing.
+	Misses uint64
+	// Evictions is the number of factorials the cache dropped to make room for others.
+	Evictions uint64
+}
+
+// HitRate returns the percentage of lookups that found a cached factorial.
+// Returns 0 if there have been no lookups yet.
+func (s CacheStats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups) * 100
+}
+
+// cacheStatsReporter is implemented by caches that count their hits, misses and evictions.
+// The statistics of such a cache are part of the PoolStats.
+type cacheStatsReporter interface {
+	CacheStats() CacheStats
+}
+
+// MapCache is an unbounded Cache backed by a map. It is the default cache of WithSharedCache.
+// Use an LRUCache to bound the memory held by the cache.
+type MapCache struct {
+	mu     sync.RWMutex
+	values map[int64]*big.Int
+	// hits and misses count the lookups.
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewMapCache returns an empty MapCache.
+func NewMapCache() *MapCache {
+	return &MapCache{values: make(map[int64]*big.Int)}
+}
+
+// Get returns the cached factorial of n, if any.
+func (c *MapCache) Get(n int64) (*big.Int, bool) {
+	c.mu.RLock()
+	factorial, ok := c.values[n]
+	c.mu.RUnlock()
+
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return factorial, ok
+}
+
+// Put stores the factorial of n.
+func (c *MapCache) Put(n int64, factorial *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[n] = factorial
+}
+
+// CacheStats returns the number of hits and misses of the cache. A MapCache never evicts.
+// It is safe to call while the cache is in use.
+func (c *MapCache) CacheStats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// LRUCache is a Cache holding at most a fixed number of factorials. When it is full, storing a factorial
+// evicts the least recently used one, so the values requested most often stay cached.
+type LRUCache struct {
+	mu sync.Mutex
+	// capacity is the maximum number of cached factorials.
+	capacity int
+	// order holds the cached entries, most recently used first, and entries indexes them by input.
+	order   *list.List
+	entries map[int64]*list.Element
+	// hits, misses and evictions are only updated while holding mu.
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// lruEntry is a factorial cached by an LRUCache.
+type lruEntry struct {
+	n         int64
+	factorial *big.Int
+}
+
+// NewLRUCache returns an empty LRUCache holding up to capacity factorials.
+// A cache without capacity stores nothing.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{capacity: capacity, order: list.New(), entries: make(map[int64]*list.Element)}
+}
+
+// Get returns the cached factorial of n, if any, and marks it as the most recently used.
+func (c *LRUCache) Get(n int64) (*big.Int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[n]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).factorial, true
+}
+
+// Put stores the factorial of n, evicting the least recently used factorial if the cache is full.
+func (c *LRUCache) Put(n int64, factorial *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		return
+	}
+	if e, ok := c.entries[n]; ok {
+		e.Value.(*lruEntry).factorial = factorial
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).n)
+		c.evictions++
+	}
+	c.entries[n] = c.order.PushFront(&lruEntry{n: n, factorial: factorial})
+}
+
+// Len returns the number of cached factorials.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// CacheStats returns the number of hits, misses and evictions of the cache.
+// It is safe to call while the cache is in use.
+func (c *LRUCache) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Evictions: c.evictions}
+}
@@ -0,0 +1,353 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingCache wraps a Cache and counts hits and misses.
+type countingCache struct {
+	Cache
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+func (c *countingCache) Get(n int64) (*big.Int, bool) {
+	factorial, ok := c.Cache.Get(n)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return factorial, ok
+}
+
+func TestMapCache(t *testing.T) {
+	cache := NewMapCache()
+
+	if _, ok := cache.Get(5); ok {
+		t.Errorf("Get(5) on an empty cache returned a value")
+	}
+
+	cache.Put(5, big.NewInt(120))
+	if factorial, ok := cache.Get(5); !ok || factorial.Cmp(big.NewInt(120)) != 0 {
+		t.Errorf("Get(5) = %v, %v, want 120, true", factorial, ok)
+	}
+}
+
+func TestPool_SharedCache(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Every value appears several times.
+	values := []int64{10, 20, 30, 10, 20, 30, 10, 20, 30}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	cache := &countingCache{Cache: NewMapCache()}
+	pool, err := NewPool(Config{Workers: 1}, WithSharedCache(cache))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+	for i, v := range values {
+		expected := big.NewInt(1)
+		for k := int64(2); k <= v; k++ {
+			expected.Mul(expected, big.NewInt(k))
+		}
+		if sortedResults[i].Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", v, expected, sortedResults[i].Factorial)
+		}
+	}
+
+	// With a single worker, only the first occurrence of each value misses.
+	if cache.misses != 3 || cache.hits != 6 {
+		t.Errorf("Cache saw %d hits and %d misses, want 6 and 3", cache.hits, cache.misses)
+	}
+}
+
+// BenchmarkPool_SharedCache processes a duplicate-rich workload, 1000 tasks over 50 distinct values,
+// and reports the cache hit rate next to the run time.
+func BenchmarkPool_SharedCache(b *testing.B) {
+	benchmarkCache(b, true)
+}
+
+// BenchmarkPool_NoCache processes the same workload as BenchmarkPool_SharedCache without caching.
+func BenchmarkPool_NoCache(b *testing.B) {
+	benchmarkCache(b, false)
+}
+
+func benchmarkCache(b *testing.B, cached bool) {
+	const numTasks = 1000
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]int64, numTasks)
+	for i := range values {
+		values[i] = int64(r.Intn(50) + 950)
+	}
+
+	var hits, lookups int
+	for i := 0; i < b.N; i++ {
+		tasks := make(chan model.Task, numTasks)
+		for id, v := range values {
+			tasks <- model.Task{ID: id, Value: v}
+		}
+		close(tasks)
+
+		cfg := Config{Workers: 8}
+		cache := &countingCache{Cache: NewMapCache()}
+		if cached {
+			cfg.Cache = cache
+		}
+
+		pool, err := NewPool(cfg)
+		if err != nil {
+			b.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.Start(tasks)
+		for range pool.Unordered() {
+		}
+
+		hits += cache.hits
+		lookups += cache.hits + cache.misses
+	}
+
+	if lookups > 0 {
+		b.ReportMetric(float64(hits)/float64(lookups)*100, "%hits")
+	}
+}
+
+func TestMapCache_CacheStats(t *testing.T) {
+	cache := NewMapCache()
+	cache.Get(5)
+	cache.Put(5, big.NewInt(120))
+	cache.Get(5)
+	cache.Get(5)
+
+	stats := cache.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 0 {
+		t.Errorf("CacheStats() = %+v, want 2 hits, 1 miss and no evictions", stats)
+	}
+	if rate := stats.HitRate(); rate < 66 || rate > 67 {
+		t.Errorf("HitRate() = %v, want 66.7", rate)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Put(3, big.NewInt(6))
+	cache.Put(4, big.NewInt(24))
+
+	// Using 3 makes 4 the least recently used, so storing 5 evicts it.
+	cache.Get(3)
+	cache.Put(5, big.NewInt(120))
+
+	if _, ok := cache.Get(4); ok {
+		t.Errorf("Get(4) returned the least recently used factorial, want it evicted")
+	}
+	for n, expected := range map[int64]int64{3: 6, 5: 120} {
+		if factorial, ok := cache.Get(n); !ok || factorial.Cmp(big.NewInt(expected)) != 0 {
+			t.Errorf("Get(%d) = %v, %v, want %d, true", n, factorial, ok, expected)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+
+	// Storing a cached value again replaces it without evicting anything.
+	cache.Put(5, big.NewInt(120))
+	stats := cache.CacheStats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Errorf("CacheStats() = %+v, want 3 hits, 1 miss and 1 eviction", stats)
+	}
+}
+
+func TestLRUCache_WithoutCapacity(t *testing.T) {
+	cache := NewLRUCache(0)
+	cache.Put(5, big.NewInt(120))
+	if _, ok := cache.Get(5); ok || cache.Len() != 0 {
+		t.Errorf("A cache without capacity stored a factorial")
+	}
+}
+
+// neverTimeout is a TimeoutPolicy that keeps every result, for tests running more tasks per worker
+// than withoutTimeouts covers.
+var neverTimeout = TimeoutPolicyFunc(func(model.Task, time.Duration, time.Duration) bool { return false })
+
+func TestPool_LRUCache(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const numTasks = 200
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: int64(r.Intn(50) + 3)}
+	}
+	close(tasks)
+
+	const capacity = 10
+	cache := NewLRUCache(capacity)
+	pool, err := NewPool(Config{Workers: 4}, WithSharedCache(cache), WithTimeoutPolicy(neverTimeout))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	for _, result := range pool.Ordered(numTasks) {
+		if expected := utils.CalcFactorial(result.Task.Value); result.Err != nil || result.Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v (%v)", result.Task.ID, expected, result.Factorial, result.Err)
+		}
+	}
+	if cache.Len() > capacity {
+		t.Errorf("Len() = %d, want at most %d", cache.Len(), capacity)
+	}
+	if stats := pool.Stats().Cache; stats == nil || stats.Evictions == 0 {
+		t.Errorf("Stats().Cache = %v, want evictions from a cache smaller than the values", stats)
+	}
+}
+
+// BenchmarkPool_RandomTasks_LRUCache processes batches of 100 random tasks in [3, 1000], like the
+// generator produces, through a bounded cache that is kept between the batches, and reports its hit rate.
+// A single batch repeats few values, so the cache pays off over the batches of a long-running process.
+func BenchmarkPool_RandomTasks_LRUCache(b *testing.B) {
+	benchmarkRandomTasks(b, NewLRUCache(500))
+}
+
+// BenchmarkPool_RandomTasks_NoCache processes the same batches as BenchmarkPool_RandomTasks_LRUCache
+// without caching.
+func BenchmarkPool_RandomTasks_NoCache(b *testing.B) {
+	benchmarkRandomTasks(b, nil)
+}
+
+func benchmarkRandomTasks(b *testing.B, cache *LRUCache) {
+	const numTasks = 100
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < b.N; i++ {
+		tasks := make(chan model.Task, numTasks)
+		for id := 0; id < numTasks; id++ {
+			tasks <- model.Task{ID: id, Value: int64(r.Intn(998) + 3)}
+		}
+		close(tasks)
+
+		cfg := Config{Workers: 1, TimeoutPolicy: neverTimeout}
+		if cache != nil {
+			cfg.Cache = cache
+		}
+		pool, err := NewPool(cfg)
+		if err != nil {
+			b.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.Start(tasks)
+		for range pool.Unordered() {
+		}
+	}
+
+	if cache != nil {
+		b.ReportMetric(cache.CacheStats().HitRate(), "%hits")
+	}
+}
+
+func TestPool_Stats_Cache(t *testing.T) {
+	withoutTimeouts(t)
+
+	// A duplicate-heavy stream: 3 distinct values, 10 times each.
+	const repeats = 10
+	distinct := []int64{10, 20, 30}
+
+	tasks := make(chan model.Task, repeats*len(distinct))
+	for i := 0; i < cap(tasks); i++ {
+		tasks <- model.Task{ID: i, Value: distinct[i%len(distinct)]}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 1}, WithSharedCache(nil))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if stats := pool.Stats().Cache; stats == nil || *stats != (CacheStats{}) {
+		t.Errorf("Stats().Cache = %v before Start, want empty statistics", stats)
+	}
+	pool.Start(tasks)
+	for range pool.Unordered() {
+	}
+
+	// With a single worker, only the first occurrence of each value misses.
+	stats := pool.Stats().Cache
+	if stats == nil {
+		t.Fatalf("Stats().Cache = nil for a pool with a MapCache")
+	}
+	if stats.Misses != uint64(len(distinct)) || stats.Hits != uint64((repeats-1)*len(distinct)) {
+		t.Errorf("Stats().Cache = %+v, want %d hits and %d misses", *stats, (repeats-1)*len(distinct), len(distinct))
+	}
+
+	// A pool without a cache reports no cache statistics.
+	uncached, err := NewPool(Config{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if stats := uncached.Stats().Cache; stats != nil {
+		t.Errorf("Stats().Cache = %+v for a pool without a cache, want nil", *stats)
+	}
+}
+
+func TestPool_Precompute(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{0, 7, 50, 23, 50, 1}
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2}, WithSharedCache(nil))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := pool.Precompute(50); err != nil {
+		t.Fatalf("Precompute() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	for i, r := range pool.Ordered(len(values)) {
+		if expected := utils.CalcFactorial(values[i]); r.Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected %d! = %v, got %v", i, values[i], expected, r.Factorial)
+		}
+	}
+
+	// Every task was a cache hit.
+	if stats := pool.Stats().Cache; stats.Misses != 0 || stats.Hits != uint64(len(values)) {
+		t.Errorf("Stats().Cache = %+v, want %d hits and no misses", *stats, len(values))
+	}
+}
+
+func TestPool_Precompute_Invalid(t *testing.T) {
+	uncached, err := NewPool(Config{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := uncached.Precompute(10); !errors.Is(err, ErrNoCache) {
+		t.Errorf("Precompute() without a cache returned %v, want ErrNoCache", err)
+	}
+
+	cached, err := NewPool(Config{Workers: 1}, WithSharedCache(nil))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := cached.Precompute(-1); err == nil {
+		t.Errorf("Precompute(-1) returned no error")
+	}
+}
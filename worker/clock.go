@@ -0,0 +1,23 @@
+package worker
+
+import "time"
+
+// Clock tells the current time. Workers read all time through a Clock,
+// so tests can replace the real clock with a fake one they control.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// realClock is a Clock that tells the actual time.
+type realClock struct{}
+
+// Now returns the current local time, including its monotonic clock reading.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// RealClock returns the Clock that tells the actual time. It is the default clock of a worker.
+func RealClock() Clock {
+	return realClock{}
+}
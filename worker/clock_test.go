@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when it is advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRealClock(t *testing.T) {
+	before := time.Now()
+	now := RealClock().Now()
+	if now.Before(before) || now.After(time.Now()) {
+		t.Errorf("RealClock().Now() = %v, want the current time", now)
+	}
+}
+
+func TestNew_DefaultClock(t *testing.T) {
+	testWorker := New(1, nil, nil, nil, nil, nil)
+	if _, ok := testWorker.clock.(realClock); !ok {
+		t.Errorf("New() with a nil clock uses %T, want realClock", testWorker.clock)
+	}
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+)
+
+// BoundedCollector retains at most a fixed number of results. When it is full, adding a result
+// evicts the oldest retained one, in the order the results were added, and hands it to the
+// overflow handler. This bounds the memory used by continuous pipelines.
+type BoundedCollector struct {
+	mu sync.Mutex
+	// buf is a ring buffer holding the retained results.
+	buf []model.Result
+	// head is the index of the oldest retained result in buf.
+	head int
+	// size is the number of retained results.
+	size int
+	// onOverflow is called with every evicted result. It may be nil.
+	onOverflow func(model.Result)
+}
+
+// NewBoundedCollector returns a collector that retains up to capacity results and passes every evicted
+// result to onOverflow, which may be nil to simply drop them. onOverflow is called synchronously from
+// Add, without holding the collector's lock.
+func NewBoundedCollector(capacity int, onOverflow func(model.Result)) *BoundedCollector {
+	return &BoundedCollector{
+		buf:        make([]model.Result, capacity),
+		onOverflow: onOverflow,
+	}
+}
+
+// Add retains the result, evicting the oldest retained result if the collector is full.
+func (c *BoundedCollector) Add(result model.Result) {
+	evicted, overflow := c.add(result)
+	if overflow && c.onOverflow != nil {
+		c.onOverflow(evicted)
+	}
+}
+
+// add retains the result and returns the evicted one, if any.
+func (c *BoundedCollector) add(result model.Result) (model.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.buf) == 0 {
+		// A collector without capacity overflows on every result.
+		return result, true
+	}
+
+	if c.size < len(c.buf) {
+		c.buf[(c.head+c.size)%len(c.buf)] = result
+		c.size++
+		return model.Result{}, false
+	}
+
+	// Overwrite the oldest result, which makes the next one the oldest.
+	evicted := c.buf[c.head]
+	c.buf[c.head] = result
+	c.head = (c.head + 1) % len(c.buf)
+	return evicted, true
+}
+
+// Collect adds every result received on the channel until it is closed.
+func (c *BoundedCollector) Collect(results <-chan model.Result) {
+	for r := range results {
+		c.Add(r)
+	}
+}
+
+// Results returns the retained results, oldest first.
+func (c *BoundedCollector) Results() []model.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := make([]model.Result, c.size)
+	for i := range results {
+		results[i] = c.buf[(c.head+i)%len(c.buf)]
+	}
+	return results
+}
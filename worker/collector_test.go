@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+	"testing"
+)
+
+func TestBoundedCollector(t *testing.T) {
+	var evicted []int
+	collector := NewBoundedCollector(3, func(r model.Result) {
+		evicted = append(evicted, r.Task.ID)
+	})
+
+	for id := 0; id < 5; id++ {
+		collector.Add(model.Result{Task: model.Task{ID: id}})
+	}
+
+	retained := collector.Results()
+	if len(retained) != 3 {
+		t.Fatalf("Results() returned %d results, want 3", len(retained))
+	}
+	for i, r := range retained {
+		if r.Task.ID != i+2 {
+			t.Errorf("Retained result %d belongs to task %d, want %d", i, r.Task.ID, i+2)
+		}
+	}
+
+	// The oldest results must be evicted first.
+	if len(evicted) != 2 || evicted[0] != 0 || evicted[1] != 1 {
+		t.Errorf("Evicted tasks %v, want [0 1]", evicted)
+	}
+}
+
+func TestPool_ResultCapacity(t *testing.T) {
+	const capacity = 10
+	const numTasks = 1000
+
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 3}
+	}
+	close(tasks)
+
+	var mu sync.Mutex
+	overflowed := 0
+	pool, err := NewPool(Config{Workers: 4}, WithResultCapacity(capacity, func(model.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		overflowed++
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	<-pool.Done()
+
+	if retained := pool.Retained(); len(retained) != capacity {
+		t.Errorf("Retained() returned %d results, want %d", len(retained), capacity)
+	}
+	if overflowed != numTasks-capacity {
+		t.Errorf("Overflow handler called %d times, want %d", overflowed, numTasks-capacity)
+	}
+}
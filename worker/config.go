@@ -0,0 +1,185 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/model"
+	"runtime"
+	"time"
+)
+
+// Config holds every tunable of a Pool. The zero value is a valid configuration:
+// each zero field is replaced with a sensible default when the pool is created.
+type Config struct {
+	// Workers is the number of workers started by the pool. With autoscaling, it is AutoscaleMax.
+	// Zero selects the number of CPU cores + 1.
+	Workers int
+	// QueueSize is the capacity of the results channel.
+	// Zero means unbuffered, so every result is handed directly to the consumer.
+	QueueSize int
+	// ThresholdFactor is the multiple of the average processing time of its worker's recent tasks a task
+	// may take before its result is discarded as a timeout. Zero selects 1.1, i.e. 10% above the average.
+	ThresholdFactor float64
+	// TaskDeadline aborts the computation of a task that runs longer, see WithTaskDeadline.
+	// Zero disables it.
+	TaskDeadline time.Duration
+	// TimeoutPolicy decides whether a task timed out. Nil selects a ThresholdPolicy with the ThresholdFactor.
+	TimeoutPolicy TimeoutPolicy
+	// Smoothing switches the average processing time from the simple moving average of the last
+	// 20 tasks to an exponentially weighted moving average with this smoothing factor, between 0 and 1.
+	// Higher values weigh recent tasks more, so the threshold adapts faster to changing conditions.
+	// Zero keeps the simple moving average.
+	Smoothing float64
+	// RetrySlowTasks recomputes a task that exceeds the processing time limit once, instead of
+	// immediately discarding its result. This lets large but valid tasks complete when the
+	// threshold has been tuned by a run of small ones.
+	RetrySlowTasks bool
+	// RetryAllowance is the multiple of the original threshold a retried task may take.
+	// Zero exempts retried tasks from the threshold altogether.
+	RetryAllowance float64
+	// Retries is the number of times a failed task is processed again before its failure is reported,
+	// and RetryBackoff the wait before the first retry, doubling for every further one. See WithRetries.
+	Retries      int
+	RetryBackoff time.Duration
+	// YieldEvery makes every computation yield the processor after this many multiplications,
+	// see utils.ComputeOptions. Zero never yields.
+	YieldEvery int64
+	// WorkerRateLimit caps the number of tasks each worker starts per second, independently of the
+	// other workers. Zero means no limit.
+	WorkerRateLimit float64
+	// CPULimit caps the share of a core's time each worker spends computing, between 0 and 1, by
+	// pausing after every task, see WithCPULimit. Zero, like 1, means no limit.
+	CPULimit float64
+	// AutoscaleMin and AutoscaleMax bound the number of active workers when autoscaling, see WithAutoscale,
+	// which keeps the queue depth near AutoscaleTarget. An AutoscaleMax of 0 disables autoscaling.
+	AutoscaleMin    int
+	AutoscaleMax    int
+	AutoscaleTarget int
+	// MaxBits is the bit-length budget of a single factorial.
+	// Zero means no budget.
+	MaxBits int
+	// Factorization attaches the prime factorization of the factorial to every successful result.
+	Factorization bool
+	// ShardedResults gives every worker its own results channel instead of one shared channel.
+	ShardedResults bool
+	// ResultCapacity makes the pool collect its own results, retaining at most this many of them.
+	// Zero disables collection, leaving the results to the consumer of the results channel.
+	ResultCapacity int
+	// CollectionStrategy selects how Pool.Results delivers the results. The zero value selects
+	// CollectStreaming, or CollectBounded if ResultCapacity is set.
+	CollectionStrategy CollectionStrategy
+	// OnOverflow receives every result evicted from a full collection, oldest first. It may be nil.
+	OnOverflow func(model.Result)
+	// ValidateResult checks every successfully computed result before it is sent.
+	// If it returns an error, the result is marked failed with that error. Nil disables validation.
+	ValidateResult func(model.Result) error
+	// OnIdle is called whenever the pool has become idle, see WithOnIdle. Nil disables it.
+	OnIdle func()
+	// PanicHandler is called on the worker goroutine when processing a task panics, before the task's
+	// result is marked failed with an error wrapping ErrTaskPanicked. Nil selects logging the panic.
+	PanicHandler PanicHandler
+	// TaskHook is called on the worker goroutine before every computation of a task, including retries.
+	// Nil disables the hook.
+	TaskHook func(model.Task)
+	// TaskObserver is notified on the worker goroutine when processing of a task starts and finishes.
+	// Nil disables it.
+	TaskObserver TaskObserver
+	// Cache is shared by all workers to avoid computing the same factorial twice.
+	// Nil disables caching.
+	Cache Cache
+	// Clock is used by the workers for every time measurement.
+	// Nil selects the real clock.
+	Clock Clock
+	// PagedSource fetches the tasks of StartPaged page by page, see WithPagedSource.
+	// Nil makes StartPaged fail.
+	PagedSource PageFunc
+	// Scheduler determines the order in which queued tasks are dispatched.
+	// Nil selects a FIFOScheduler.
+	Scheduler Scheduler
+	// IDs assigns the IDs of the tasks submitted with SubmitFuture, see WithIDAllocator.
+	// Nil keeps the IDs the tasks are submitted with.
+	IDs generator.IDAllocator
+}
+
+// Option modifies a Config. Options are applied by NewPool on top of the Config it receives.
+type Option func(*Config)
+
+// Validate reports the first inconsistency in the configuration, after defaults have been applied.
+func (c Config) Validate() error {
+	c = c.withDefaults()
+	if c.Workers <= 0 {
+		return errors.New("worker count must be positive")
+	}
+	if c.QueueSize < 0 {
+		return errors.New("queue size must not be negative")
+	}
+	if !(c.ThresholdFactor > 0) {
+		return errors.New("threshold factor must be positive")
+	}
+	if !(c.Smoothing >= 0 && c.Smoothing <= 1) {
+		return errors.New("smoothing factor must be between 0 and 1")
+	}
+	if !(c.RetryAllowance >= 0) {
+		return errors.New("retry allowance must not be negative")
+	}
+	if c.ResultCapacity < 0 {
+		return errors.New("result capacity must not be negative")
+	}
+	if c.YieldEvery < 0 {
+		return errors.New("yield interval must not be negative")
+	}
+	if !(c.WorkerRateLimit >= 0) {
+		return errors.New("worker rate limit must not be negative")
+	}
+	if !(c.CPULimit >= 0 && c.CPULimit <= 1) {
+		return errors.New("CPU limit must be between 0 and 1")
+	}
+	if c.CollectionStrategy < CollectStreaming || c.CollectionStrategy > CollectBounded {
+		return errors.New("unknown collection strategy")
+	}
+	if c.CollectionStrategy == CollectBounded && c.ResultCapacity == 0 {
+		return errors.New("bounded collection requires a result capacity")
+	}
+	if c.CollectionStrategy == CollectOrdered && c.ResultCapacity > 0 {
+		return errors.New("ordered collection cannot be combined with a result capacity")
+	}
+	if c.AutoscaleMax != 0 && (c.AutoscaleMin < 1 || c.AutoscaleMax < c.AutoscaleMin) {
+		return errors.New("autoscaling requires 1 <= min <= max workers")
+	}
+	if c.AutoscaleMax != 0 && c.AutoscaleTarget < 1 {
+		return errors.New("autoscaling requires a positive target queue depth")
+	}
+	if c.Retries < 0 {
+		return errors.New("number of retries must not be negative")
+	}
+	if c.RetryBackoff < 0 {
+		return errors.New("retry backoff must not be negative")
+	}
+	if c.TaskDeadline < 0 {
+		return errors.New("task deadline must not be negative")
+	}
+	if c.MaxBits < 0 {
+		return errors.New("bit budget must not be negative")
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the configuration with every zero field replaced by its default.
+func (c Config) withDefaults() Config {
+	if c.AutoscaleMax > 0 {
+		c.Workers = c.AutoscaleMax
+	}
+	if c.Workers == 0 {
+		c.Workers = runtime.NumCPU() + 1
+	}
+	if c.ThresholdFactor == 0 {
+		c.ThresholdFactor = defaultThresholdFactor
+	}
+	if c.ResultCapacity > 0 && c.CollectionStrategy == CollectStreaming {
+		c.CollectionStrategy = CollectBounded
+	}
+	if c.Scheduler == nil {
+		c.Scheduler = NewFIFOScheduler()
+	}
+	return c
+}
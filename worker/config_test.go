@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"math"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"zero value", Config{}, false},
+		{"explicit values", Config{Workers: 4, QueueSize: 10, ThresholdFactor: 2, MaxBits: 1024}, false},
+		{"negative worker count", Config{Workers: -1}, true},
+		{"negative queue size", Config{QueueSize: -1}, true},
+		{"negative threshold factor", Config{ThresholdFactor: -0.5}, true},
+		{"NaN threshold factor", Config{ThresholdFactor: math.NaN()}, true},
+		{"negative bit budget", Config{MaxBits: -1}, true},
+		{"negative task deadline", Config{TaskDeadline: -time.Second}, true},
+		{"negative retries", Config{Retries: -1}, true},
+		{"negative retry backoff", Config{Retries: 1, RetryBackoff: -time.Second}, true},
+		{"smoothing factor above 1", Config{Smoothing: 1.5}, true},
+		{"negative smoothing factor", Config{Smoothing: -0.1}, true},
+		{"negative worker rate limit", Config{WorkerRateLimit: -1}, true},
+		{"CPU limit above 1", Config{CPULimit: 1.5}, true},
+		{"NaN CPU limit", Config{CPULimit: math.NaN()}, true},
+		{"negative yield interval", Config{YieldEvery: -1}, true},
+		{"unknown collection strategy", Config{CollectionStrategy: CollectBounded + 1}, true},
+		{"bounded collection without capacity", Config{CollectionStrategy: CollectBounded}, true},
+		{"ordered collection with capacity", Config{CollectionStrategy: CollectOrdered, ResultCapacity: 4}, true},
+		{"autoscaling without a minimum", Config{AutoscaleMax: 4, AutoscaleTarget: 1}, true},
+		{"autoscaling maximum below the minimum", Config{AutoscaleMin: 4, AutoscaleMax: 2, AutoscaleTarget: 1}, true},
+		{"autoscaling without a target", Config{AutoscaleMin: 1, AutoscaleMax: 2}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cfg.Validate()
+			if test.wantErr && err == nil {
+				t.Errorf("Validate() returned no error")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("Validate() returned an unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Defaults(t *testing.T) {
+	cfg := Config{}.withDefaults()
+
+	if cfg.Workers != runtime.NumCPU()+1 {
+		t.Errorf("Workers = %d, want %d", cfg.Workers, runtime.NumCPU()+1)
+	}
+	if cfg.ThresholdFactor != defaultThresholdFactor {
+		t.Errorf("ThresholdFactor = %v, want %v", cfg.ThresholdFactor, defaultThresholdFactor)
+	}
+	if _, ok := cfg.Scheduler.(*FIFOScheduler); !ok {
+		t.Errorf("Scheduler = %T, want *FIFOScheduler", cfg.Scheduler)
+	}
+}
+
+func TestNewPool_InvalidConfig(t *testing.T) {
+	if _, err := NewPool(Config{Workers: -1}); err == nil {
+		t.Errorf("NewPool() accepted a negative worker count")
+	}
+	if _, err := NewPool(Config{}, WithBitBudget(-1)); err == nil {
+		t.Errorf("NewPool() accepted a negative bit budget set through an option")
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// Coordinator splits batches of tasks across several pools, e.g. pools configured for different
+// resource groups, and merges their results back into the order of the tasks.
+type Coordinator struct {
+	pools []*Pool
+}
+
+// NewCoordinator returns a Coordinator over the given pools, which must not have been started.
+func NewCoordinator(pools ...*Pool) (*Coordinator, error) {
+	if len(pools) == 0 {
+		return nil, errors.New("coordinator needs at least one pool")
+	}
+	return &Coordinator{pools: pools}, nil
+}
+
+// Process runs the tasks on the coordinator's pools and returns their results in the order of the
+// tasks, regardless of which pool processed them. Every task goes to the pool with the least load at
+// the time, measured as its queued and running tasks per active worker, so larger pools take on more work.
+// The task IDs must be unique within the batch. As pools cannot be restarted, Process starts every
+// pool and may only be called once.
+func (c *Coordinator) Process(tasks []model.Task) []model.Result {
+	inputs := make([]chan model.Task, len(c.pools))
+	outputs := make([]<-chan model.Result, len(c.pools))
+	for i, p := range c.pools {
+		inputs[i] = make(chan model.Task)
+		p.Start(inputs[i])
+		outputs[i] = p.Unordered()
+	}
+
+	// Distribute the tasks while the results are collected, so no pool blocks on its results.
+	go func() {
+		for _, task := range tasks {
+			inputs[c.leastLoaded()] <- task
+		}
+		for _, input := range inputs {
+			close(input)
+		}
+	}()
+
+	index := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+	}
+	ordered := make([]model.Result, len(tasks))
+	for r := range Merge(outputs...) {
+		ordered[index[r.Task.ID]] = r
+	}
+	return ordered
+}
+
+// leastLoaded returns the index of the pool with the fewest queued and running tasks per active worker.
+// Retired workers of an autoscaling pool take no tasks, so they do not count.
+func (c *Coordinator) leastLoaded() int {
+	best, bestLoad := 0, 0.0
+	for i, p := range c.pools {
+		load := float64(p.QueueDepth()+len(p.CurrentTasks())) / float64(p.ActiveWorkers())
+		if i == 0 || load < bestLoad {
+			best, bestLoad = i, load
+		}
+	}
+	return best
+}
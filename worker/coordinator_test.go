@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"math/rand"
+	"testing"
+)
+
+func TestCoordinator_Process(t *testing.T) {
+	// Pools of different sizes, as if they represented different resource groups.
+	// The batch is too long for withoutTimeouts, so a huge threshold keeps results from timing out.
+	var pools []*Pool
+	for _, workers := range []int{1, 2, 4} {
+		pool, err := NewPool(Config{Workers: workers, ThresholdFactor: 1e9})
+		if err != nil {
+			t.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pools = append(pools, pool)
+	}
+
+	coordinator, err := NewCoordinator(pools...)
+	if err != nil {
+		t.Fatalf("NewCoordinator() returned an error: %v", err)
+	}
+
+	// The IDs are shuffled, so the order of the results must follow the tasks, not the IDs.
+	const numTasks = 100
+	r := rand.New(rand.NewSource(1))
+	tasks := make([]model.Task, numTasks)
+	for i, id := range r.Perm(numTasks) {
+		tasks[i] = model.Task{ID: id, Value: 3 + r.Int63n(200)}
+	}
+
+	results := coordinator.Process(tasks)
+
+	if len(results) != numTasks {
+		t.Fatalf("Process() returned %d results, want %d", len(results), numTasks)
+	}
+	for i, result := range results {
+		if result.Task.ID != tasks[i].ID {
+			t.Errorf("Result %d is for task %d, want task %d", i, result.Task.ID, tasks[i].ID)
+		}
+		if expected := utils.CalcFactorial(tasks[i].Value); result.Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", tasks[i].Value, expected, result.Factorial)
+		}
+	}
+
+	processed := 0
+	for i, pool := range pools {
+		poolProcessed := 0
+		for _, ws := range pool.Stats().Workers {
+			poolProcessed += ws.Processed
+		}
+		if poolProcessed == 0 {
+			t.Errorf("Pool %d processed no task", i)
+		}
+		processed += poolProcessed
+	}
+	if processed != numTasks {
+		t.Errorf("The pools processed %d tasks, want %d", processed, numTasks)
+	}
+}
+
+func TestNewCoordinator_NoPools(t *testing.T) {
+	if _, err := NewCoordinator(); err == nil {
+		t.Errorf("NewCoordinator() without pools returned no error")
+	}
+}
+
+func TestCoordinator_LeastLoaded_ActiveWorkers(t *testing.T) {
+	// The first pool has four workers, but only one of them is active, so its two queued tasks weigh
+	// twice as much as the two queued tasks of the second pool, which has two active workers.
+	autoscaled, err := NewPool(Config{}, WithAutoscale(1, 4, 1000))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	fixed, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	autoscaled.queued.Store(2)
+	fixed.queued.Store(2)
+
+	coordinator, err := NewCoordinator(autoscaled, fixed)
+	if err != nil {
+		t.Fatalf("NewCoordinator() returned an error: %v", err)
+	}
+	if best := coordinator.leastLoaded(); best != 1 {
+		t.Errorf("leastLoaded() = %d, want 1, the pool with more active workers", best)
+	}
+}
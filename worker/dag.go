@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// ErrDependencyCycle is returned when the dependencies of a batch of tasks form a cycle.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// ValidateDependencies checks that the tasks of a batch can be executed in dependency order:
+// task IDs must be unique, every dependency must refer to a task of the batch, and the
+// dependencies must not form a cycle. Cycles are reported with ErrDependencyCycle.
+func ValidateDependencies(tasks []model.Task) error {
+	indegree := make(map[int]int, len(tasks))
+	for _, task := range tasks {
+		if _, ok := indegree[task.ID]; ok {
+			return fmt.Errorf("duplicate task ID %d", task.ID)
+		}
+		indegree[task.ID] = len(task.DependsOn)
+	}
+
+	dependents := make(map[int][]int)
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if _, ok := indegree[dep]; !ok {
+				return fmt.Errorf("task %d depends on unknown task %d", task.ID, dep)
+			}
+			dependents[dep] = append(dependents[dep], task.ID)
+		}
+	}
+
+	// Kahn's algorithm: repeatedly remove the tasks without unresolved dependencies.
+	// Whatever cannot be removed is part of, or depends on, a cycle.
+	var ready []int
+	for _, task := range tasks {
+		if indegree[task.ID] == 0 {
+			ready = append(ready, task.ID)
+		}
+	}
+	resolved := 0
+	for len(ready) > 0 {
+		id := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		resolved++
+		for _, dependent := range dependents[id] {
+			indegree[dependent]--
+			if indegree[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if resolved < len(tasks) {
+		for _, task := range tasks {
+			if indegree[task.ID] > 0 {
+				return fmt.Errorf("%w involving task %d", ErrDependencyCycle, task.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// StartBatch validates the dependencies of the tasks with ValidateDependencies and, if they are
+// valid, starts processing the batch like Start. A task is only dispatched once every task it
+// depends on has delivered its result. Nothing is started if an error is returned.
+// Dependencies only affect scheduling: the value of a task is not derived from the results it depends on.
+func (p *Pool) StartBatch(tasks []model.Task) error {
+	if err := ValidateDependencies(tasks); err != nil {
+		return err
+	}
+
+	deps := newDependencyTracker(len(p.workers))
+	for _, w := range p.workers {
+		w.completed = deps.completed
+	}
+
+	// Queue the whole batch up front, the dispatch loop holds back what is not ready yet.
+	queue := make(chan model.Task, len(tasks))
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+
+	p.start(context.Background(), queue, deps)
+	return nil
+}
+
+// dependencyTracker keeps the tasks whose dependencies have not completed yet.
+// It is only accessed from the dispatch loop. All methods are no-ops on a nil tracker,
+// in which case every task is ready immediately.
+type dependencyTracker struct {
+	// completed receives the ID of every task after its result has been sent.
+	completed chan int
+	// done holds the IDs of the completed tasks.
+	done map[int]bool
+	// waiting holds the tasks that are not ready yet, by task ID.
+	waiting map[int]*waitingTask
+	// dependents maps a task ID to the IDs of the waiting tasks that depend on it.
+	dependents map[int][]int
+	// inflight is the number of dispatched tasks that have not completed yet.
+	inflight int
+}
+
+// waitingTask is a task together with the number of its dependencies that have not completed yet.
+type waitingTask struct {
+	task      model.Task
+	remaining int
+}
+
+// newDependencyTracker returns an empty tracker for a pool with numWorkers workers.
+func newDependencyTracker(numWorkers int) *dependencyTracker {
+	return &dependencyTracker{
+		// At most one task per worker can be in flight, so the workers never block on notifying
+		// completion, not even after the dispatch loop has stopped listening.
+		completed:  make(chan int, numWorkers),
+		done:       make(map[int]bool),
+		waiting:    make(map[int]*waitingTask),
+		dependents: make(map[int][]int),
+	}
+}
+
+// ready reports whether every dependency of the task has completed.
+// If not, the task is kept until they have.
+func (d *dependencyTracker) ready(task model.Task) bool {
+	if d == nil {
+		return true
+	}
+
+	remaining := 0
+	for _, dep := range task.DependsOn {
+		if !d.done[dep] {
+			remaining++
+			d.dependents[dep] = append(d.dependents[dep], task.ID)
+		}
+	}
+	if remaining == 0 {
+		return true
+	}
+
+	d.waiting[task.ID] = &waitingTask{task: task, remaining: remaining}
+	return false
+}
+
+// dispatched records that a task has been handed to a worker.
+func (d *dependencyTracker) dispatched() {
+	if d == nil {
+		return
+	}
+	d.inflight++
+}
+
+// complete records that the task with the given ID has completed
+// and returns the waiting tasks that became ready because of it.
+func (d *dependencyTracker) complete(id int) []model.Task {
+	if d == nil {
+		return nil
+	}
+
+	d.inflight--
+	d.done[id] = true
+
+	var ready []model.Task
+	for _, dependent := range d.dependents[id] {
+		w := d.waiting[dependent]
+		w.remaining--
+		if w.remaining == 0 {
+			delete(d.waiting, dependent)
+			ready = append(ready, w.task)
+		}
+	}
+	delete(d.dependents, id)
+	return ready
+}
+
+// blocked reports whether tasks are waiting for dependencies that are still being processed.
+func (d *dependencyTracker) blocked() bool {
+	if d == nil {
+		return false
+	}
+	return len(d.waiting) > 0 && d.inflight > 0
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		tasks   []model.Task
+		wantErr bool
+	}{
+		{"no dependencies", []model.Task{{ID: 0}, {ID: 1}}, false},
+		{"chain", []model.Task{{ID: 0}, {ID: 1, DependsOn: []int{0}}, {ID: 2, DependsOn: []int{1}}}, false},
+		{"diamond", []model.Task{{ID: 0}, {ID: 1, DependsOn: []int{0}}, {ID: 2, DependsOn: []int{0}}, {ID: 3, DependsOn: []int{1, 2}}}, false},
+		{"duplicate ID", []model.Task{{ID: 0}, {ID: 0}}, true},
+		{"unknown dependency", []model.Task{{ID: 0, DependsOn: []int{7}}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateDependencies(test.tasks)
+			if test.wantErr && err == nil {
+				t.Errorf("ValidateDependencies() returned no error")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("ValidateDependencies() returned an unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateDependencies_Cycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []model.Task
+	}{
+		{"self dependency", []model.Task{{ID: 0, DependsOn: []int{0}}}},
+		{"two tasks", []model.Task{{ID: 0, DependsOn: []int{1}}, {ID: 1, DependsOn: []int{0}}}},
+		{"behind a valid task", []model.Task{{ID: 0}, {ID: 1, DependsOn: []int{0, 3}}, {ID: 2, DependsOn: []int{1}}, {ID: 3, DependsOn: []int{2}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateDependencies(test.tasks); !errors.Is(err, ErrDependencyCycle) {
+				t.Errorf("ValidateDependencies() = %v, want ErrDependencyCycle", err)
+			}
+		})
+	}
+}
+
+func TestPool_StartBatch_DependencyOrder(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Task 4 depends on tasks 2 and 3, which depend on task 1, which depends on task 0.
+	// Task 5 is independent.
+	tasks := []model.Task{
+		{ID: 4, Value: 10, DependsOn: []int{2, 3}},
+		{ID: 3, Value: 9, DependsOn: []int{1}},
+		{ID: 2, Value: 8, DependsOn: []int{1}},
+		{ID: 1, Value: 7, DependsOn: []int{0}},
+		{ID: 0, Value: 6},
+		{ID: 5, Value: 5},
+	}
+
+	pool, err := NewPool(Config{Workers: 4})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := pool.StartBatch(tasks); err != nil {
+		t.Fatalf("StartBatch() returned an error: %v", err)
+	}
+
+	// Record the position in which each task's result arrived.
+	position := make(map[int]int)
+	for result := range pool.Unordered() {
+		position[result.Task.ID] = len(position)
+	}
+
+	if len(position) != len(tasks) {
+		t.Fatalf("Received %d results, want %d", len(position), len(tasks))
+	}
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if position[dep] > position[task.ID] {
+				t.Errorf("Task %d completed before its dependency %d", task.ID, dep)
+			}
+		}
+	}
+}
+
+func TestPool_StartBatch_RejectsCycle(t *testing.T) {
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	tasks := []model.Task{{ID: 0, DependsOn: []int{1}}, {ID: 1, DependsOn: []int{0}}}
+	if err := pool.StartBatch(tasks); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("StartBatch() = %v, want ErrDependencyCycle", err)
+	}
+}
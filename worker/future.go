@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// ErrPoolClosed is returned for a task submitted to a pool that no longer accepts tasks.
+var ErrPoolClosed = errors.New("pool is closed")
+
+// Future is the handle of a task submitted with SubmitFuture. It resolves once the task's result
+// is available. It is safe for concurrent use, and Get may be called any number of times.
+type Future struct {
+	// task is the submitted task.
+	task model.Task
+	// done is closed once the future has resolved.
+	done chan struct{}
+	// result and err are the outcome of the task. They are set before done is closed.
+	result model.Result
+	err    error
+}
+
+// Get blocks until the task has completed and returns its result, or returns the error of ctx if it is
+// done first. Cancelling ctx only stops waiting; the task is still processed, and a later Get can
+// still return its result. The error is ErrPoolClosed if the pool stopped before accepting the task.
+func (f *Future) Get(ctx context.Context) (model.Result, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return model.Result{}, ctx.Err()
+	}
+}
+
+// Task returns the submitted task, with the ID it was given by the pool's IDAllocator, if any.
+func (f *Future) Task() model.Task {
+	return f.task
+}
+
+// resolve completes the future with the outcome of its task.
+func (f *Future) resolve(result model.Result, err error) {
+	f.result = result
+	f.err = err
+	close(f.done)
+}
+
+// WithIDAllocator makes SubmitFuture assign the IDs of the submitted tasks from ids. Sharing ids with
+// the generators feeding the pool's tasks channel keeps the IDs unique across both paths.
+func WithIDAllocator(ids generator.IDAllocator) Option {
+	return func(c *Config) {
+		c.IDs = ids
+	}
+}
+
+// SubmitFuture submits a task to a started pool and returns a Future that resolves with its result,
+// which saves correlating task IDs with results for request/response patterns. The result is delivered
+// only to the future, not to the results channel. The pool accepts submitted tasks for as long as its
+// incoming tasks channel is open; SubmitFuture blocks until the task has been accepted or the pool has
+// stopped, in which case the future resolves with ErrPoolClosed. It also resolves with ErrPoolClosed if
+// the pool stops before the task has completed, e.g. because the context of the run was cancelled.
+// The task's ID must not be used by any other task of the pool. Only the IDs of other pending futures
+// are checked, in which case the future resolves with an error right away; a task submitted through the
+// tasks channel with the same ID would take the future's result instead. A pool configured with
+// WithIDAllocator replaces the task's ID with the next one of its allocator, which rules this out if
+// the tasks channel is fed by generators sharing the allocator, see generator.WithIDAllocator.
+func (p *Pool) SubmitFuture(task model.Task) *Future {
+	if p.cfg.IDs != nil {
+		task.ID = p.cfg.IDs.Next()
+	}
+	f := &Future{task: task, done: make(chan struct{})}
+
+	p.futuresLock.Lock()
+	if _, ok := p.futures[task.ID]; ok {
+		p.futuresLock.Unlock()
+		f.resolve(model.Result{Task: task}, fmt.Errorf("task ID %d is already awaited by another future", task.ID))
+		return f
+	}
+	p.futures[task.ID] = f
+	p.futuresLock.Unlock()
+
+	select {
+	case p.submitted <- task:
+	case <-p.quit:
+		// The future may have been resolved by abandonFutures already.
+		p.futuresLock.Lock()
+		pending := p.futures[task.ID] == f
+		delete(p.futures, task.ID)
+		p.futuresLock.Unlock()
+		if pending {
+			f.resolve(model.Result{Task: task}, ErrPoolClosed)
+		}
+	}
+	return f
+}
+
+// resolveFuture resolves the future of the result's task, if there is one, and reports whether it did.
+func (p *Pool) resolveFuture(result model.Result) bool {
+	p.futuresLock.Lock()
+	f, ok := p.futures[result.Task.ID]
+	delete(p.futures, result.Task.ID)
+	p.futuresLock.Unlock()
+
+	if ok {
+		f.resolve(result, nil)
+	}
+	return ok
+}
+
+// abandonFutures resolves the futures of the tasks that will never complete with ErrPoolClosed.
+// It is called once every worker has finished.
+func (p *Pool) abandonFutures() {
+	p.futuresLock.Lock()
+	abandoned := p.futures
+	p.futures = make(map[int]*Future)
+	p.futuresLock.Unlock()
+
+	for _, f := range abandoned {
+		f.resolve(model.Result{Task: f.task}, ErrPoolClosed)
+	}
+}
@@ -0,0 +1,225 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool_SubmitFuture(t *testing.T) {
+	withoutTimeouts(t)
+
+	tasks := make(chan model.Task)
+	pool, err := NewPool(Config{Workers: 3})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	// Await several futures concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			value := int64(id + 3)
+			result, err := pool.SubmitFuture(model.Task{ID: id, Value: value}).Get(context.Background())
+			if err != nil {
+				t.Errorf("Future of task %d returned an error: %v", id, err)
+				return
+			}
+			if result.Task.ID != id || result.Factorial.Cmp(utils.CalcFactorial(value)) != 0 {
+				t.Errorf("Future of task %d resolved with task %d and %v, want %v", id, result.Task.ID, result.Factorial, utils.CalcFactorial(value))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The results of futures are not delivered to the results channel.
+	close(tasks)
+	for result := range pool.Unordered() {
+		t.Errorf("Results channel delivered task %d, which was awaited by a future", result.Task.ID)
+	}
+	<-pool.Done()
+
+	if _, err := pool.SubmitFuture(model.Task{ID: 10, Value: 3}).Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Future submitted after the run returned %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestFuture_GetCancelled(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// Hold the task in processing until the test releases it.
+	release := make(chan struct{})
+	simulateDelay = func() {
+		<-release
+	}
+	defer func() { simulateDelay = nil }()
+
+	tasks := make(chan model.Task)
+	defer close(tasks)
+	pool, err := NewPool(Config{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	future := pool.SubmitFuture(model.Task{ID: 0, Value: 5})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := future.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get() with an expired context returned %v, want context.DeadlineExceeded", err)
+	}
+
+	// The task is still processed, and the future still resolves.
+	close(release)
+	result, err := future.Get(context.Background())
+	if err != nil || result.Factorial.Cmp(utils.CalcFactorial(5)) != 0 {
+		t.Errorf("Get() after the task completed returned %v with error %v, want %v", result.Factorial, err, utils.CalcFactorial(5))
+	}
+
+	if _, err := pool.SubmitFuture(model.Task{ID: 0, Value: 5}).Get(context.Background()); err != nil {
+		t.Errorf("Reusing the ID of a resolved future returned an error: %v", err)
+	}
+}
+
+func TestPool_SubmitFuture_DuplicateID(t *testing.T) {
+	release := make(chan struct{})
+	simulateDelay = func() {
+		<-release
+	}
+	defer func() { simulateDelay = nil }()
+
+	tasks := make(chan model.Task)
+	defer close(tasks)
+	pool, err := NewPool(Config{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	first := pool.SubmitFuture(model.Task{ID: 1, Value: 5})
+	if _, err := pool.SubmitFuture(model.Task{ID: 1, Value: 7}).Get(context.Background()); err == nil {
+		t.Errorf("Submitting a second future for task 1 returned no error")
+	}
+
+	close(release)
+	if _, err := first.Get(context.Background()); err != nil {
+		t.Errorf("First future of task 1 returned an error: %v", err)
+	}
+}
+
+func TestPool_SubmitFuture_Cancelled(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// Hold the first task in processing until the test releases it, so the others stay queued.
+	release := make(chan struct{})
+	simulateDelay = func() {
+		<-release
+	}
+	defer func() { simulateDelay = nil }()
+
+	tasks := make(chan model.Task)
+	defer close(tasks)
+	pool, err := NewPool(Config{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.StartContext(ctx, tasks)
+
+	var futures []*Future
+	for id := 0; id < 3; id++ {
+		futures = append(futures, pool.SubmitFuture(model.Task{ID: id, Value: 5}))
+	}
+	cancel()
+	for !pool.dispatchClosed.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	// Every future resolves, the queued ones with ErrPoolClosed.
+	for id, future := range futures {
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+		result, err := future.Get(waitCtx)
+		waitCancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Future of task %d never resolved after the run was cancelled", id)
+		}
+		if id > 0 && (!errors.Is(err, ErrPoolClosed) || result.Task.ID != id) {
+			t.Errorf("Future of queued task %d resolved with task %d and error %v, want ErrPoolClosed", id, result.Task.ID, err)
+		}
+	}
+	<-pool.Done()
+}
+
+func TestPool_SubmitFuture_IDAllocator(t *testing.T) {
+	withoutTimeouts(t)
+
+	// The generator and the futures share an allocator, so their tasks never share an ID.
+	const numTasks = 20
+	ids := generator.NewCounterAllocator()
+	tasks := make(chan model.Task)
+	pool, err := NewPool(Config{Workers: 3}, WithIDAllocator(ids))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	// The pool stops accepting futures once its tasks channel is closed, so the generated tasks are
+	// forwarded on a channel of their own, and the tasks channel is closed once the futures are done.
+	generatedTasks := make(chan model.Task)
+	go generator.GenerateTasks(numTasks, generatedTasks, generator.WithIDAllocator(ids))
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for task := range generatedTasks {
+			tasks <- task
+		}
+	}()
+
+	// Collect the generated results while the futures are awaited, so no worker blocks on them.
+	generated := make(chan []int)
+	go func() {
+		var ids []int
+		for result := range pool.Unordered() {
+			ids = append(ids, result.Task.ID)
+		}
+		generated <- ids
+	}()
+
+	seen := make(map[int]bool)
+	for i := 0; i < numTasks; i++ {
+		// Every future is submitted with ID 0, which the allocator replaces.
+		future := pool.SubmitFuture(model.Task{ID: 0, Value: 5})
+		result, err := future.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Future %d returned an error: %v", i, err)
+		}
+		if result.Task.ID != future.Task().ID {
+			t.Errorf("Future of task %d resolved with task %d", future.Task().ID, result.Task.ID)
+		}
+		seen[result.Task.ID] = true
+	}
+	<-forwarded
+	close(tasks)
+	for _, id := range <-generated {
+		if seen[id] {
+			t.Errorf("Task ID %d was assigned to a generated task and a future", id)
+		}
+		seen[id] = true
+	}
+
+	if len(seen) != 2*numTasks {
+		t.Errorf("Got %d distinct task IDs, want %d", len(seen), 2*numTasks)
+	}
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"math/big"
+	"sort"
+)
+
+// ErrIncompleteGroup is wrapped by the error of a group whose results did not all arrive.
+var ErrIncompleteGroup = errors.New("group incomplete")
+
+// Reducer combines the factorials of a group's tasks, ordered by task ID, into the group's aggregate.
+// It must not modify the factorials, which may be shared through a cache.
+type Reducer func(factorials []*big.Int) *big.Int
+
+// ReduceSum is a Reducer that adds up the factorials.
+func ReduceSum(factorials []*big.Int) *big.Int {
+	sum := big.NewInt(0)
+	for _, f := range factorials {
+		sum.Add(sum, f)
+	}
+	return sum
+}
+
+// ReduceProduct is a Reducer that multiplies the factorials.
+func ReduceProduct(factorials []*big.Int) *big.Int {
+	product := big.NewInt(1)
+	for _, f := range factorials {
+		product.Mul(product, f)
+	}
+	return product
+}
+
+// GroupResult is the aggregate of the results of a group of tasks.
+type GroupResult struct {
+	// GroupID identifies the group.
+	GroupID string
+	// Results holds the results of the group's tasks that arrived, ordered by task ID.
+	Results []model.Result
+	// Value is the reduced aggregate of the group's factorials. It is 0 if the group failed.
+	Value *big.Int
+	// Err is set if a task of the group failed, or if the group is incomplete, in which case it wraps
+	// ErrIncompleteGroup.
+	Err error
+}
+
+// GroupCollector combines the results of tasks by their GroupID, delivering one GroupResult per group
+// as soon as all of its tasks have completed. This supports map-reduce style workloads, where every
+// task computes a part and only the aggregate is of interest.
+//
+// A group is complete once as many results have arrived as its size says. Groups without a known size
+// are only complete once the input is closed, and groups that are still incomplete by then are
+// delivered as failed. A group with a failed task fails as a whole, and its Value is not reduced.
+// Results without a GroupID are ignored.
+type GroupCollector struct {
+	// grouped receives every group once it is complete.
+	grouped chan GroupResult
+	// sizes holds the number of tasks of every group of known size.
+	sizes map[string]int
+	// reduce combines the factorials of a group.
+	reduce Reducer
+}
+
+// NewGroupCollector starts combining the results of the channel into groups with the reducer. The sizes
+// map the IDs of the groups to their number of tasks; it may be nil, and is not modified.
+func NewGroupCollector(results <-chan model.Result, sizes map[string]int, reduce Reducer) *GroupCollector {
+	c := &GroupCollector{
+		grouped: make(chan GroupResult),
+		sizes:   sizes,
+		reduce:  reduce,
+	}
+
+	go func() {
+		defer close(c.grouped)
+
+		pending := make(map[string][]model.Result)
+		for r := range results {
+			id := r.Task.GroupID
+			if id == "" {
+				continue
+			}
+			pending[id] = append(pending[id], r)
+			if size, ok := c.sizes[id]; ok && len(pending[id]) >= size {
+				c.grouped <- c.combine(id, pending[id], true)
+				delete(pending, id)
+			}
+		}
+
+		// Deliver the remaining groups in the order of their IDs.
+		ids := make([]string, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			_, sized := c.sizes[id]
+			c.grouped <- c.combine(id, pending[id], !sized)
+		}
+	}()
+	return c
+}
+
+// Results returns the channel on which the groups are delivered as they complete. It must be drained;
+// it is closed once the input has been closed and every group has been delivered.
+func (c *GroupCollector) Results() <-chan GroupResult {
+	return c.grouped
+}
+
+// combine reduces the results of the group, which failed if it is not complete.
+func (c *GroupCollector) combine(id string, results []model.Result, complete bool) GroupResult {
+	sort.Slice(results, func(i, j int) bool { return results[i].Task.ID < results[j].Task.ID })
+	g := GroupResult{GroupID: id, Results: results, Value: big.NewInt(0)}
+
+	if !complete {
+		g.Err = fmt.Errorf("group %q: %d of %d results: %w", id, len(results), c.sizes[id], ErrIncompleteGroup)
+		return g
+	}
+	factorials := make([]*big.Int, len(results))
+	for i, r := range results {
+		if failed(r) {
+			g.Err = fmt.Errorf("group %q: %w", id, resultError(r))
+			return g
+		}
+		factorials[i] = r.Factorial
+	}
+	g.Value = c.reduce(factorials)
+	return g
+}
+
+// Groups returns a GroupCollector over the pool's results, see NewGroupCollector.
+// It must not be combined with Unordered or Ordered.
+func (p *Pool) Groups(sizes map[string]int, reduce Reducer) *GroupCollector {
+	return NewGroupCollector(p.Unordered(), sizes, reduce)
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"math/big"
+	"testing"
+)
+
+func TestPool_Groups(t *testing.T) {
+	withoutTimeouts(t)
+
+	tasks := []model.Task{
+		{ID: 0, Value: 3, GroupID: "sum"},
+		{ID: 1, Value: 4, GroupID: "sum"},
+		{ID: 2, Value: 5, GroupID: "sum"},
+		{ID: 3, Value: 7},
+		{ID: 4, Value: 6, GroupID: "failing"},
+		{ID: 5, Value: 13, GroupID: "failing"},
+		{ID: 6, Value: 2, GroupID: "incomplete"},
+		{ID: 7, Value: 8, GroupID: "unsized"},
+		{ID: 8, Value: 9, GroupID: "unsized"},
+	}
+	sizes := map[string]int{"sum": 3, "failing": 2, "incomplete": 2}
+
+	in := make(chan model.Task, len(tasks))
+	for _, task := range tasks {
+		in <- task
+	}
+	close(in)
+
+	rejected := errors.New("rejected")
+	pool, err := NewPool(Config{Workers: 3}, WithResultValidation(func(r model.Result) error {
+		if r.Task.Value == 13 {
+			return rejected
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(in)
+
+	groups := make(map[string]GroupResult)
+	for g := range pool.Groups(sizes, ReduceSum).Results() {
+		if _, ok := groups[g.GroupID]; ok {
+			t.Errorf("Group %q was delivered twice", g.GroupID)
+		}
+		groups[g.GroupID] = g
+	}
+	if len(groups) != 4 {
+		t.Errorf("Expected 4 groups, got %d", len(groups))
+	}
+
+	sum := func(values ...int64) *big.Int {
+		factorials := make([]*big.Int, len(values))
+		for i, v := range values {
+			factorials[i] = utils.CalcFactorial(v)
+		}
+		return ReduceSum(factorials)
+	}
+	tests := []struct {
+		group    string
+		expected *big.Int
+		ids      []int
+		err      error
+	}{
+		{"sum", sum(3, 4, 5), []int{0, 1, 2}, nil},
+		{"unsized", sum(8, 9), []int{7, 8}, nil},
+		{"failing", big.NewInt(0), []int{4, 5}, rejected},
+		{"incomplete", big.NewInt(0), []int{6}, ErrIncompleteGroup},
+	}
+	for _, test := range tests {
+		g := groups[test.group]
+		if g.Value == nil || g.Value.Cmp(test.expected) != 0 {
+			t.Errorf("Group %q expected value %v, got %v", test.group, test.expected, g.Value)
+		}
+		if !errors.Is(g.Err, test.err) || (test.err == nil) != (g.Err == nil) {
+			t.Errorf("Group %q expected error %v, got %v", test.group, test.err, g.Err)
+		}
+		if len(g.Results) != len(test.ids) {
+			t.Errorf("Group %q expected the results of tasks %v, got %d results", test.group, test.ids, len(g.Results))
+			continue
+		}
+		for i, id := range test.ids {
+			if g.Results[i].Task.ID != id {
+				t.Errorf("Group %q expected the results of tasks %v in order, got task %d at %d", test.group, test.ids, g.Results[i].Task.ID, i)
+			}
+		}
+	}
+}
+
+func TestReduceProduct(t *testing.T) {
+	factorials := []*big.Int{big.NewInt(2), big.NewInt(6), big.NewInt(24)}
+	if product := ReduceProduct(factorials); product.Cmp(big.NewInt(288)) != 0 {
+		t.Errorf("Expected 288, got %v", product)
+	}
+	if product := ReduceProduct(nil); product.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Expected the empty product 1, got %v", product)
+	}
+	// The inputs are left untouched.
+	if factorials[0].Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("ReduceProduct() modified its input to %v", factorials[0])
+	}
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"log"
+)
+
+// HybridCollector streams results in completion order while it builds the ordered view of them,
+// so a consumer can react to every result as soon as it completes and still get all of them sorted
+// by task ID at the end, without a second pass over the results.
+type HybridCollector struct {
+	// streamed receives every result in completion order.
+	streamed chan model.Result
+	// ordered holds every result at the index of its task ID.
+	ordered []model.Result
+	// done is closed once the input has been drained.
+	done chan struct{}
+}
+
+// NewHybridCollector starts collecting the results channel, which must deliver the results of
+// length tasks with IDs from 0 to length-1, like for SortResults. A result with an ID outside that
+// range is still streamed, but is left out of the ordered view with a logged warning.
+func NewHybridCollector(results <-chan model.Result, length int) *HybridCollector {
+	c := &HybridCollector{
+		streamed: make(chan model.Result),
+		ordered:  make([]model.Result, max(length, 0)),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(c.done)
+		defer close(c.streamed)
+		for r := range results {
+			if r.Task.ID < 0 || r.Task.ID >= len(c.ordered) {
+				log.Printf("HybridCollector: dropping the result of task %d with an ID outside [0, %d) from the ordered view", r.Task.ID, len(c.ordered))
+			} else {
+				c.ordered[r.Task.ID] = r
+			}
+			c.streamed <- r
+		}
+	}()
+	return c
+}
+
+// Results returns the channel on which the results are delivered in the order they complete.
+// The channel is closed once every result has been delivered or Close has been called. A result
+// reaches the ordered view before it is delivered, so a slow consumer delays the collection.
+func (c *HybridCollector) Results() <-chan model.Result {
+	return c.streamed
+}
+
+// Close waits for the input to be drained and returns the results sorted by task ID, exactly like
+// SortResults would. Results not read from Results yet are discarded from the stream, but they
+// are still part of the ordered view.
+func (c *HybridCollector) Close() []model.Result {
+	for range c.streamed {
+	}
+	<-c.done
+	return c.ordered
+}
+
+// Hybrid returns a HybridCollector over the pool's results, which streams them in completion
+// order and returns them sorted by task ID on Close. The length must be the number of tasks
+// submitted to the pool. It must not be combined with Unordered or Ordered.
+func (p *Pool) Hybrid(length int) *HybridCollector {
+	return NewHybridCollector(p.Unordered(), length)
+}
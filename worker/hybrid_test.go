@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"reflect"
+	"testing"
+)
+
+// shuffledResults returns a closed channel delivering the results of the values in the given order of IDs.
+func shuffledResults(values []int64, order []int) chan model.Result {
+	results := make(chan model.Result, len(order))
+	for _, id := range order {
+		results <- model.Result{Task: model.Task{ID: id, Value: values[id]}, Factorial: utils.CalcFactorial(values[id])}
+	}
+	close(results)
+	return results
+}
+
+func TestHybridCollector(t *testing.T) {
+	values := []int64{3, 5, 7, 10, 12}
+	order := []int{3, 0, 4, 1, 2}
+
+	c := NewHybridCollector(shuffledResults(values, order), len(values))
+
+	var streamed []int
+	for r := range c.Results() {
+		streamed = append(streamed, r.Task.ID)
+	}
+	if !reflect.DeepEqual(streamed, order) {
+		t.Errorf("Results() delivered IDs %v, want completion order %v", streamed, order)
+	}
+
+	ordered := c.Close()
+	expected := SortResults(shuffledResults(values, order), len(values))
+	if !reflect.DeepEqual(ordered, expected) {
+		t.Errorf("Close() = %v, want %v like SortResults", ordered, expected)
+	}
+}
+
+func TestHybridCollector_CloseWithoutStreaming(t *testing.T) {
+	checkGoroutines(t)
+	values := []int64{3, 5, 7}
+	order := []int{2, 1, 0}
+
+	// Closing without reading the stream must still collect every result.
+	ordered := NewHybridCollector(shuffledResults(values, order), len(values)).Close()
+	expected := SortResults(shuffledResults(values, order), len(values))
+	if !reflect.DeepEqual(ordered, expected) {
+		t.Errorf("Close() = %v, want %v like SortResults", ordered, expected)
+	}
+}
+
+func TestHybridCollector_IDOutOfRange(t *testing.T) {
+	checkGoroutines(t)
+	const length = 3
+	results := make(chan model.Result, 6)
+	for _, id := range []int{0, 1, 2, length, 1 << 40, -1} {
+		results <- model.Result{Task: model.Task{ID: id, Value: int64(id + 10)}}
+	}
+	close(results)
+
+	// Every result is streamed, but only those with an ID in [0, length) are ordered.
+	c := NewHybridCollector(results, length)
+	streamed := 0
+	for range c.Results() {
+		streamed++
+	}
+	if streamed != 6 {
+		t.Errorf("Results() delivered %d results, want %d", streamed, 6)
+	}
+	ordered := c.Close()
+	if len(ordered) != length {
+		t.Fatalf("Expected %d results, got %d", length, len(ordered))
+	}
+	for i, r := range ordered {
+		if r.Task.ID != i || r.Task.Value != int64(i+10) {
+			t.Errorf("Expected result %d to be task %d, got task %d (%d!)", i, i, r.Task.ID, r.Task.Value)
+		}
+	}
+}
+
+func TestPool_Hybrid(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10, 12, 15}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 3})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	c := pool.Hybrid(len(values))
+	streamed := 0
+	for range c.Results() {
+		streamed++
+	}
+	if streamed != len(values) {
+		t.Errorf("Results() delivered %d results, want %d", streamed, len(values))
+	}
+
+	for i, r := range c.Close() {
+		if r.Task.ID != i || r.Factorial.Cmp(utils.CalcFactorial(values[i])) != 0 {
+			t.Errorf("Ordered result %d is task %d with %v, want %v", i, r.Task.ID, r.Factorial, utils.CalcFactorial(values[i]))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package worker
+
+import "time"
+
+// idleDebounce is how long the pool must stay idle before OnIdle is called.
+var idleDebounce = 10 * time.Millisecond
+
+// taskFinished counts a task whose result has been sent and wakes the idle watcher.
+func (p *Pool) taskFinished() {
+	p.finished.Add(1)
+	select {
+	case p.idleCheck <- struct{}{}:
+	default:
+		// A check is already pending.
+	}
+}
+
+// idle reports whether every task received so far has finished and none is waiting to be received.
+func (p *Pool) idle() bool {
+	return len(p.incoming) == 0 && p.received.Load() == p.finished.Load()
+}
+
+// watchIdle calls OnIdle whenever the pool has stayed idle for idleDebounce, at most once per idle
+// period. A period ends when another task is received. It returns once the run has finished, after
+// a final check, so the idleness at the end of the run is reported as well.
+func (p *Pool) watchIdle() {
+	// reported is the number of received tasks at the last call, which identifies its idle period.
+	reported := int64(0)
+	for {
+		select {
+		case <-p.idleCheck:
+		case <-p.quit:
+			if p.idle() && p.received.Load() != reported {
+				p.cfg.OnIdle()
+			}
+			return
+		}
+
+		// Wait for the pool to settle, unless the run ends in the meantime.
+		timer := time.NewTimer(idleDebounce)
+		select {
+		case <-timer.C:
+		case <-p.quit:
+			timer.Stop()
+		}
+
+		if received := p.received.Load(); received != reported && p.idle() {
+			reported = received
+			p.cfg.OnIdle()
+		}
+	}
+}
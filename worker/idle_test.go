@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_OnIdle(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// Hold every task in processing until the test releases it.
+	release := make(chan struct{})
+	simulateDelay = func() {
+		<-release
+	}
+	defer func() { simulateDelay = nil }()
+
+	var calls atomic.Int32
+	idle := make(chan struct{}, 10)
+	pool, err := NewPool(Config{Workers: 2}, WithOnIdle(func() {
+		calls.Add(1)
+		idle <- struct{}{}
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	// The tasks channel stays open, as more tasks might come later.
+	tasks := make(chan model.Task, 10)
+	for i := 0; i < 3; i++ {
+		tasks <- model.Task{ID: i, Value: 5}
+	}
+	pool.Start(tasks)
+
+	results := pool.Unordered()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-idle:
+			t.Fatalf("OnIdle was called while %d tasks were still queued or running", 3-i)
+		case <-time.After(2 * idleDebounce):
+		}
+		release <- struct{}{}
+		<-results
+	}
+
+	select {
+	case <-idle:
+	case <-time.After(time.Second):
+		t.Fatalf("OnIdle was not called after the last queued task completed")
+	}
+
+	// Staying idle does not cause more calls.
+	time.Sleep(3 * idleDebounce)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("OnIdle was called %d times during one idle period, want once", n)
+	}
+
+	// A new task starts a new idle period.
+	tasks <- model.Task{ID: 3, Value: 5}
+	close(tasks)
+	release <- struct{}{}
+	for range results {
+	}
+	<-pool.Done()
+
+	select {
+	case <-idle:
+	case <-time.After(time.Second):
+		t.Fatalf("OnIdle was not called after the second idle period began")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("OnIdle was called %d times for two idle periods, want twice", n)
+	}
+}
@@ -0,0 +1,33 @@
+package worker
+
+import "github.com/lipcsei/konstruktor/model"
+
+// RunInline processes the tasks synchronously on the calling goroutine and returns their results
+// in the order they were processed. The tasks pass through the pool's scheduler and are processed
+// one at a time by the first worker, with the same time limits, retries, caching and validation
+// as in a running pool, but without any other goroutine. With a fake clock this makes the ordering
+// and timing of a run fully deterministic, which is meant for tests.
+// Dependencies between the tasks are not enforced. RunInline must not be combined with Start.
+func (p *Pool) RunInline(tasks []model.Task) []model.Result {
+	for _, task := range tasks {
+		p.cfg.Scheduler.Push(task)
+	}
+
+	w := p.workers[0]
+	results := make([]model.Result, 0, len(tasks))
+	for {
+		task, ok := p.cfg.Scheduler.Pop()
+		if !ok {
+			return results
+		}
+
+		startTime := w.clock.Now()
+		w.current.Store(&task)
+		r, attemptStart := w.processWithRetries(task, startTime)
+		w.recordOutcome(r, w.clock.Now().Sub(attemptStart))
+		w.recordResultBits(r)
+		results = append(results, r)
+		w.current.Store(nil)
+		w.addBusyTime(w.clock.Now().Sub(startTime))
+	}
+}
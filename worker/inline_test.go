@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"testing"
+)
+
+func TestPool_RunInline(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7}
+	tasks := make([]model.Task, len(values))
+	for i, v := range values {
+		tasks[i] = model.Task{ID: i, Value: v}
+	}
+
+	// The LIFO scheduler reverses the order of the tasks.
+	var hooked []int
+	pool, err := NewPool(Config{Workers: 2}, WithScheduler(&lifoScheduler{}), WithTaskHook(func(task model.Task) {
+		hooked = append(hooked, task.ID)
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	results := pool.RunInline(tasks)
+	if len(results) != len(values) {
+		t.Fatalf("RunInline() returned %d results, want %d", len(results), len(values))
+	}
+	for i, result := range results {
+		task := tasks[len(tasks)-1-i]
+		if result.Task.ID != task.ID || result.Factorial.Cmp(utils.CalcFactorial(task.Value)) != 0 {
+			t.Errorf("Result %d is %d! = %v, want %d! = %v", i, result.Task.Value, result.Factorial, task.Value, utils.CalcFactorial(task.Value))
+		}
+		if hooked[i] != task.ID {
+			t.Errorf("Task hook call %d saw task %d, want %d", i, hooked[i], task.ID)
+		}
+	}
+
+	if processed := pool.Stats().Workers[0].Processed; processed != len(values) {
+		t.Errorf("The first worker processed %d tasks, want %d", processed, len(values))
+	}
+	if current := pool.CurrentTasks(); len(current) != 0 {
+		t.Errorf("CurrentTasks() = %v after RunInline, want no tasks", current)
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// ErrNoFactorial is wrapped by the error a fail-fast ResultIter reports for a result without a factorial
+// and without an error of its own, which the results of a Pool always have.
+var ErrNoFactorial = errors.New("no factorial computed")
+
+// ResultIter iterates over a results channel:
+//
+//	it := pool.Iter(true)
+//	for it.Next() {
+//		use(it.Result())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// In fail-fast mode, iteration stops at the first failed result and Err reports it. The rest of the
+// channel is then drained and discarded in the background, so the workers are not left blocked on
+// sending results nobody reads.
+type ResultIter struct {
+	results  <-chan model.Result
+	failFast bool
+	current  model.Result
+	err      error
+	done     bool
+}
+
+// NewResultIter returns a ResultIter over the results channel, stopping at the first failed result
+// if failFast is set.
+func NewResultIter(results <-chan model.Result, failFast bool) *ResultIter {
+	return &ResultIter{results: results, failFast: failFast}
+}
+
+// Iter returns a ResultIter over the pool's results, in completion order like Unordered.
+func (p *Pool) Iter(failFast bool) *ResultIter {
+	return NewResultIter(p.Unordered(), failFast)
+}
+
+// Next advances to the next result, which is then available from Result. It returns false once the
+// channel has been closed, or, in fail-fast mode, when the next result has failed.
+func (it *ResultIter) Next() bool {
+	if it.done {
+		return false
+	}
+
+	r, ok := <-it.results
+	if !ok {
+		it.done = true
+		return false
+	}
+	it.current = r
+
+	if it.failFast && failed(r) {
+		it.done = true
+		it.err = resultError(r)
+		// Discard the remaining results, so nobody blocks on sending them.
+		go func() {
+			for range it.results {
+			}
+		}()
+		return false
+	}
+	return true
+}
+
+// Result returns the current result. After Next has returned false because of a failure, it returns
+// the failed result.
+func (it *ResultIter) Result() model.Result {
+	return it.current
+}
+
+// Err returns the error of the failed result that stopped a fail-fast iteration, or nil.
+func (it *ResultIter) Err() error {
+	return it.err
+}
+
+// resultError describes why the result failed.
+func resultError(r model.Result) error {
+	err := r.Err
+	if err == nil {
+		err = ErrNoFactorial
+	}
+	return fmt.Errorf("task %d (%d!): %w", r.Task.ID, r.Task.Value, err)
+}
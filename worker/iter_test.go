@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestResultIter(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10, 12}
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	it := pool.Iter(true)
+	seen := 0
+	for it.Next() {
+		if r := it.Result(); r.Err != nil {
+			t.Errorf("Result() returned a failed result %+v", r)
+		}
+		seen++
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	if seen != len(values) {
+		t.Errorf("Expected %d results, got %d", len(values), seen)
+	}
+	if it.Next() {
+		t.Errorf("Next() returned true after the end")
+	}
+}
+
+func TestResultIter_FailFast(t *testing.T) {
+	checkGoroutines(t)
+	rejected := errors.New("rejected")
+	failures := []struct {
+		name     string
+		result   model.Result
+		expected error
+	}{
+		{"without a factorial", model.Result{Task: model.Task{ID: 1, Value: 500}}, ErrNoFactorial},
+		{"with an error", model.Result{Task: model.Task{ID: 1, Value: 500}, Err: rejected}, rejected},
+	}
+
+	for _, failure := range failures {
+		t.Run(failure.name, func(t *testing.T) {
+			// An unbuffered channel, like the one of a pool without a queue, with more results to come.
+			results := make(chan model.Result)
+			sent := make(chan struct{})
+			go func() {
+				defer close(sent)
+				results <- model.Result{Task: model.Task{ID: 0, Value: 3}, Factorial: big.NewInt(6)}
+				results <- failure.result
+				for i := 2; i < 10; i++ {
+					results <- model.Result{Task: model.Task{ID: i, Value: 3}, Factorial: big.NewInt(6)}
+				}
+				close(results)
+			}()
+
+			it := NewResultIter(results, true)
+			seen := 0
+			for it.Next() {
+				seen++
+			}
+			if seen != 1 {
+				t.Errorf("Expected the iteration to stop after 1 result, got %d", seen)
+			}
+			if err := it.Err(); !errors.Is(err, failure.expected) {
+				t.Errorf("Err() = %v, want it to wrap %v", err, failure.expected)
+			}
+			if it.Result().Task.ID != 1 {
+				t.Errorf("Result() = task %d, want the failed task 1", it.Result().Task.ID)
+			}
+
+			// The remaining results are drained, so the sender does not block.
+			select {
+			case <-sent:
+			case <-time.After(time.Second):
+				t.Fatalf("The remaining results were not drained")
+			}
+		})
+	}
+}
+
+func TestResultIter_WithoutFailFast(t *testing.T) {
+	results := make(chan model.Result, 3)
+	results <- model.Result{Task: model.Task{ID: 0}, Factorial: big.NewInt(1)}
+	results <- model.Result{Task: model.Task{ID: 1}, Err: ErrTaskTimeout}
+	results <- model.Result{Task: model.Task{ID: 2}, Factorial: big.NewInt(1)}
+	close(results)
+
+	it := NewResultIter(results, false)
+	seen := 0
+	for it.Next() {
+		seen++
+	}
+	if seen != 3 || it.Err() != nil {
+		t.Errorf("Expected all 3 results without an error, got %d and %v", seen, it.Err())
+	}
+}
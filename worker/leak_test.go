@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakSettle is how long checkGoroutines gives the goroutines of a finished test to wind down.
+const leakSettle = time.Second
+
+// checkGoroutines fails the test if it leaves goroutines of this module running: any goroutine that is
+// running code of the module once the test and its deferred calls have finished, and that was not
+// running before the test started, counts as leaked unless it exits within leakSettle. Goroutines of
+// the runtime and the testing package are ignored, as are goroutines left over from earlier tests.
+func checkGoroutines(t *testing.T) {
+	t.Helper()
+	before := make(map[string]bool)
+	for _, g := range moduleGoroutines() {
+		before[goroutineHeader(g)] = true
+	}
+
+	t.Cleanup(func() {
+		deadline := time.Now().Add(leakSettle)
+		for {
+			var leaked []string
+			for _, g := range moduleGoroutines() {
+				if !before[goroutineHeader(g)] {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+// moduleGoroutines returns the stack traces of the goroutines running code of this module, other than
+// those running a test.
+func moduleGoroutines() []string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var goroutines []string
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, "github.com/lipcsei/konstruktor/") && !strings.Contains(g, "testing.tRunner") {
+			goroutines = append(goroutines, g)
+		}
+	}
+	return goroutines
+}
+
+// goroutineHeader returns the ID of the goroutine whose stack trace is given, e.g. "goroutine 7".
+func goroutineHeader(stack string) string {
+	var id int
+	if _, err := fmt.Sscanf(stack, "goroutine %d", &id); err != nil {
+		return stack
+	}
+	return fmt.Sprintf("goroutine %d", id)
+}
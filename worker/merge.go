@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+)
+
+// Merge fans in several result channels into a single channel.
+// The returned channel is closed once every input channel has been closed.
+// Results keep their relative order per input channel, but are interleaved across channels.
+func Merge(channels ...<-chan model.Result) <-chan model.Result {
+	merged := make(chan model.Result, len(channels))
+
+	var wg sync.WaitGroup
+	for _, c := range channels {
+		wg.Add(1)
+		go func(c <-chan model.Result) {
+			defer wg.Done()
+			for r := range c {
+				merged <- r
+			}
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()     // Wait for every input channel to be drained.
+		close(merged) // Signal the consumer that no more results will be sent.
+	}()
+
+	return merged
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	channels := make([]<-chan model.Result, 3)
+	id := 0
+	for i := range channels {
+		c := make(chan model.Result, 2)
+		for j := 0; j < 2; j++ {
+			c <- model.Result{Task: model.Task{ID: id}}
+			id++
+		}
+		close(c)
+		channels[i] = c
+	}
+
+	seen := make(map[int]bool)
+	for r := range Merge(channels...) {
+		seen[r.Task.ID] = true
+	}
+
+	if len(seen) != id {
+		t.Errorf("Merge() delivered %d distinct results, want %d", len(seen), id)
+	}
+}
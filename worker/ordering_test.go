@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// orderingStrategies holds every way of getting results in task ID order, each taking the results of
+// the tasks with IDs 0 to len(results)-1 in completion order.
+var orderingStrategies = []struct {
+	name  string
+	order func(results []model.Result) []model.Result
+}{
+	{"SortResults", func(results []model.Result) []model.Result {
+		return SortResults(feed(results), len(results))
+	}},
+	{"SortResultsContext", func(results []model.Result) []model.Result {
+		sorted, _ := SortResultsContext(context.Background(), feed(results), len(results))
+		return sorted
+	}},
+	{"OrderedResults", func(results []model.Result) []model.Result {
+		var ordered []model.Result
+		for r := range OrderedResults(feed(results)) {
+			ordered = append(ordered, r)
+		}
+		return ordered
+	}},
+	{"HybridCollector", func(results []model.Result) []model.Result {
+		return NewHybridCollector(feed(results), len(results)).Close()
+	}},
+	{"WindowCollector", func(results []model.Result) []model.Result {
+		collector := NewWindowCollector(len(results)+1, nil)
+		collector.Collect(feed(results))
+		return collector.Results()
+	}},
+}
+
+// feed returns a closed channel holding the results.
+func feed(results []model.Result) <-chan model.Result {
+	c := make(chan model.Result, len(results))
+	for _, r := range results {
+		c <- r
+	}
+	close(c)
+	return c
+}
+
+// orderedResults returns distinguishable results of the tasks with IDs 0 to n-1, in ID order.
+func orderedResults(n int) []model.Result {
+	results := make([]model.Result, n)
+	for i := range results {
+		results[i] = model.Result{Task: model.Task{ID: i, Value: int64(i)}, Factorial: big.NewInt(int64(i * i)), WorkerID: i % 3}
+	}
+	return results
+}
+
+// checkOrdering feeds the permutation of the results to every strategy and reports those whose output
+// differs from the results in ID order.
+func checkOrdering(t *testing.T, expected, permutation []model.Result) {
+	t.Helper()
+	for _, strategy := range orderingStrategies {
+		input := append([]model.Result(nil), permutation...)
+		ordered := strategy.order(input)
+		if err := sameResults(ordered, expected); err != nil {
+			t.Errorf("%s reordered the completion order %v wrongly: %v", strategy.name, taskIDs(permutation), err)
+		}
+	}
+}
+
+// sameResults reports the first difference between the results.
+func sameResults(actual, expected []model.Result) error {
+	if len(actual) != len(expected) {
+		return fmt.Errorf("got %d results, want %d", len(actual), len(expected))
+	}
+	for i := range expected {
+		a, e := actual[i], expected[i]
+		if a.Task.ID != e.Task.ID || a.Task.Value != e.Task.Value || a.WorkerID != e.WorkerID || a.Factorial.Cmp(e.Factorial) != 0 {
+			return fmt.Errorf("result %d is %+v, want %+v", i, a, e)
+		}
+	}
+	return nil
+}
+
+// taskIDs returns the task IDs of the results, in their order.
+func taskIDs(results []model.Result) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.Task.ID
+	}
+	return ids
+}
+
+// TestOrdering_AllPermutations feeds every completion order of a few results to every strategy.
+func TestOrdering_AllPermutations(t *testing.T) {
+	for n := 0; n <= 5; n++ {
+		expected := orderedResults(n)
+		permutation := append([]model.Result(nil), expected...)
+
+		// Heap's algorithm visits every permutation, swapping a single pair between two of them.
+		var permute func(k int)
+		permute = func(k int) {
+			if k <= 1 {
+				checkOrdering(t, expected, permutation)
+				return
+			}
+			for i := 0; i < k-1; i++ {
+				permute(k - 1)
+				if k%2 == 0 {
+					permutation[i], permutation[k-1] = permutation[k-1], permutation[i]
+				} else {
+					permutation[0], permutation[k-1] = permutation[k-1], permutation[0]
+				}
+			}
+			permute(k - 1)
+		}
+		permute(n)
+	}
+}
+
+// TestOrdering_RandomPermutations feeds random completion orders of larger batches to every strategy.
+func TestOrdering_RandomPermutations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := r.Intn(200) + 6
+		expected := orderedResults(n)
+		permutation := append([]model.Result(nil), expected...)
+		r.Shuffle(n, func(a, b int) { permutation[a], permutation[b] = permutation[b], permutation[a] })
+		checkOrdering(t, expected, permutation)
+	}
+}
@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// ErrNoPagedSource is returned by StartPaged for a pool without a paged source, see WithPagedSource.
+var ErrNoPagedSource = errors.New("pool has no paged source")
+
+// PageFunc fetches a page of tasks, e.g. from a database. It receives the token of the page to fetch,
+// which is empty for the first page, and returns the tasks of the page along with the token of the next
+// page, or an empty token after the last page. A page may be empty without being the last one.
+type PageFunc func(pageToken string) ([]model.Task, string, error)
+
+// WithPagedSource makes StartPaged pull the pool's tasks from fetch, one page at a time, so that a large
+// dataset never has to be loaded into memory at once. The next page is only requested once the tasks of
+// the previous one have been handed to the pool, so at most a page is held in memory beyond the queue.
+// If fetch returns an error, ingestion stops: the tasks already received are processed, and the error
+// is reported by SourceErr.
+func WithPagedSource(fetch PageFunc) Option {
+	return func(c *Config) {
+		c.PagedSource = fetch
+	}
+}
+
+// StartPaged is like Start, but pulls the tasks from the paged source of the pool, see WithPagedSource.
+// It returns ErrNoPagedSource, without starting the pool, if the pool has no paged source.
+func (p *Pool) StartPaged() error {
+	return p.StartPagedContext(context.Background())
+}
+
+// StartPagedContext is like StartPaged, but stops as soon as ctx is cancelled, like StartContext.
+// No further page is requested once ctx has been cancelled.
+func (p *Pool) StartPagedContext(ctx context.Context) error {
+	fetch := p.cfg.PagedSource
+	if fetch == nil {
+		return ErrNoPagedSource
+	}
+
+	var page []model.Task
+	token := ""
+	first := true
+	next := func() (model.Task, bool) {
+		// Skip empty pages until a task turns up or the source is exhausted.
+		for len(page) == 0 {
+			if !first && token == "" {
+				return model.Task{}, false
+			}
+			requested := token
+			var err error
+			page, token, err = fetch(requested)
+			if err != nil {
+				p.setSourceErr(fmt.Errorf("fetching page %q: %w", requested, err))
+				return model.Task{}, false
+			}
+			first = false
+		}
+		task := page[0]
+		page = page[1:]
+		return task, true
+	}
+	p.StartFuncContext(ctx, next)
+	return nil
+}
+
+// SourceErr returns the error that stopped the ingestion of tasks from the paged source, or nil if the
+// source was exhausted, or has not failed yet. Wait for Done to be sure the ingestion is over.
+func (p *Pool) SourceErr() error {
+	p.sourceErrLock.Lock()
+	defer p.sourceErrLock.Unlock()
+	return p.sourceErr
+}
+
+// setSourceErr records the error that stopped the ingestion of tasks.
+func (p *Pool) setSourceErr(err error) {
+	p.sourceErrLock.Lock()
+	defer p.sourceErrLock.Unlock()
+	p.sourceErr = err
+}
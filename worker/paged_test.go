@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"strconv"
+	"testing"
+)
+
+// fakePages serves pages of tasks, the page with index i under the token strconv.Itoa(i), and records the
+// tokens it was asked for. It fails with err when asked for the page with index failAt.
+type fakePages struct {
+	pages     [][]model.Task
+	failAt    int
+	err       error
+	requested []string
+}
+
+func (f *fakePages) fetch(pageToken string) ([]model.Task, string, error) {
+	f.requested = append(f.requested, pageToken)
+	i := 0
+	if pageToken != "" {
+		i, _ = strconv.Atoi(pageToken)
+	}
+	if i == f.failAt {
+		return nil, "", f.err
+	}
+	next := ""
+	if i+1 < len(f.pages) {
+		next = strconv.Itoa(i + 1)
+	}
+	return f.pages[i], next, nil
+}
+
+// taskPages returns pages of the given sizes holding tasks with consecutive IDs from 0.
+func taskPages(sizes ...int) [][]model.Task {
+	var pages [][]model.Task
+	id := 0
+	for _, size := range sizes {
+		page := []model.Task{}
+		for i := 0; i < size; i++ {
+			page = append(page, model.Task{ID: id, Value: int64(id + 3)})
+			id++
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func TestPool_StartPaged(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Four pages, one of them empty, of 9 tasks in all.
+	source := &fakePages{pages: taskPages(4, 0, 3, 2), failAt: -1}
+	pool, err := NewPool(Config{Workers: 2}, WithPagedSource(source.fetch))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := pool.StartPaged(); err != nil {
+		t.Fatalf("StartPaged() returned an error: %v", err)
+	}
+
+	for i, r := range pool.Ordered(9) {
+		if r.Task.ID != i || r.Task.Value != int64(i+3) {
+			t.Errorf("Result %d is task %d with value %d, want task %d with value %d", i, r.Task.ID, r.Task.Value, i, i+3)
+		}
+	}
+	<-pool.Done()
+
+	if err := pool.SourceErr(); err != nil {
+		t.Errorf("SourceErr() = %v, want nil", err)
+	}
+	expected := []string{"", "1", "2", "3"}
+	if len(source.requested) != len(expected) {
+		t.Fatalf("Expected the pages %q to be requested, got %q", expected, source.requested)
+	}
+	for i := range expected {
+		if source.requested[i] != expected[i] {
+			t.Errorf("Expected the pages %q to be requested, got %q", expected, source.requested)
+			break
+		}
+	}
+}
+
+func TestPool_StartPaged_Error(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// The third page fails, so only the tasks of the first two are processed.
+	failure := errors.New("connection lost")
+	source := &fakePages{pages: taskPages(3, 2, 3), failAt: 2, err: failure}
+	pool, err := NewPool(Config{Workers: 2}, WithPagedSource(source.fetch))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := pool.StartPaged(); err != nil {
+		t.Fatalf("StartPaged() returned an error: %v", err)
+	}
+
+	processed := 0
+	for r := range pool.Unordered() {
+		if r.Task.ID >= 5 {
+			t.Errorf("Task %d of the failed page was processed", r.Task.ID)
+		}
+		processed++
+	}
+	<-pool.Done()
+
+	if processed != 5 {
+		t.Errorf("Expected 5 tasks to be processed, got %d", processed)
+	}
+	if err := pool.SourceErr(); !errors.Is(err, failure) {
+		t.Errorf("SourceErr() = %v, want it to wrap %v", err, failure)
+	}
+	if len(source.requested) != 3 {
+		t.Errorf("Expected 3 pages to be requested, got %q", source.requested)
+	}
+}
+
+func TestPool_StartPaged_NoSource(t *testing.T) {
+	pool, err := NewPool(Config{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if err := pool.StartPaged(); !errors.Is(err, ErrNoPagedSource) {
+		t.Errorf("StartPaged() without a paged source returned %v, want ErrNoPagedSource", err)
+	}
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"sort"
+)
+
+// PartitionByRange splits tasks into buckets by value. The boundaries must be sorted in ascending order;
+// with n boundaries there are n+1 buckets, where bucket i holds the tasks whose value lies in
+// [boundaries[i-1], boundaries[i]). The first bucket is open below and the last one is open above.
+// Every task lands in exactly one bucket, keeping its relative order.
+func PartitionByRange(tasks []model.Task, boundaries []int64) [][]model.Task {
+	partitions := make([][]model.Task, len(boundaries)+1)
+	for _, task := range tasks {
+		// The bucket index is the number of boundaries less than or equal to the task's value.
+		i := sort.Search(len(boundaries), func(i int) bool {
+			return boundaries[i] > task.Value
+		})
+		partitions[i] = append(partitions[i], task)
+	}
+	return partitions
+}
+
+// RunPartitioned partitions the tasks by value range and processes each non-empty partition
+// in its own Pool configured by cfg. The results of all pools are merged into the returned
+// channel in completion order, which is closed once every task has been processed.
+// A scheduler cannot be shared between pools, so every pool gets a fresh scheduler of the kind
+// configured, see newPartitionScheduler. An error is returned if the configuration is invalid
+// or its scheduler cannot be recreated.
+func RunPartitioned(tasks []model.Task, boundaries []int64, cfg Config) (<-chan model.Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	// Check the scheduler before any pool is started.
+	if _, err := newPartitionScheduler(cfg.Scheduler); err != nil {
+		return nil, err
+	}
+
+	var channels []<-chan model.Result
+	for _, partition := range PartitionByRange(tasks, boundaries) {
+		if len(partition) == 0 {
+			continue
+		}
+
+		// Queue the whole partition up front, so the pool can drain it at its own pace.
+		partitionTasks := make(chan model.Task, len(partition))
+		for _, task := range partition {
+			partitionTasks <- task
+		}
+		close(partitionTasks)
+
+		partitionCfg := cfg
+		partitionCfg.Scheduler, _ = newPartitionScheduler(cfg.Scheduler)
+		pool, err := NewPool(partitionCfg)
+		if err != nil {
+			return nil, err
+		}
+		pool.Start(partitionTasks)
+		channels = append(channels, pool.Unordered())
+	}
+
+	return Merge(channels...), nil
+}
+
+// newPartitionScheduler returns an empty scheduler of the same kind and with the same settings as
+// scheduler, for one of the pools of RunPartitioned. Only the schedulers of this package can be
+// recreated; an error is returned for any other. A nil scheduler stays nil, selecting the default.
+func newPartitionScheduler(scheduler Scheduler) (Scheduler, error) {
+	switch s := scheduler.(type) {
+	case nil:
+		return nil, nil
+	case *FIFOScheduler:
+		return NewFIFOScheduler(), nil
+	case *PriorityScheduler:
+		return NewPriorityScheduler(s.priority, s.agingRate, s.clock), nil
+	default:
+		return nil, fmt.Errorf("scheduler %T cannot be recreated for every partition", scheduler)
+	}
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+)
+
+func TestPartitionByRange(t *testing.T) {
+	var tasks []model.Task
+	for i := 0; i < 100; i++ {
+		tasks = append(tasks, model.Task{ID: i, Value: int64(i * 10)})
+	}
+	boundaries := []int64{100, 250, 500}
+
+	partitions := PartitionByRange(tasks, boundaries)
+	if len(partitions) != len(boundaries)+1 {
+		t.Fatalf("PartitionByRange() returned %d partitions, want %d", len(partitions), len(boundaries)+1)
+	}
+
+	seen := make(map[int]int)
+	for i, partition := range partitions {
+		for _, task := range partition {
+			seen[task.ID]++
+
+			// Check the task lies within the partition's range.
+			if i > 0 && task.Value < boundaries[i-1] {
+				t.Errorf("Task %d with value %d is below partition %d", task.ID, task.Value, i)
+			}
+			if i < len(boundaries) && task.Value >= boundaries[i] {
+				t.Errorf("Task %d with value %d is above partition %d", task.ID, task.Value, i)
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		if seen[task.ID] != 1 {
+			t.Errorf("Task %d landed in %d partitions, want exactly 1", task.ID, seen[task.ID])
+		}
+	}
+}
+
+func TestRunPartitioned(t *testing.T) {
+	withoutTimeouts(t)
+
+	var tasks []model.Task
+	for i := 0; i < 20; i++ {
+		tasks = append(tasks, model.Task{ID: i, Value: int64(i + 3)})
+	}
+
+	results, err := RunPartitioned(tasks, []int64{8, 15}, Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("RunPartitioned() returned an error: %v", err)
+	}
+	sortedResults := SortResults(results, len(tasks))
+
+	for i, task := range tasks {
+		if sortedResults[i].Task.ID != task.ID {
+			t.Errorf("Result %d belongs to task %d, want %d", i, sortedResults[i].Task.ID, task.ID)
+		}
+		if sortedResults[i].Factorial == nil {
+			t.Errorf("Task %d has no result", task.ID)
+		}
+	}
+}
+
+// queueScheduler is a Scheduler of its own, which RunPartitioned cannot recreate.
+type queueScheduler struct{ FIFOScheduler }
+
+func TestRunPartitioned_Scheduler(t *testing.T) {
+	withoutTimeouts(t)
+
+	var tasks []model.Task
+	for i := 0; i < 20; i++ {
+		tasks = append(tasks, model.Task{ID: i, Value: int64(i + 3), Priority: i % 3})
+	}
+
+	// Every partition gets a scheduler of its own with the same settings, leaving the configured one unused.
+	scheduler := NewPriorityScheduler(TaskPriority, 2, nil)
+	results, err := RunPartitioned(tasks, []int64{8, 15}, Config{Workers: 2, Scheduler: scheduler})
+	if err != nil {
+		t.Fatalf("RunPartitioned() returned an error: %v", err)
+	}
+	for i, r := range SortResults(results, len(tasks)) {
+		if r.Factorial == nil {
+			t.Errorf("Task %d has no result", i)
+		}
+	}
+	if scheduler.pushed != 0 {
+		t.Errorf("The configured scheduler was pushed %d tasks, want 0", scheduler.pushed)
+	}
+
+	fresh, err := newPartitionScheduler(scheduler)
+	if err != nil {
+		t.Fatalf("newPartitionScheduler() returned an error: %v", err)
+	}
+	if priority, ok := fresh.(*PriorityScheduler); !ok || priority == scheduler || priority.agingRate != scheduler.agingRate {
+		t.Errorf("newPartitionScheduler() = %+v, want a new PriorityScheduler aging by %v", fresh, scheduler.agingRate)
+	}
+
+	// A scheduler of another kind is rejected before any pool starts.
+	if _, err := RunPartitioned(tasks, []int64{8, 15}, Config{Workers: 2, Scheduler: &queueScheduler{}}); err == nil {
+		t.Error("RunPartitioned() with a custom scheduler returned no error")
+	}
+}
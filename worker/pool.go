@@ -0,0 +1,578 @@
+package worker
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool runs a fixed number of workers and feeds them tasks through a Scheduler.
+// Incoming tasks are pushed into the scheduler by a single dispatch loop, which pops
+// them again in the scheduler's order and hands them to whichever worker is free.
+type Pool struct {
+	// cfg is the validated configuration of the pool, with defaults applied.
+	cfg Config
+	// workers holds the workers started by the pool, indexed by worker ID.
+	workers []*Worker
+
+	// incoming is the channel the pool receives its tasks from.
+	incoming <-chan model.Task
+	// queued is the number of tasks received from incoming that have not been dispatched yet.
+	queued atomic.Int64
+	// inflight tracks the values being computed, to count redundant computations.
+	inflight *inflightTracker
+	// maxResultBits is the bit length of the largest factorial computed so far.
+	maxResultBits atomic.Int64
+	// received is the total number of tasks received from incoming.
+	received atomic.Int64
+	// sourceErr is the error that stopped the ingestion of tasks from the paged source, if any.
+	sourceErr     error
+	sourceErrLock sync.Mutex
+	// submitted receives the tasks of SubmitFuture.
+	submitted chan model.Task
+	// futures holds the futures of the submitted tasks that have not completed yet, keyed by task ID.
+	futures     map[int]*Future
+	futuresLock sync.Mutex
+	// finished is the total number of tasks whose result has been sent.
+	finished atomic.Int64
+	// idleCheck signals the idle watcher that the pool may have become idle.
+	idleCheck chan struct{}
+
+	// dispatch is the channel the dispatch loop uses to hand tasks to the workers.
+	dispatch chan model.Task
+	// incomingClosed, dispatchClosed and resultsClosed record which channels have been closed.
+	incomingClosed atomic.Bool
+	dispatchClosed atomic.Bool
+	resultsClosed  atomic.Bool
+	// results is the channel the workers send processed tasks to. It is nil in sharded mode.
+	results chan model.Result
+	// workerResults holds the result channel of each worker in sharded mode, indexed by worker ID.
+	workerResults []chan model.Result
+	// merged fans in workerResults when a sharded pool is consumed through Unordered or Ordered.
+	merged     <-chan model.Result
+	mergedOnce sync.Once
+	// quit is closed when the context of the run is cancelled, or once every worker has finished.
+	quit     chan struct{}
+	quitOnce sync.Once
+	// done is closed once the run has finished and, if enabled, all results have been collected.
+	done chan struct{}
+	// strategyResults is the channel returned by Results, set up on its first call.
+	strategyResults <-chan model.Result
+	resultsOnce     sync.Once
+	// collector retains the results when the pool collects them itself. It is nil otherwise.
+	collector *BoundedCollector
+	// wg is used to wait for all workers to finish processing.
+	wg sync.WaitGroup
+
+	// active is the number of workers taking tasks when autoscaling; the workers with a lower ID are active.
+	// rescaled is closed and replaced whenever it changes, until scalingFinished.
+	active          int
+	rescaled        chan struct{}
+	scalingFinished bool
+	scaleLock       sync.Mutex
+
+	// alloc samples the runtime memory statistics around the run.
+	alloc allocSampler
+}
+
+// WithScheduler sets the scheduler used to order queued tasks.
+// By default tasks are dispatched in FIFO order.
+func WithScheduler(scheduler Scheduler) Option {
+	return func(c *Config) {
+		c.Scheduler = scheduler
+	}
+}
+
+// WithBitBudget aborts any factorial whose intermediate result grows beyond maxBits bits.
+// Aborted tasks fail with an error wrapping utils.ErrBitBudgetExceeded.
+// A maxBits of 0 disables the budget, which is the default.
+func WithBitBudget(maxBits int) Option {
+	return func(c *Config) {
+		c.MaxBits = maxBits
+	}
+}
+
+// WithYield makes the workers yield the processor with runtime.Gosched after every given number of
+// multiplications of a computation, so that huge factorials do not starve other goroutines of a process that does more
+// than compute factorials. Yielding costs a little throughput, which is negligible for intervals in the
+// thousands. An interval of 0 never yields, which is the default.
+func WithYield(every int64) Option {
+	return func(c *Config) {
+		c.YieldEvery = every
+	}
+}
+
+// WithEWMA makes the workers compare processing times against an exponentially weighted moving
+// average with the given smoothing factor, instead of the simple moving average of recent tasks.
+// The smoothing factor must be between 0 and 1; higher values adapt faster to changing conditions.
+func WithEWMA(smoothing float64) Option {
+	return func(c *Config) {
+		c.Smoothing = smoothing
+	}
+}
+
+// WithSlowTaskRetry recomputes tasks that exceed the processing time limit once, allowing the retry
+// allowance times the original threshold. An allowance of 0 exempts retried tasks from the threshold,
+// so they always complete. Retried tasks are marked in their result.
+func WithSlowTaskRetry(allowance float64) Option {
+	return func(c *Config) {
+		c.RetrySlowTasks = true
+		c.RetryAllowance = allowance
+	}
+}
+
+// WithResultCapacity makes the pool collect its own results, retaining at most capacity of them.
+// Once the limit is reached, the oldest retained result is evicted for every new one and handed to
+// onOverflow, e.g. to write it to disk; onOverflow may be nil to drop evicted results. Results are
+// evicted in the order they completed, which is not necessarily the order of their task IDs.
+// The retained results are available through Retained; Unordered and Ordered must not be used.
+func WithResultCapacity(capacity int, onOverflow func(model.Result)) Option {
+	return func(c *Config) {
+		c.ResultCapacity = capacity
+		c.OnOverflow = onOverflow
+	}
+}
+
+// WithResultValidation installs a hook that checks every successfully computed result before it
+// is sent, e.g. to sanity-check its number of digits. If the hook returns an error, the result is
+// marked failed: its Factorial is set to nil and its Err to the returned error. Results that already
+// failed, e.g. by timing out, are not passed to the hook.
+// The hook runs on the worker goroutines, so it is called concurrently and must be safe for
+// concurrent use. It must not modify the result's Factorial, which may be shared through a cache.
+func WithResultValidation(validate func(model.Result) error) Option {
+	return func(c *Config) {
+		c.ValidateResult = validate
+	}
+}
+
+// WithSharedCache makes all workers of the pool share the cache, so once any worker has computed
+// the factorial of a value, every other worker reuses it. A nil cache selects an unbounded MapCache;
+// an LRUCache bounds the number of cached factorials.
+// Cached factorials are shared between results and must be treated as read-only.
+func WithSharedCache(cache Cache) Option {
+	return func(c *Config) {
+		if cache == nil {
+			cache = NewMapCache()
+		}
+		c.Cache = cache
+	}
+}
+
+// WithFactorization makes the workers attach the prime factorization of every successfully
+// computed factorial to its result, as a map from prime to exponent. It is computed with
+// Legendre's formula rather than by factoring the result, so it adds little work per task.
+func WithFactorization() Option {
+	return func(c *Config) {
+		c.Factorization = true
+	}
+}
+
+// WithTaskHook installs a hook that is called before every computation of a task, including retries
+// of slow tasks, e.g. for tracing. Tests can use it to simulate the cost of a task by advancing a fake
+// clock. The hook runs on the worker goroutines, so it must be safe for concurrent use.
+func WithTaskHook(hook func(model.Task)) Option {
+	return func(c *Config) {
+		c.TaskHook = hook
+	}
+}
+
+// WithTaskObserver installs an observer that is notified when a worker starts processing a task and
+// again with its result once processing has finished, including any retry. Unlike WithTaskHook it sees
+// every task exactly once, which makes it suitable for tracing, see the tracing package. The observer
+// runs on the worker goroutines, so it must be safe for concurrent use. The option adds to any observer
+// installed before, see MultiObserver, so several of them can be combined, e.g. for tracing and metrics.
+func WithTaskObserver(observer TaskObserver) Option {
+	return func(c *Config) {
+		if c.TaskObserver != nil {
+			observer = MultiObserver(c.TaskObserver, observer)
+		}
+		c.TaskObserver = observer
+	}
+}
+
+// WithWorkerRateLimit caps every worker at perSecond tasks per second. Each worker has its own limit,
+// so no single worker can monopolize a downstream resource keyed by worker, while the pool as a whole
+// may process up to perSecond times the number of workers. A worker waiting for its limit is idle.
+// A perSecond of 0 disables the limit, which is the default.
+func WithWorkerRateLimit(perSecond float64) Option {
+	return func(c *Config) {
+		c.WorkerRateLimit = perSecond
+	}
+}
+
+// WithCPULimit caps every worker at the given fraction of a core's time, e.g. 0.5 for half a core, by
+// having it pause after every task in proportion to how long the task took: a task that took 10ms
+// is followed by a 10ms pause at 0.5. A pool of n workers thus uses at most about n times the fraction.
+// This is best-effort: the pauses only start once a task has finished, so a single long task still
+// runs at full speed, and a paused worker is idle rather than descheduled by the operating system.
+// A fraction of 0 or 1 disables the limit, which is the default.
+func WithCPULimit(fraction float64) Option {
+	return func(c *Config) {
+		c.CPULimit = fraction
+	}
+}
+
+// WithOnIdle installs a callback that is called whenever the pool becomes idle: every task received
+// so far has been processed and no task is waiting in the incoming channel, even though more tasks may
+// still arrive. It is called at most once per idle period, after the pool has stayed idle for a short
+// debounce interval, so rapid idle/busy transitions do not cause spurious calls. This lets multi-stage
+// pipelines start the next stage once the current one has drained. The callback runs on a goroutine of
+// the pool and must not block for long.
+func WithOnIdle(onIdle func()) Option {
+	return func(c *Config) {
+		c.OnIdle = onIdle
+	}
+}
+
+// WithPanicHandler installs a handler that is called when processing a task panics, e.g. to log the
+// panic, emit a metric or raise an alert. The worker recovers from the panic either way and survives it;
+// the task's result is marked failed with an error wrapping ErrTaskPanicked. The handler runs in the
+// recovering deferred function on the worker goroutine, so it must be safe for concurrent use and
+// must not panic itself. By default, the panic is logged with the worker's stack.
+func WithPanicHandler(handler PanicHandler) Option {
+	return func(c *Config) {
+		c.PanicHandler = handler
+	}
+}
+
+// WithShardedResults gives every worker its own results channel, available through WorkerResults,
+// instead of having all workers send to one shared channel. This avoids contention on the shared
+// channel when many workers produce results at a high rate.
+func WithShardedResults() Option {
+	return func(c *Config) {
+		c.ShardedResults = true
+	}
+}
+
+// NewPool initializes and returns a new Pool. The options are applied on top of cfg,
+// and an error is returned if the resulting configuration is invalid.
+func NewPool(cfg Config, opts ...Option) (*Pool, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults()
+
+	p := &Pool{
+		cfg:       cfg,
+		dispatch:  make(chan model.Task),
+		submitted: make(chan model.Task),
+		futures:   make(map[int]*Future),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+		inflight:  newInflightTracker(),
+		active:    cfg.AutoscaleMin,
+		rescaled:  make(chan struct{}),
+	}
+	if !cfg.ShardedResults {
+		p.results = make(chan model.Result, cfg.QueueSize)
+	}
+
+	for workerID := 0; workerID < cfg.Workers; workerID++ {
+		p.workers = append(p.workers, p.newWorker(workerID))
+	}
+	return p, nil
+}
+
+// newWorker creates the worker with the given ID, configured from the pool's Config. In sharded mode,
+// it also creates the worker's result channel.
+func (p *Pool) newWorker(workerID int) *Worker {
+	results := p.results
+	if p.cfg.ShardedResults {
+		results = make(chan model.Result, p.cfg.QueueSize)
+		p.workerResults = append(p.workerResults, results)
+	}
+
+	w := New(workerID, p.dispatch, results, &p.wg, p.quit, p.cfg.Clock)
+	w.maxBits = p.cfg.MaxBits
+	w.yieldEvery = p.cfg.YieldEvery
+	w.thresholdFactor = p.cfg.ThresholdFactor
+	w.timeoutPolicy = p.cfg.TimeoutPolicy
+	w.taskDeadline = p.cfg.TaskDeadline
+	w.smoothing = p.cfg.Smoothing
+	w.retrySlowTasks = p.cfg.RetrySlowTasks
+	w.retryAllowance = p.cfg.RetryAllowance
+	w.retries = p.cfg.Retries
+	w.retryBackoff = p.cfg.RetryBackoff
+	w.cache = p.cfg.Cache
+	w.inflight = p.inflight
+	w.validate = p.cfg.ValidateResult
+	w.factorize = p.cfg.Factorization
+	w.taskHook = p.cfg.TaskHook
+	w.observer = p.cfg.TaskObserver
+	w.maxResultBits = &p.maxResultBits
+	w.intercept = p.resolveFuture
+	if p.cfg.OnIdle != nil {
+		w.finished = p.taskFinished
+	}
+	w.cpuLimit = p.cfg.CPULimit
+	if p.cfg.WorkerRateLimit > 0 {
+		w.limiter = newRateLimiter(p.cfg.WorkerRateLimit)
+	}
+	if p.cfg.PanicHandler != nil {
+		w.panicHandler = p.cfg.PanicHandler
+	}
+	if p.cfg.AutoscaleMax > 0 {
+		w.active = p.workerActive
+	}
+	return w
+}
+
+// Start launches the workers and the dispatch loop that feeds them from the tasks channel.
+// Once the tasks channel is closed and every queued task has been processed,
+// the results channel is closed.
+func (p *Pool) Start(tasks <-chan model.Task) {
+	p.StartContext(context.Background(), tasks)
+}
+
+// StartContext is like Start, but stops as soon as ctx is cancelled. Tasks that are still queued
+// at that point are dropped, and workers stop after their current task. A worker whose result
+// cannot be delivered because nobody reads the results channel any more drops the result rather
+// than blocking, so the results channel is closed even if the consumer has gone away.
+func (p *Pool) StartContext(ctx context.Context, tasks <-chan model.Task) {
+	p.start(ctx, tasks, nil)
+}
+
+// start launches the workers, the dispatch loop and the goroutine that closes the results channel.
+// If deps is not nil, the dispatch loop holds back every task until its dependencies have completed.
+func (p *Pool) start(ctx context.Context, tasks <-chan model.Task, deps *dependencyTracker) {
+	p.alloc.start()
+	p.incoming = tasks
+
+	for _, w := range p.workers {
+		// Increment the WaitGroup counter for each worker.
+		p.wg.Add(1)
+		go w.Start()
+	}
+
+	go p.dispatchLoop(ctx, tasks, deps)
+
+	if p.cfg.OnIdle != nil {
+		p.idleCheck = make(chan struct{}, 1)
+		go p.watchIdle()
+	}
+
+	if p.cfg.AutoscaleMax > 0 {
+		go p.autoscale(autoscaleInterval)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.stop() // Make the workers abandon their current work.
+		case <-p.quit:
+			// The run finished before the context was cancelled.
+		}
+	}()
+
+	collecting := p.cfg.ResultCapacity > 0
+	if collecting {
+		p.collector = NewBoundedCollector(p.cfg.ResultCapacity, p.cfg.OnOverflow)
+		go func() {
+			p.collector.Collect(p.Unordered())
+			close(p.done)
+		}()
+	}
+
+	go func() {
+		p.wg.Wait()        // Wait for all workers to finish.
+		p.alloc.finish()   // Take the memory sample before the consumer is notified.
+		p.closeResults()   // Signal the consumer that no more results will be sent.
+		p.stop()           // Signal any remaining goroutines to terminate.
+		p.abandonFutures() // Resolve the futures of the tasks that were dropped.
+		if !collecting {
+			close(p.done)
+		}
+	}()
+}
+
+// Done returns a channel that is closed once every task has been processed and its result
+// has been sent, and, with WithResultCapacity, once every result has been collected.
+func (p *Pool) Done() <-chan struct{} {
+	return p.done
+}
+
+// Retained returns the results retained by a pool created with WithResultCapacity, in the order
+// they completed. It may be called while the pool is running; wait for Done to get the final set.
+// Returns nil if the pool does not collect its results.
+func (p *Pool) Retained() []model.Result {
+	if p.collector == nil {
+		return nil
+	}
+	return p.collector.Results()
+}
+
+// Unordered returns the channel on which results are delivered as soon as their task completes.
+// Results are neither buffered nor reordered, so their order is nondeterministic and generally
+// differs from the order of the tasks. This is the lowest-latency way to consume the pool.
+// The channel is closed once every task has been processed.
+// In sharded mode it merges the channels of all workers; it must not be combined with WorkerResults.
+func (p *Pool) Unordered() <-chan model.Result {
+	if p.cfg.ShardedResults {
+		p.mergedOnce.Do(func() {
+			channels := make([]<-chan model.Result, len(p.workerResults))
+			for i, c := range p.workerResults {
+				channels[i] = c
+			}
+			p.merged = Merge(channels...)
+		})
+		return p.merged
+	}
+	return p.results
+}
+
+// WorkerResults returns the results channel of the worker with the given ID in sharded mode,
+// see WithShardedResults. Each channel is closed once every task has been processed.
+// Returns nil if the pool is not sharded or there is no worker with that ID.
+func (p *Pool) WorkerResults(id int) <-chan model.Result {
+	if id < 0 || id >= len(p.workerResults) {
+		return nil
+	}
+	return p.workerResults[id]
+}
+
+// Ordered waits for all tasks to be processed and returns their results sorted by task ID.
+// It buffers every result in memory and is meant for consumers that need the original order.
+// The length must be the number of tasks submitted to the pool.
+func (p *Pool) Ordered(length int) []model.Result {
+	return SortResults(p.Unordered(), length)
+}
+
+// Stats returns a snapshot of the statistics of every worker in the pool.
+// It is safe to call while the pool is running.
+func (p *Pool) Stats() PoolStats {
+	stats := PoolStats{Workers: make([]WorkerStats, len(p.workers))}
+	for i, w := range p.workers {
+		stats.Workers[i] = w.Stats()
+	}
+	stats.RedundantComputations = p.inflight.redundant.Load()
+	if reporter, ok := p.cfg.Cache.(cacheStatsReporter); ok {
+		cacheStats := reporter.CacheStats()
+		stats.Cache = &cacheStats
+	}
+	return stats
+}
+
+// MaxResultBits returns the bit length of the largest factorial the pool has computed so far,
+// a watermark that helps to catch runaway inputs; see WithBitBudget to abort them. Failed results
+// do not count. It is safe to call while the pool is running and returns 0 before any result.
+func (p *Pool) MaxResultBits() int {
+	return int(p.maxResultBits.Load())
+}
+
+// stop closes the quit channel, which makes the workers exit. It may be called more than once.
+func (p *Pool) stop() {
+	p.quitOnce.Do(func() {
+		close(p.quit)
+	})
+}
+
+// QueueDepth returns the number of tasks waiting to be dispatched to a worker: those buffered in
+// the incoming tasks channel plus those the pool has received but not dispatched yet.
+// It must not be called before Start.
+func (p *Pool) QueueDepth() int {
+	return len(p.incoming) + int(p.queued.Load())
+}
+
+// CurrentTasks returns the task each busy worker is processing right now, keyed by worker ID.
+// Idle workers are absent from the map. It never blocks the workers.
+func (p *Pool) CurrentTasks() map[int]model.Task {
+	current := make(map[int]model.Task)
+	for _, w := range p.workers {
+		if task, ok := w.CurrentTask(); ok {
+			current[w.ID] = task
+		}
+	}
+	return current
+}
+
+// closeResults closes every channel the workers send results to.
+func (p *Pool) closeResults() {
+	defer p.resultsClosed.Store(true)
+	if p.cfg.ShardedResults {
+		for _, c := range p.workerResults {
+			close(c)
+		}
+		return
+	}
+	close(p.results)
+}
+
+// dispatchLoop moves tasks from the incoming channel into the scheduler and from the scheduler
+// to the workers. It closes the dispatch channel once the incoming channel is closed and the
+// scheduler has been drained, or ctx is cancelled, which makes the workers exit.
+// If deps is not nil, tasks only enter the scheduler once all their dependencies have completed.
+func (p *Pool) dispatchLoop(ctx context.Context, tasks <-chan model.Task, deps *dependencyTracker) {
+	defer func() {
+		close(p.dispatch)
+		p.dispatchClosed.Store(true)
+		if p.cfg.AutoscaleMax > 0 {
+			// Wake the retired workers, so they see the closed dispatch channel and exit.
+			p.finishScaling()
+		}
+	}()
+
+	// completed stays nil unless dependencies are tracked, which disables its case.
+	var completed <-chan int
+	if deps != nil {
+		completed = deps.completed
+	}
+
+	// accept queues a received task, unless it is held back until its dependencies have completed.
+	accept := func(task model.Task) {
+		p.queued.Add(1)
+		p.received.Add(1)
+		if deps.ready(task) {
+			p.cfg.Scheduler.Push(task)
+		}
+	}
+
+	var next model.Task
+	hasNext := false
+	for {
+		if !hasNext {
+			next, hasNext = p.cfg.Scheduler.Pop()
+		}
+
+		// A nil channel blocks forever, which disables the send case while there is nothing to dispatch.
+		var out chan<- model.Task
+		if hasNext {
+			out = p.dispatch
+		} else if tasks == nil && !deps.blocked() {
+			// No more incoming tasks and nothing left in the scheduler or waiting for dependencies.
+			return
+		}
+
+		// Submitted tasks are accepted for as long as the incoming channel is open.
+		var submitted <-chan model.Task
+		if tasks != nil {
+			submitted = p.submitted
+		}
+
+		select {
+		case task, ok := <-tasks:
+			if !ok {
+				// Stop receiving, but keep dispatching what is left in the scheduler.
+				tasks = nil
+				p.incomingClosed.Store(true)
+				continue
+			}
+			accept(task)
+		case task := <-submitted:
+			accept(task)
+		case out <- next:
+			hasNext = false
+			p.queued.Add(-1)
+			deps.dispatched()
+		case id := <-completed:
+			for _, task := range deps.complete(id) {
+				p.cfg.Scheduler.Push(task)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
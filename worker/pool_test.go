@@ -0,0 +1,676 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lifoScheduler is a custom Scheduler used to verify that the pool dispatches through the configured scheduler.
+type lifoScheduler struct {
+	mu     sync.Mutex
+	stack  []model.Task
+	pushes int
+	pops   int
+}
+
+func (s *lifoScheduler) Push(task model.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushes++
+	s.stack = append(s.stack, task)
+}
+
+func (s *lifoScheduler) Pop() (model.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.stack) == 0 {
+		return model.Task{}, false
+	}
+	s.pops++
+	task := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	return task, true
+}
+
+// withoutTimeouts seeds the processing time history of the workers created during the test with a
+// long duration, so the adaptive timeout cannot fail any of the first 20 tasks of each worker.
+func withoutTimeouts(t testing.TB) {
+	initialProcessingTimes = []time.Duration{time.Hour}
+	t.Cleanup(func() { initialProcessingTimes = nil })
+}
+
+func TestPool_CustomScheduler(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10, 12}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	scheduler := &lifoScheduler{}
+	pool, err := NewPool(Config{Workers: 2}, WithScheduler(scheduler))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+
+	for i, v := range values {
+		expected := utils.CalcFactorial(v)
+		if sortedResults[i].Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", v, expected, sortedResults[i].Factorial)
+		}
+	}
+
+	if scheduler.pushes != len(values) || scheduler.pops != len(values) {
+		t.Errorf("scheduler saw %d pushes and %d pops, want %d of each", scheduler.pushes, scheduler.pops, len(values))
+	}
+}
+
+func TestPool_Unordered(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10, 12}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 3})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	seen := make(map[int]bool)
+	for result := range pool.Unordered() {
+		if seen[result.Task.ID] {
+			t.Errorf("Task %d delivered more than once", result.Task.ID)
+		}
+		seen[result.Task.ID] = true
+
+		expected := utils.CalcFactorial(result.Task.Value)
+		if result.Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", result.Task.Value, expected, result.Factorial)
+		}
+	}
+
+	if len(seen) != len(values) {
+		t.Errorf("Received %d results, want %d", len(seen), len(values))
+	}
+}
+
+func TestPool_BitBudget(t *testing.T) {
+	withoutTimeouts(t)
+
+	// 20! fits in 64 bits, 100000! by far does not.
+	values := []int64{20, 100000}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2}, WithBitBudget(64))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+
+	if expected := utils.CalcFactorial(20); sortedResults[0].Factorial.Cmp(expected) != 0 {
+		t.Errorf("Task 20 expected result %v, got %v", expected, sortedResults[0].Factorial)
+	}
+	if r := sortedResults[1]; r.Factorial != nil || !errors.Is(r.Err, utils.ErrBitBudgetExceeded) {
+		t.Errorf("Task 100000 = %v with error %v, want no factorial and ErrBitBudgetExceeded", r.Factorial, r.Err)
+	}
+}
+
+func TestPool_WorkerResults(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	const numWorkers = 3
+	values := []int64{3, 5, 7, 10, 12, 15}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: numWorkers}, WithShardedResults())
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	if pool.WorkerResults(numWorkers) != nil {
+		t.Errorf("WorkerResults(%d) returned a channel for a worker that does not exist", numWorkers)
+	}
+
+	// Consume every worker's stream on its own goroutine.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	received := 0
+	for id := 0; id < numWorkers; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for result := range pool.WorkerResults(id) {
+				if result.WorkerID != id {
+					t.Errorf("Worker %d's channel delivered a result of worker %d", id, result.WorkerID)
+				}
+				mu.Lock()
+				received++
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if received != len(values) {
+		t.Errorf("Received %d results, want %d", received, len(values))
+	}
+}
+
+func TestPool_ShardedOrdered(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10, 12, 15}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 3}, WithShardedResults())
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+	for i, v := range values {
+		expected := utils.CalcFactorial(v)
+		if sortedResults[i].Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", v, expected, sortedResults[i].Factorial)
+		}
+	}
+}
+
+// benchmarkResultChannels pushes many tiny tasks through a pool with lots of workers,
+// so the cost of delivering results dominates the cost of computing them.
+func benchmarkResultChannels(b *testing.B, sharded bool) {
+	const numWorkers = 64
+	const numTasks = 10000
+
+	for i := 0; i < b.N; i++ {
+		tasks := make(chan model.Task, numTasks)
+		for id := 0; id < numTasks; id++ {
+			tasks <- model.Task{ID: id, Value: 3}
+		}
+		close(tasks)
+
+		pool, err := NewPool(Config{Workers: numWorkers, QueueSize: 16, ShardedResults: sharded})
+		if err != nil {
+			b.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.Start(tasks)
+
+		if !sharded {
+			for range pool.Unordered() {
+			}
+			continue
+		}
+
+		// Drain every worker's channel with its own consumer.
+		var wg sync.WaitGroup
+		for id := 0; id < numWorkers; id++ {
+			wg.Add(1)
+			go func(c <-chan model.Result) {
+				defer wg.Done()
+				for range c {
+				}
+			}(pool.WorkerResults(id))
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkPool_SharedResults(b *testing.B) {
+	benchmarkResultChannels(b, false)
+}
+
+func BenchmarkPool_ShardedResults(b *testing.B) {
+	benchmarkResultChannels(b, true)
+}
+
+// BenchmarkPool_QueueSize measures how the capacity of the results channel affects throughput and
+// latency, for several worker counts. Latency is the time from the start of a task's computation until
+// its result reaches the consumer, so it includes the time the result waits in the buffer.
+func BenchmarkPool_QueueSize(b *testing.B) {
+	const numTasks = 1000
+
+	for _, numWorkers := range []int{1, 4, 16} {
+		for _, queueSize := range []int{0, 1, 16, 256, numTasks} {
+			b.Run(fmt.Sprintf("workers=%d/queue=%d", numWorkers, queueSize), func(b *testing.B) {
+				var latencies []time.Duration
+				var elapsed time.Duration
+
+				for i := 0; i < b.N; i++ {
+					tasks := make(chan model.Task, numTasks)
+					for id := 0; id < numTasks; id++ {
+						tasks <- model.Task{ID: id, Value: 100}
+					}
+					close(tasks)
+
+					// Every task writes only its own slot; the result's delivery orders the write before the read.
+					started := make([]time.Time, numTasks)
+					pool, err := NewPool(Config{Workers: numWorkers, QueueSize: queueSize, ThresholdFactor: 1e9},
+						WithTaskHook(func(task model.Task) {
+							started[task.ID] = time.Now()
+						}))
+					if err != nil {
+						b.Fatalf("NewPool() returned an error: %v", err)
+					}
+
+					begin := time.Now()
+					pool.Start(tasks)
+					for result := range pool.Unordered() {
+						latencies = append(latencies, time.Since(started[result.Task.ID]))
+					}
+					elapsed += time.Since(begin)
+				}
+
+				sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+				p99 := latencies[len(latencies)*99/100]
+				b.ReportMetric(float64(numTasks*b.N)/elapsed.Seconds(), "tasks/s")
+				b.ReportMetric(float64(p99.Microseconds()), "p99-µs")
+			})
+		}
+	}
+}
+
+func TestPool_StartContext_ConsumerGone(t *testing.T) {
+	checkGoroutines(t)
+	const numTasks = 100
+
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 3}
+	}
+	close(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An unbuffered results channel that nobody reads blocks every worker on its first send.
+	pool, err := NewPool(Config{Workers: 4, QueueSize: 0})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.StartContext(ctx, tasks)
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-pool.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Pool did not shut down after cancellation while its consumer was gone")
+	}
+}
+
+func TestPool_CurrentTasks(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Hold every task in processing until the test releases it.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	simulateDelay = func() {
+		started <- struct{}{}
+		<-release
+	}
+	defer func() { simulateDelay = nil }()
+
+	tasks := make(chan model.Task, 1)
+	tasks <- model.Task{ID: 7, Value: 5}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if current := pool.CurrentTasks(); len(current) != 0 {
+		t.Errorf("CurrentTasks() = %v before Start, want no tasks", current)
+	}
+	pool.Start(tasks)
+
+	<-started
+	current := pool.CurrentTasks()
+	if len(current) != 1 {
+		t.Fatalf("CurrentTasks() = %v, want exactly one task", current)
+	}
+	for _, task := range current {
+		if task.ID != 7 {
+			t.Errorf("CurrentTasks() reports task %d, want 7", task.ID)
+		}
+	}
+
+	close(release)
+	for range pool.Unordered() {
+	}
+
+	if current := pool.CurrentTasks(); len(current) != 0 {
+		t.Errorf("CurrentTasks() = %v after the run, want no tasks", current)
+	}
+}
+
+func TestPool_ResultValidation(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	// Reject every result with more than 4 digits, i.e. 10! = 3628800.
+	errTooLong := errors.New("too many digits")
+	pool, err := NewPool(Config{Workers: 2}, WithResultValidation(func(r model.Result) error {
+		if len(r.Factorial.String()) > 4 {
+			return errTooLong
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+	for i, v := range values[:3] {
+		if sortedResults[i].Err != nil {
+			t.Errorf("Task %d failed validation: %v", v, sortedResults[i].Err)
+		}
+	}
+	if last := sortedResults[3]; !errors.Is(last.Err, errTooLong) || last.Factorial != nil {
+		t.Errorf("Task 10 = %v with error %v, want no factorial and the validation error", last.Factorial, last.Err)
+	}
+}
+
+func TestPool_Factorization(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{5, 10}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2}, WithFactorization())
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	// 10! = 3628800 = 2^8 * 3^4 * 5^2 * 7
+	sortedResults := pool.Ordered(len(values))
+	if factorization := sortedResults[1].Factorization; factorization[2] != 8 || factorization[3] != 4 ||
+		factorization[5] != 2 || factorization[7] != 1 || len(factorization) != 4 {
+		t.Errorf("Task 10 has factorization %v, want 2^8 * 3^4 * 5^2 * 7", factorization)
+	}
+	if len(sortedResults[0].Factorization) != 3 {
+		t.Errorf("Task 5 has factorization %v, want 3 prime factors", sortedResults[0].Factorization)
+	}
+}
+
+// TestPool_Shutdown exercises the shutdown paths of the pool; run it with -race to check that
+// closing quit does not race with the workers that are still processing or starting up.
+func TestPool_Shutdown(t *testing.T) {
+	withoutTimeouts(t)
+
+	const numTasks = 50
+
+	newTasks := func() chan model.Task {
+		tasks := make(chan model.Task, numTasks)
+		for i := 0; i < numTasks; i++ {
+			tasks <- model.Task{ID: i, Value: 20}
+		}
+		close(tasks)
+		return tasks
+	}
+
+	t.Run("tasks exhausted", func(t *testing.T) {
+		checkGoroutines(t)
+		pool, err := NewPool(Config{Workers: 8})
+		if err != nil {
+			t.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.Start(newTasks())
+
+		received := 0
+		for range pool.Unordered() {
+			received++
+		}
+		<-pool.Done()
+		if received != numTasks {
+			t.Errorf("Received %d results, want %d", received, numTasks)
+		}
+	})
+
+	t.Run("cancelled before start", func(t *testing.T) {
+		checkGoroutines(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		pool, err := NewPool(Config{Workers: 8})
+		if err != nil {
+			t.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.StartContext(ctx, newTasks())
+
+		for range pool.Unordered() {
+		}
+		select {
+		case <-pool.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("Pool did not shut down when started with a cancelled context")
+		}
+	})
+
+	t.Run("cancelled while running", func(t *testing.T) {
+		checkGoroutines(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pool, err := NewPool(Config{Workers: 8})
+		if err != nil {
+			t.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.StartContext(ctx, newTasks())
+
+		<-pool.Unordered()
+		cancel()
+		for range pool.Unordered() {
+		}
+		select {
+		case <-pool.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("Pool did not shut down after cancellation")
+		}
+	})
+}
+
+func TestPool_SeededBatchRoundTrip(t *testing.T) {
+	withoutTimeouts(t)
+
+	batch := model.Batch{Seed: 7, NumTasks: 20, Min: 3, Max: 50}
+	tasks := make(chan model.Task, batch.NumTasks)
+	generator.GenerateSeededTasks(batch, tasks)
+
+	pool, err := NewPool(Config{Workers: 3})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+	results := pool.Ordered(batch.NumTasks)
+
+	// Regenerate the batch from the metadata recorded in a result alone.
+	recorded := results[0].Task.Batch
+	if recorded == nil {
+		t.Fatalf("Result carries no batch metadata")
+	}
+	regenerated := make(chan model.Task, recorded.NumTasks)
+	generator.GenerateSeededTasks(*recorded, regenerated)
+
+	for task := range regenerated {
+		result := results[task.ID]
+		if result.Task.Value != task.Value {
+			t.Errorf("Task %d was %d, regenerated as %d", task.ID, result.Task.Value, task.Value)
+		}
+		if result.Factorial.Cmp(utils.CalcFactorial(task.Value)) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", task.Value, utils.CalcFactorial(task.Value), result.Factorial)
+		}
+	}
+}
+
+func TestPool_PanicHandler(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	values := []int64{3, 13, 5}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	type panicReport struct {
+		workerID  int
+		task      model.Task
+		recovered any
+	}
+	var reports []panicReport
+
+	// A single worker has to survive the panic to process the remaining task.
+	pool, err := NewPool(Config{Workers: 1},
+		WithTaskHook(func(task model.Task) {
+			if task.Value == 13 {
+				panic("unlucky number")
+			}
+		}),
+		WithPanicHandler(func(workerID int, task model.Task, recovered any) {
+			reports = append(reports, panicReport{workerID, task, recovered})
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+
+	if len(reports) != 1 {
+		t.Fatalf("Panic handler was called %d times, want once", len(reports))
+	}
+	if report := reports[0]; report.workerID != 0 || report.task.ID != 1 || report.task.Value != 13 || report.recovered != "unlucky number" {
+		t.Errorf("Panic handler got worker %d, task %d (%d!) and %v, want worker 0, task 1 (13!) and \"unlucky number\"",
+			report.workerID, report.task.ID, report.task.Value, report.recovered)
+	}
+
+	if failed := sortedResults[1]; !errors.Is(failed.Err, ErrTaskPanicked) || failed.Factorial != nil {
+		t.Errorf("Task 13 = %v with error %v, want no factorial and an error wrapping ErrTaskPanicked", failed.Factorial, failed.Err)
+	}
+	for _, i := range []int{0, 2} {
+		if expected := utils.CalcFactorial(values[i]); sortedResults[i].Factorial.Cmp(expected) != 0 || sortedResults[i].Err != nil {
+			t.Errorf("Task %d expected result %v, got %v with error %v", values[i], expected, sortedResults[i].Factorial, sortedResults[i].Err)
+		}
+	}
+}
+
+func TestPool_Yield(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{10, 2000}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2}, WithYield(100))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	sortedResults := pool.Ordered(len(values))
+	for i, v := range values {
+		if expected := utils.CalcFactorial(v); sortedResults[i].Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v", v, expected, sortedResults[i].Factorial)
+		}
+	}
+}
+
+func TestPool_TaskObservers(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Both observers see every task, including one that is not interested in the results.
+	var mu sync.Mutex
+	started, finished := 0, 0
+	pool, err := NewPool(Config{Workers: 2},
+		WithTaskObserver(func(int, model.Task) func(model.Result, time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			started++
+			return nil
+		}),
+		WithTaskObserver(func(int, model.Task) func(model.Result, time.Duration) {
+			return func(model.Result, time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				finished++
+			}
+		}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	tasks := make(chan model.Task, 5)
+	for i := 0; i < cap(tasks); i++ {
+		tasks <- model.Task{ID: i, Value: 5}
+	}
+	close(tasks)
+	pool.Start(tasks)
+	for range pool.Unordered() {
+	}
+
+	if started != cap(tasks) || finished != cap(tasks) {
+		t.Errorf("Observers saw %d started and %d finished tasks, want %d of each", started, finished, cap(tasks))
+	}
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/utils"
+)
+
+// ErrNoCache is returned by Precompute for a pool without a cache, see WithSharedCache.
+var ErrNoCache = errors.New("pool has no cache")
+
+// Precompute warms the pool's cache with the factorials of 0 through maxValue, computed in a single
+// ascending pass with utils.FactorialSequence, so it costs about as much as maxValue! alone. Every task
+// with a value up to maxValue is then a cache lookup, which front-loads the cost of a batch within a
+// known range. The cache holds every factorial up to maxValue, which takes memory quadratic in
+// maxValue, about 70 MB for a maxValue of 10000, and a bounded cache may evict some of them again.
+// It may be called before or while the pool is running. It returns ErrNoCache if the pool has no
+// cache, and an error if maxValue is negative.
+func (p *Pool) Precompute(maxValue int64) error {
+	if p.cfg.Cache == nil {
+		return ErrNoCache
+	}
+	if maxValue < 0 {
+		return errors.New("precompute value must not be negative")
+	}
+
+	n := int64(0)
+	for factorial := range utils.FactorialSequence(maxValue) {
+		p.cfg.Cache.Put(n, factorial)
+		n++
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"container/heap"
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+	"time"
+)
+
+// PriorityScheduler dispatches the task with the highest priority first, and tasks of equal priority
+// in the order they were pushed. On its own this starves low-priority tasks for as long as
+// higher-priority ones keep arriving, so the scheduler can age the tasks: every queued task gains
+// agingRate priority per second it has waited, and eventually runs ahead of any newer task.
+//
+// Aging raises the priority of every waiting task at the same rate, so it never changes the order of
+// two waiting tasks relative to each other, only relative to tasks pushed later. The scheduler exploits
+// this by ordering the tasks by their priority minus the aging their push time forgoes, which makes
+// both Push and Pop logarithmic in the number of queued tasks.
+type PriorityScheduler struct {
+	mu sync.Mutex
+	// priority returns the base priority of a task.
+	priority func(model.Task) float64
+	// agingRate is the priority a task gains per second it waits.
+	agingRate float64
+	// clock tells the push times, measured from start.
+	clock Clock
+	start time.Time
+	// queue holds the queued tasks as a heap.
+	queue priorityQueue
+	// pushed counts the pushed tasks, to keep tasks of equal priority in order.
+	pushed uint64
+}
+
+// NewPriorityScheduler returns an empty PriorityScheduler ordering the tasks by the given priority,
+// highest first, and aging them by agingRate priority per second they wait, as told by clock.
+// An agingRate of 0 disables aging. A nil clock selects the real clock.
+func NewPriorityScheduler(priority func(model.Task) float64, agingRate float64, clock Clock) *PriorityScheduler {
+	if clock == nil {
+		clock = RealClock()
+	}
+	return &PriorityScheduler{priority: priority, agingRate: agingRate, clock: clock, start: clock.Now()}
+}
+
+// Push queues the task with its current priority.
+func (s *PriorityScheduler) Push(task model.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A task pushed at time t has the priority p + agingRate*(now-t) when popped; ordering by
+	// p - agingRate*t gives the same order without depending on now.
+	waited := s.clock.Now().Sub(s.start).Seconds()
+	heap.Push(&s.queue, prioritizedTask{
+		task: task,
+		key:  s.priority(task) - s.agingRate*waited,
+		seq:  s.pushed,
+	})
+	s.pushed++
+}
+
+// Pop removes and returns the task with the highest aged priority.
+func (s *PriorityScheduler) Pop() (model.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return model.Task{}, false
+	}
+	return heap.Pop(&s.queue).(prioritizedTask).task, true
+}
+
+// TaskPriority returns the priority the task carries in its Priority field. It is the priority function
+// of the scheduler installed by WithTaskPriority.
+func TaskPriority(task model.Task) float64 {
+	return float64(task.Priority)
+}
+
+// WithTaskPriority makes the pool dispatch the queued tasks with the highest Priority first, and tasks
+// of equal priority in the order they arrived, i.e. by ID for tasks sent in ID order. It installs a
+// PriorityScheduler without aging, so a steady stream of urgent tasks starves the others; use
+// WithScheduler with NewPriorityScheduler(TaskPriority, agingRate, clock) to age them.
+// Without this option, the priorities of the tasks are ignored.
+func WithTaskPriority() Option {
+	return func(c *Config) {
+		c.Scheduler = NewPriorityScheduler(TaskPriority, 0, c.Clock)
+	}
+}
+
+// prioritizedTask is a queued task of a PriorityScheduler.
+type prioritizedTask struct {
+	task model.Task
+	// key orders the tasks, highest first, and seq breaks ties, lowest first.
+	key float64
+	seq uint64
+}
+
+// priorityQueue implements heap.Interface for the PriorityScheduler.
+type priorityQueue []prioritizedTask
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].key != q[j].key {
+		return q[i].key > q[j].key
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x any) { *q = append(*q, x.(prioritizedTask)) }
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
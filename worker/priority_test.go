@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// byValue prioritizes tasks by their value.
+func byValue(task model.Task) float64 {
+	return float64(task.Value)
+}
+
+func TestPriorityScheduler_Order(t *testing.T) {
+	scheduler := NewPriorityScheduler(byValue, 0, nil)
+
+	if _, ok := scheduler.Pop(); ok {
+		t.Fatalf("Pop() on an empty scheduler returned a task")
+	}
+
+	for i, v := range []int64{3, 9, 5, 9, 1} {
+		scheduler.Push(model.Task{ID: i, Value: v})
+	}
+
+	var popped []int
+	for {
+		task, ok := scheduler.Pop()
+		if !ok {
+			break
+		}
+		popped = append(popped, task.ID)
+	}
+
+	// The highest value first, equal values in the order they were pushed.
+	expected := []int{1, 3, 2, 0, 4}
+	if !reflect.DeepEqual(popped, expected) {
+		t.Errorf("Popped tasks %v, want %v", popped, expected)
+	}
+}
+
+func TestPriorityScheduler_Aging(t *testing.T) {
+	// starvedFor pushes a low-priority task and then, every second, a high-priority task that is popped
+	// right away, and returns how many high-priority tasks ran before the low-priority one.
+	starvedFor := func(agingRate float64) int {
+		clock := newFakeClock()
+		scheduler := NewPriorityScheduler(byValue, agingRate, clock)
+		scheduler.Push(model.Task{ID: -1, Value: 0})
+
+		for i := 0; i < 100; i++ {
+			clock.Advance(time.Second)
+			scheduler.Push(model.Task{ID: i, Value: 10})
+			if task, _ := scheduler.Pop(); task.ID == -1 {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if ran := starvedFor(0); ran != -1 {
+		t.Errorf("Without aging, the low-priority task ran after %d high-priority tasks, want it starved", ran)
+	}
+	// After 10 seconds the low-priority task has gained the 10 it lacks, and it is the older one.
+	if ran := starvedFor(1); ran != 9 {
+		t.Errorf("With aging, the low-priority task ran after %d high-priority tasks, want 9", ran)
+	}
+}
+
+func TestPool_TaskPriority(t *testing.T) {
+	withoutTimeouts(t)
+
+	pool, err := NewPool(Config{Workers: 1}, WithTaskPriority())
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	tasks := []model.Task{
+		{ID: 0, Value: 3},
+		{ID: 1, Value: 4, Priority: 5},
+		{ID: 2, Value: 5},
+		{ID: 3, Value: 6, Priority: 5},
+		{ID: 4, Value: 7, Priority: 1},
+		{ID: 5, Value: 8, Priority: -1},
+	}
+	var processed []int
+	for _, r := range pool.RunInline(tasks) {
+		processed = append(processed, r.Task.ID)
+	}
+
+	// The highest priority first, equal priorities in ID order.
+	expected := []int{1, 3, 4, 0, 2, 5}
+	if !reflect.DeepEqual(processed, expected) {
+		t.Errorf("Processed tasks %v, want %v", processed, expected)
+	}
+}
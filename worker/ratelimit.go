@@ -0,0 +1,35 @@
+package worker
+
+import "time"
+
+// rateLimiter spaces out the tasks of a single worker, so it starts at most a fixed number of
+// tasks per second. It is only used by the goroutine of its worker and needs no synchronization.
+type rateLimiter struct {
+	// interval is the minimum time between the starts of two tasks.
+	interval time.Duration
+	// next is the earliest time the next task may start.
+	next time.Time
+}
+
+// newRateLimiter returns a rateLimiter that allows perSecond tasks per second.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next task may start, given the current time now, and reserves the slot.
+// It reports false without reserving a slot if quit is closed while waiting. The waiting itself
+// happens in real time, even if now was taken from a fake clock.
+func (l *rateLimiter) wait(now time.Time, quit <-chan struct{}) bool {
+	if now.Before(l.next) {
+		timer := time.NewTimer(l.next.Sub(now))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-quit:
+			return false
+		}
+		now = l.next
+	}
+	l.next = now.Add(l.interval)
+	return true
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := newRateLimiter(10) // One task every 100ms.
+	start := time.Now()
+
+	if !l.wait(start, nil) {
+		t.Fatalf("wait() refused the first task")
+	}
+	// The second task is due 100ms after the first; pretend 60ms have passed.
+	before := time.Now()
+	if !l.wait(start.Add(60*time.Millisecond), nil) {
+		t.Fatalf("wait() refused the second task")
+	}
+	if waited := time.Since(before); waited < 30*time.Millisecond {
+		t.Errorf("wait() returned after %v, want about 40ms", waited)
+	}
+
+	// A closed quit channel cuts the wait short.
+	quit := make(chan struct{})
+	close(quit)
+	if l.wait(start.Add(100*time.Millisecond), quit) {
+		t.Errorf("wait() allowed a task after quit was closed")
+	}
+}
+
+func TestPool_WorkerRateLimit(t *testing.T) {
+	withoutTimeouts(t)
+
+	const numWorkers = 2
+	const perWorker = 4
+	const interval = 50 * time.Millisecond
+
+	tasks := make(chan model.Task, numWorkers*perWorker)
+	for i := 0; i < cap(tasks); i++ {
+		tasks <- model.Task{ID: i, Value: 3}
+	}
+	close(tasks)
+
+	// Record when every task started.
+	var mu sync.Mutex
+	started := make(map[int]time.Time)
+	pool, err := NewPool(Config{Workers: numWorkers}, WithWorkerRateLimit(float64(time.Second/interval)), WithTaskHook(func(task model.Task) {
+		mu.Lock()
+		defer mu.Unlock()
+		started[task.ID] = time.Now()
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	begin := time.Now()
+	pool.Start(tasks)
+
+	workerOf := make(map[int]int)
+	for result := range pool.Unordered() {
+		workerOf[result.Task.ID] = result.WorkerID
+	}
+	elapsed := time.Since(begin)
+
+	mu.Lock()
+	defer mu.Unlock()
+	byWorker := make(map[int][]time.Time)
+	for id, worker := range workerOf {
+		byWorker[worker] = append(byWorker[worker], started[id])
+	}
+
+	// Every worker on its own keeps to the interval.
+	for id, times := range byWorker {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		for i := 1; i < len(times); i++ {
+			if gap := times[i].Sub(times[i-1]); gap < interval-5*time.Millisecond {
+				t.Errorf("Worker %d started two tasks %v apart, want at least %v", id, gap, interval)
+			}
+		}
+	}
+
+	// The limits are independent, so the workers together run about twice as fast as one would.
+	if sequential := (numWorkers*perWorker - 1) * interval; elapsed >= sequential {
+		t.Errorf("The pool took %v, want less than the %v of a single shared limit", elapsed, sequential)
+	}
+}
+
+func TestPool_CPULimit(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	const numTasks = 5
+	const cost = 10 * time.Millisecond
+	const limit = 0.25
+
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 3}
+	}
+	close(tasks)
+
+	// Every task takes 10ms on the fake clock, and every pause passes on it instantly.
+	clock := newFakeClock()
+	var paused time.Duration
+	original := throttleSleep
+	defer func() { throttleSleep = original }()
+	throttleSleep = func(d time.Duration, quit <-chan struct{}) bool {
+		paused += d
+		clock.Advance(d)
+		return true
+	}
+
+	pool, err := NewPool(Config{Workers: 1, Clock: clock}, WithCPULimit(limit), WithTaskHook(func(model.Task) {
+		clock.Advance(cost)
+	}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+	for range pool.Unordered() {
+	}
+
+	// The worker computed for a quarter of the run.
+	computed := numTasks * cost
+	if share := float64(computed) / float64(computed+paused); share < limit-0.01 || share > limit+0.01 {
+		t.Errorf("The worker computed for %.2f of the run (paused %v), want %.2f", share, paused, limit)
+	}
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// inflightTracker counts the computations of every value in progress across the workers of a pool,
+// to detect redundant computations: those that start while another worker computes the same value.
+type inflightTracker struct {
+	mu     sync.Mutex
+	values map[int64]int
+	// redundant is the number of redundant computations so far.
+	redundant atomic.Uint64
+}
+
+// newInflightTracker returns a tracker without computations in progress.
+func newInflightTracker() *inflightTracker {
+	return &inflightTracker{values: make(map[int64]int)}
+}
+
+// begin records that the computation of value has started, and returns the function that records
+// that it has finished.
+func (t *inflightTracker) begin(value int64) (end func()) {
+	t.mu.Lock()
+	if t.values[value] > 0 {
+		t.redundant.Add(1)
+	}
+	t.values[value]++
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.values[value]--; t.values[value] == 0 {
+			delete(t.values, value)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"time"
+)
+
+// WithRetries processes a failed task again, up to n more times, before its failure is reported.
+// Before every retry the worker waits for a backoff that starts at backoff and doubles with every
+// further attempt; a backoff of 0 retries right away. Every failure is retried, be it a timeout,
+// a deadline, a panic or a rejected result, except for a task exceeding its bit budget or with a
+// negative value, which would fail the same way every time. The number of attempts a task took is recorded in its result.
+// A worker that is told to quit gives up before the next retry and reports the last failure.
+// Unlike WithSlowTaskRetry, which gives a slow task one extended allowance, the retries are subject
+// to the same limits as the first attempt. An n of 0 disables retries, which is the default.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Config) {
+		c.Retries = n
+		c.RetryBackoff = backoff
+	}
+}
+
+// retryable reports whether another attempt at processing a task could turn its failed result into a
+// successful one.
+func retryable(r model.Result) bool {
+	return r.Err != nil && !errors.Is(r.Err, utils.ErrBitBudgetExceeded) && !errors.Is(r.Err, utils.ErrUndefinedFactorial)
+}
+
+// processWithRetries processes the task like processSafely, whose first attempt started at startTime, and
+// retries it after a failure as configured by WithRetries. It returns the result of the last attempt,
+// along with the number of attempts in its Attempts field, and the time that attempt started, so the
+// earlier attempts and the backoffs are not counted as the task's processing time. A worker that is
+// told to quit gives up before the next retry and returns the last failure.
+func (w *Worker) processWithRetries(task model.Task, startTime time.Time) (model.Result, time.Time) {
+	r := w.processSafely(task, startTime)
+	attempts := 1
+	backoff := w.retryBackoff
+	for ; attempts <= w.retries && retryable(r); attempts++ {
+		if backoff > 0 {
+			w.setState(stateBackingOff)
+			if !throttleSleep(backoff, w.quit) {
+				break
+			}
+			backoff *= 2
+			w.setState(stateProcessing)
+		} else if isClosed(w.quit) {
+			break
+		}
+		startTime = w.clock.Now()
+		r = w.processSafely(task, startTime)
+	}
+	r.Attempts = attempts
+	return r, startTime
+}
@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool_Retries(t *testing.T) {
+	withoutTimeouts(t)
+
+	// The backoffs pass instantly.
+	var backoffs []time.Duration
+	original := throttleSleep
+	defer func() { throttleSleep = original }()
+	throttleSleep = func(d time.Duration, quit <-chan struct{}) bool {
+		backoffs = append(backoffs, d)
+		return true
+	}
+
+	// Task 1 fails twice before it succeeds, task 2 always fails.
+	attempts := make(map[int]int)
+	pool, err := NewPool(Config{Workers: 1}, WithRetries(3, 10*time.Millisecond),
+		WithPanicHandler(func(int, model.Task, any) {}),
+		WithTaskHook(func(task model.Task) {
+			attempts[task.ID]++
+			if (task.ID == 1 && attempts[task.ID] <= 2) || task.ID == 2 {
+				panic("flaky")
+			}
+		}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	results := pool.RunInline([]model.Task{{ID: 0, Value: 5}, {ID: 1, Value: 6}, {ID: 2, Value: 7}})
+
+	expected := []struct {
+		attempts int
+		failed   bool
+	}{{1, false}, {3, false}, {4, true}}
+	for i, r := range results {
+		if r.Attempts != expected[i].attempts || (r.Err != nil) != expected[i].failed {
+			t.Errorf("Task %d took %d attempts with error %v, want %d attempts and failed: %v",
+				r.Task.ID, r.Attempts, r.Err, expected[i].attempts, expected[i].failed)
+		}
+	}
+	if r := results[1]; r.Err == nil && r.Factorial.Cmp(utils.CalcFactorial(6)) != 0 {
+		t.Errorf("Task 1 expected result 720, got %v", r.Factorial)
+	}
+	if r := results[2]; !errors.Is(r.Err, ErrTaskPanicked) {
+		t.Errorf("Task 2 expected an error wrapping ErrTaskPanicked, got %v", r.Err)
+	}
+
+	// The backoff doubles with every retry of a task and starts over for the next one.
+	ms := time.Millisecond
+	if want := []time.Duration{10 * ms, 20 * ms, 10 * ms, 20 * ms, 40 * ms}; !reflect.DeepEqual(backoffs, want) {
+		t.Errorf("Backed off %v, want %v", backoffs, want)
+	}
+}
+
+func TestPool_Retries_BitBudget(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Exceeding the bit budget fails the same way every time, so it is not retried.
+	pool, err := NewPool(Config{Workers: 1}, WithRetries(3, 0), WithBitBudget(10))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	r := pool.RunInline([]model.Task{{ID: 0, Value: 100}})[0]
+	if !errors.Is(r.Err, utils.ErrBitBudgetExceeded) || r.Attempts != 1 {
+		t.Errorf("Expected a single attempt failing with ErrBitBudgetExceeded, got %d attempts and %v", r.Attempts, r.Err)
+	}
+}
+
+func TestPool_NegativeValue(t *testing.T) {
+	withoutTimeouts(t)
+
+	// A negative value fails the same way every time, so it is not retried either.
+	pool, err := NewPool(Config{Workers: 1}, WithRetries(3, 0))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	r := pool.RunInline([]model.Task{{ID: 0, Value: -5}})[0]
+	if r.Factorial != nil || !errors.Is(r.Err, utils.ErrUndefinedFactorial) || r.Attempts != 1 {
+		t.Errorf("Expected a single attempt failing with ErrUndefinedFactorial, got %v after %d attempts with %v", r.Factorial, r.Attempts, r.Err)
+	}
+}
+
+func TestWorker_Retries_QuitWhileBackingOff(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	simulateDelay = func() { panic("always") }
+	defer func() { simulateDelay = nil }()
+
+	tasks := make(chan model.Task, 1)
+	results := make(chan model.Result, 1)
+	quit := make(chan struct{})
+	tasks <- model.Task{ID: 0, Value: 5}
+
+	var wg sync.WaitGroup
+	w := New(0, tasks, results, &wg, quit, nil)
+	w.panicHandler = func(int, model.Task, any) {}
+	w.retries = 5
+	w.retryBackoff = time.Hour
+
+	wg.Add(1)
+	go w.Start()
+
+	// The worker backs off for an hour after its first attempt, until it is told to quit.
+	for w.state.Load() != int32(stateBackingOff) {
+		time.Sleep(time.Millisecond)
+	}
+	close(quit)
+	wg.Wait()
+
+	select {
+	case r := <-results:
+		if r.Attempts != 1 || !errors.Is(r.Err, ErrTaskPanicked) {
+			t.Errorf("Expected the failure of the first attempt, got %d attempts and %v", r.Attempts, r.Err)
+		}
+	default:
+		// The worker may quit instead of delivering the failure.
+	}
+}
+
+func TestWorker_Retries_QuitWithoutBackoff(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	tasks := make(chan model.Task, 1)
+	results := make(chan model.Result, 1)
+	quit := make(chan struct{})
+	tasks <- model.Task{ID: 0, Value: 5}
+
+	// The first attempt fails and tells the worker to quit, which must not retry without a backoff either.
+	calls := 0
+	simulateDelay = func() {
+		calls++
+		if calls == 1 {
+			close(quit)
+		}
+		panic("always")
+	}
+	defer func() { simulateDelay = nil }()
+
+	var wg sync.WaitGroup
+	w := New(0, tasks, results, &wg, quit, nil)
+	w.panicHandler = func(int, model.Task, any) {}
+	w.retries = 5
+
+	wg.Add(1)
+	go w.Start()
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected a single attempt after quitting, got %d", calls)
+	}
+}
+
+func TestPool_Retries_ProcessingTime(t *testing.T) {
+	withoutTimeouts(t)
+
+	const cost = 10 * time.Millisecond
+	clock := newFakeClock()
+	original := throttleSleep
+	defer func() { throttleSleep = original }()
+	throttleSleep = func(d time.Duration, quit <-chan struct{}) bool {
+		clock.Advance(d)
+		return true
+	}
+
+	// Every attempt takes cost, and the first two fail.
+	attempts := 0
+	var durations []time.Duration
+	pool, err := NewPool(Config{Workers: 1, Clock: clock}, WithRetries(3, time.Second),
+		WithPanicHandler(func(int, model.Task, any) {}),
+		WithTaskObserver(func(int, model.Task) func(model.Result, time.Duration) {
+			return func(_ model.Result, d time.Duration) { durations = append(durations, d) }
+		}),
+		WithTaskHook(func(model.Task) {
+			clock.Advance(cost)
+			if attempts++; attempts <= 2 {
+				panic("flaky")
+			}
+		}))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	tasks := make(chan model.Task, 1)
+	tasks <- model.Task{ID: 0, Value: 5}
+	close(tasks)
+	pool.Start(tasks)
+	for range pool.Unordered() {
+	}
+
+	// Neither the failed attempts nor the backoffs count as processing time.
+	if !reflect.DeepEqual(durations, []time.Duration{cost}) {
+		t.Errorf("Observed durations %v, want %v", durations, []time.Duration{cost})
+	}
+}
@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/generator"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// RunContext generates numTasks random tasks and processes them with a pool of numWorkers workers,
+// both bound to ctx. Cancelling ctx stops the generator and the pool together: no further tasks are
+// generated or dispatched, and the returned channel is closed once every worker has stopped. A worker
+// abandons a result nobody receives at the time of the cancellation, so a cancelled run may drop the
+// results of the tasks that were in flight. The caller must drain the channel until it is closed.
+// An error is returned if the pool configuration is invalid.
+func RunContext(ctx context.Context, numTasks, numWorkers int) (<-chan model.Result, error) {
+	pool, err := NewPool(Config{Workers: numWorkers})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make(chan model.Task, numTasks)
+	go generator.GenerateTasksContext(ctx, numTasks, generator.DefaultMin, generator.DefaultMax, tasks)
+	pool.StartContext(ctx, tasks)
+
+	return pool.Unordered(), nil
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRunContext(t *testing.T) {
+	const numTasks = 50
+
+	results, err := RunContext(context.Background(), numTasks, 4)
+	if err != nil {
+		t.Fatalf("RunContext() returned an error: %v", err)
+	}
+
+	sortedResults := SortResults(results, numTasks)
+	for i, result := range sortedResults {
+		// Some results may have timed out, but every task has one.
+		if result.Task.ID != i || (result.Factorial == nil && result.Err == nil) {
+			t.Errorf("Missing result for task %d", i)
+		}
+	}
+}
+
+func TestRunContext_Cancel(t *testing.T) {
+	checkGoroutines(t)
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const numTasks = 100000
+	results, err := RunContext(ctx, numTasks, 4)
+	if err != nil {
+		t.Fatalf("RunContext() returned an error: %v", err)
+	}
+
+	// Cancel after the first few results and drain whatever is still delivered.
+	received := 0
+	for range results {
+		received++
+		if received == 10 {
+			cancel()
+		}
+	}
+
+	if received >= numTasks {
+		t.Errorf("Received all %d results despite cancellation", received)
+	}
+
+	// Give the goroutines a moment to exit after the results channel has been closed.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Errorf("%d goroutines still running after cancellation, want at most %d", n, baseline)
+	}
+}
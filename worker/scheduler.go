@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sync"
+)
+
+// Scheduler decides the order in which queued tasks are handed to the workers of a Pool.
+// Implementations must be safe for concurrent use, although the Pool only calls them
+// from its single dispatch goroutine.
+type Scheduler interface {
+	// Push adds a task to the scheduler.
+	Push(task model.Task)
+	// Pop removes and returns the next task to be processed.
+	// The boolean is false if the scheduler holds no tasks.
+	Pop() (model.Task, bool)
+}
+
+// FIFOScheduler dispatches tasks in the order they were pushed.
+// It is the default Scheduler of a Pool.
+//
+// Most tasks carry nothing but an ID and a value, so the queue stores those packed as two int64
+// per task, a third of the size of a Task. Tasks with metadata, i.e. dependencies, a group, a batch or a priority, are
+// kept in full on the side, so any task can be pushed and comes back from Pop unchanged.
+type FIFOScheduler struct {
+	mu sync.Mutex
+	// packed holds the ID and the value of every queued task, oldest first.
+	packed []int64
+	// head is the sequence number of the oldest queued task, i.e. the number of tasks popped so far.
+	head uint64
+	// extended holds the queued tasks with metadata, keyed by their sequence number.
+	extended map[uint64]model.Task
+}
+
+// NewFIFOScheduler returns an empty FIFOScheduler.
+func NewFIFOScheduler() *FIFOScheduler {
+	return &FIFOScheduler{}
+}
+
+// Push appends the task to the end of the queue.
+func (s *FIFOScheduler) Push(task model.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task.DependsOn != nil || task.GroupID != "" || task.Batch != nil || task.Priority != 0 {
+		if s.extended == nil {
+			s.extended = make(map[uint64]model.Task)
+		}
+		s.extended[s.head+uint64(len(s.packed)/2)] = task
+	}
+	s.packed = append(s.packed, int64(task.ID), task.Value)
+}
+
+// Pop removes and returns the oldest task in the queue.
+func (s *FIFOScheduler) Pop() (model.Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.packed) == 0 {
+		return model.Task{}, false
+	}
+
+	task := model.Task{ID: int(s.packed[0]), Value: s.packed[1]}
+	if full, ok := s.extended[s.head]; ok {
+		task = full
+		delete(s.extended, s.head)
+	}
+	s.packed = s.packed[2:]
+	s.head++
+	return task, true
+}
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"runtime"
+	"testing"
+)
+
+func TestFIFOScheduler_Order(t *testing.T) {
+	scheduler := NewFIFOScheduler()
+
+	if _, ok := scheduler.Pop(); ok {
+		t.Fatalf("Pop() on an empty scheduler returned a task")
+	}
+
+	for i := 0; i < 5; i++ {
+		scheduler.Push(model.Task{ID: i, Value: int64(i)})
+	}
+
+	for i := 0; i < 5; i++ {
+		task, ok := scheduler.Pop()
+		if !ok {
+			t.Fatalf("Pop() returned no task, want task %d", i)
+		}
+		if task.ID != i {
+			t.Errorf("Pop() returned task %d, want %d", task.ID, i)
+		}
+	}
+
+	if _, ok := scheduler.Pop(); ok {
+		t.Errorf("Pop() on a drained scheduler returned a task")
+	}
+}
+
+func TestFIFOScheduler_Metadata(t *testing.T) {
+	scheduler := NewFIFOScheduler()
+
+	batch := &model.Batch{Seed: 1, NumTasks: 4}
+	tasks := []model.Task{
+		{ID: 0, Value: 3},
+		{ID: 1, Value: 5, DependsOn: []int{0}},
+		{ID: 2, Value: 7},
+		{ID: 3, Value: 9, Batch: batch},
+		{ID: 4, Value: 11, GroupID: "group"},
+		{ID: 5, Value: 13, Priority: 2},
+	}
+
+	// Interleave pushes and pops, so the sequence numbers of the tasks with metadata move.
+	scheduler.Push(tasks[0])
+	scheduler.Push(tasks[1])
+	popped := make([]model.Task, 0, len(tasks))
+	task, _ := scheduler.Pop()
+	popped = append(popped, task)
+	scheduler.Push(tasks[2])
+	scheduler.Push(tasks[3])
+	scheduler.Push(tasks[4])
+	scheduler.Push(tasks[5])
+	for {
+		task, ok := scheduler.Pop()
+		if !ok {
+			break
+		}
+		popped = append(popped, task)
+	}
+
+	if len(popped) != len(tasks) {
+		t.Fatalf("Popped %d tasks, want %d", len(popped), len(tasks))
+	}
+	for i, task := range popped {
+		if task.ID != tasks[i].ID || task.Value != tasks[i].Value || task.Batch != tasks[i].Batch ||
+			task.GroupID != tasks[i].GroupID || task.Priority != tasks[i].Priority || len(task.DependsOn) != len(tasks[i].DependsOn) {
+			t.Errorf("Pop() returned %+v, want %+v", task, tasks[i])
+		}
+	}
+	if len(scheduler.extended) != 0 {
+		t.Errorf("Scheduler still holds %d tasks with metadata after being drained", len(scheduler.extended))
+	}
+}
+
+// heapGrowth returns the number of bytes the live heap grew by while fill ran, after a garbage collection.
+// The value returned by fill is kept alive until the measurement is done.
+func heapGrowth(fill func() any) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	kept := fill()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(kept)
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+// benchmarkQueueMemory reports the heap bytes per task of queueing 10 million tasks with fill.
+func benchmarkQueueMemory(b *testing.B, fill func(numTasks int) any) {
+	const numTasks = 10_000_000
+	for i := 0; i < b.N; i++ {
+		growth := heapGrowth(func() any { return fill(numTasks) })
+		b.ReportMetric(float64(growth)/numTasks, "B/task")
+	}
+}
+
+func BenchmarkQueueMemory_TaskChannel(b *testing.B) {
+	benchmarkQueueMemory(b, func(numTasks int) any {
+		tasks := make(chan model.Task, numTasks)
+		for id := 0; id < numTasks; id++ {
+			tasks <- model.Task{ID: id, Value: 3}
+		}
+		return tasks
+	})
+}
+
+func BenchmarkQueueMemory_FIFOScheduler(b *testing.B) {
+	benchmarkQueueMemory(b, func(numTasks int) any {
+		scheduler := NewFIFOScheduler()
+		for id := 0; id < numTasks; id++ {
+			scheduler.Push(model.Task{ID: id, Value: 3})
+		}
+		return scheduler
+	})
+}
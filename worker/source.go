@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// StartFunc is like Start, but pulls the tasks from next instead of receiving them from a channel.
+// Next returns the next task, or false once there are no more tasks; it is not called again after that.
+// It is only ever called from a single goroutine of the pool, so it needs no synchronization of its
+// own, and only when the pool is ready to accept another task, so producers that compute their tasks
+// on demand do not run far ahead of the workers.
+func (p *Pool) StartFunc(next func() (model.Task, bool)) {
+	p.StartFuncContext(context.Background(), next)
+}
+
+// StartFuncContext is like StartFunc, but stops as soon as ctx is cancelled, like StartContext.
+// Next is not called again once ctx has been cancelled.
+func (p *Pool) StartFuncContext(ctx context.Context, next func() (model.Task, bool)) {
+	tasks := make(chan model.Task)
+	go func() {
+		defer close(tasks)
+		for ctx.Err() == nil {
+			task, ok := next()
+			if !ok {
+				return
+			}
+			select {
+			case tasks <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	p.StartContext(ctx, tasks)
+}
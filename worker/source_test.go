@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+	"time"
+)
+
+func TestPool_StartFunc(t *testing.T) {
+	withoutTimeouts(t)
+
+	const numTasks = 10
+
+	// Count the calls, including those after exhaustion, which must not happen.
+	calls := 0
+	next := func() (model.Task, bool) {
+		calls++
+		if calls > numTasks {
+			return model.Task{}, false
+		}
+		return model.Task{ID: calls - 1, Value: int64(calls + 2)}, true
+	}
+
+	pool, err := NewPool(Config{Workers: 3})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.StartFunc(next)
+
+	for i, r := range pool.Ordered(numTasks) {
+		if r.Task.ID != i || r.Task.Value != int64(i+3) {
+			t.Errorf("Result %d is task %d with value %d, want task %d with value %d", i, r.Task.ID, r.Task.Value, i, i+3)
+		}
+	}
+	<-pool.Done()
+	if calls != numTasks+1 {
+		t.Errorf("Expected next to be called %d times, got %d", numTasks+1, calls)
+	}
+}
+
+func TestPool_StartFuncContext_Cancel(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// An endless producer, stopped only by the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	id := 0
+	next := func() (model.Task, bool) {
+		id++
+		return model.Task{ID: id, Value: 5}, true
+	}
+
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.StartFuncContext(ctx, next)
+
+	results := pool.Unordered()
+	<-results
+	cancel()
+	for range results {
+	}
+
+	select {
+	case <-pool.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Pool did not stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// workerState is what a worker is doing right now, as reported by Pool.DumpState.
+type workerState int32
+
+const (
+	// stateStarting is the state of a worker that has not started yet.
+	stateStarting workerState = iota
+	// stateWaiting is the state of a worker waiting for a task.
+	stateWaiting
+	// stateRateLimited is the state of a worker waiting for its rate limit to allow the next task.
+	stateRateLimited
+	// stateProcessing is the state of a worker computing a task.
+	stateProcessing
+	// stateSending is the state of a worker waiting for the consumer to take a result.
+	stateSending
+	// stateReporting is the state of a worker waiting for the dispatcher to take a completed task ID.
+	stateReporting
+	// stateStopped is the state of a worker that has returned.
+	stateStopped
+	// stateRetired is the state of a worker the autoscaler has retired.
+	stateRetired
+	// stateThrottled is the state of a worker pausing to stay within its CPU limit.
+	stateThrottled
+	// stateBackingOff is the state of a worker waiting to retry a failed task.
+	stateBackingOff
+)
+
+// String returns a human-readable description of the state.
+func (s workerState) String() string {
+	switch s {
+	case stateStarting:
+		return "not started"
+	case stateWaiting:
+		return "waiting for a task"
+	case stateRateLimited:
+		return "waiting for its rate limit"
+	case stateProcessing:
+		return "processing"
+	case stateSending:
+		return "sending the result of"
+	case stateReporting:
+		return "reporting the completion of"
+	case stateStopped:
+		return "stopped"
+	case stateRetired:
+		return "retired by the autoscaler"
+	case stateThrottled:
+		return "pausing for its CPU limit"
+	case stateBackingOff:
+		return "waiting to retry a failed task"
+	default:
+		return fmt.Sprintf("workerState(%d)", int32(s))
+	}
+}
+
+// DumpState returns a human-readable snapshot of the internal state of the pool, for diagnosing a pool
+// that appears to hang: the queue depth, the state of every channel, and what every worker is doing and
+// with which task. A worker "sending the result of" a task is blocked on a consumer that does not read
+// the results, while one "waiting for a task" is starved by the producer or the scheduler.
+// It only reads atomic values, channel lengths and the briefly locked worker statistics, so it never
+// blocks on the pool's channels and is safe to call at any time, e.g. from a signal handler or a debug
+// endpoint. The values are read one by one, so the snapshot is not taken at a single instant.
+func (p *Pool) DumpState() string {
+	var b strings.Builder
+
+	processed := 0
+	for _, w := range p.workers {
+		processed += w.Stats().Processed
+	}
+	fmt.Fprintf(&b, "pool: %d workers, queue depth %d (%d buffered incoming, %d queued), %d received, %d processed\n",
+		len(p.workers), p.QueueDepth(), len(p.incoming), p.queued.Load(), p.received.Load(), processed)
+
+	results := fmt.Sprintf("%d/%d buffered", len(p.results), cap(p.results))
+	if p.cfg.ShardedResults {
+		buffered := 0
+		for _, c := range p.workerResults {
+			buffered += len(c)
+		}
+		results = fmt.Sprintf("sharded, %d buffered", buffered)
+	}
+	fmt.Fprintf(&b, "channels: incoming %s, dispatch %s, results %s %s, quit %s, done %s\n",
+		openOrClosed(!p.incomingClosed.Load()), openOrClosed(!p.dispatchClosed.Load()),
+		results, openOrClosed(!p.resultsClosed.Load()), openOrClosed(!isClosed(p.quit)), openOrClosed(!isClosed(p.done)))
+
+	for _, w := range p.workers {
+		state := workerState(w.state.Load())
+		if task, ok := w.CurrentTask(); ok && state != stateWaiting {
+			fmt.Fprintf(&b, "worker %d: %v task %d (%d!)\n", w.ID, state, task.ID, task.Value)
+		} else {
+			fmt.Fprintf(&b, "worker %d: %v\n", w.ID, state)
+		}
+	}
+	return b.String()
+}
+
+// openOrClosed describes whether a channel is open.
+func openOrClosed(open bool) string {
+	if open {
+		return "open"
+	}
+	return "closed"
+}
+
+// isClosed reports whether the signal channel c has been closed, without blocking.
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
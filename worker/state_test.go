@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForState polls DumpState until it contains every fragment, and fails the test if it does not
+// within a second. It returns the last snapshot.
+func waitForState(t *testing.T, pool *Pool, fragments ...string) string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		state := pool.DumpState()
+		missing := ""
+		for _, fragment := range fragments {
+			if !strings.Contains(state, fragment) {
+				missing = fragment
+				break
+			}
+		}
+		if missing == "" {
+			return state
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("DumpState() does not contain %q:\n%s", missing, state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPool_DumpState(t *testing.T) {
+	withoutTimeouts(t)
+
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if state := pool.DumpState(); !strings.Contains(state, "worker 0: not started") {
+		t.Errorf("DumpState() before Start does not report the workers as not started:\n%s", state)
+	}
+
+	tasks := make(chan model.Task)
+	pool.Start(tasks)
+
+	// Nothing to do: the workers starve.
+	waitForState(t, pool, "incoming open", "worker 0: waiting for a task", "worker 1: waiting for a task")
+
+	// Nobody reads the unbuffered results channel: the workers block on sending.
+	tasks <- model.Task{ID: 0, Value: 5}
+	tasks <- model.Task{ID: 1, Value: 7}
+	tasks <- model.Task{ID: 2, Value: 9}
+	state := waitForState(t, pool, "sending the result of task 0 (5!)", "sending the result of task 1 (7!)", "queue depth 1")
+	if !strings.Contains(state, "3 received, 0 processed") {
+		t.Errorf("DumpState() does not report the task counts:\n%s", state)
+	}
+
+	close(tasks)
+	for range pool.Unordered() {
+	}
+	<-pool.Done()
+	waitForState(t, pool, "incoming closed", "dispatch closed", "results 0/0 buffered closed", "quit closed", "done closed",
+		"worker 0: stopped", "worker 1: stopped")
+}
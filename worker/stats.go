@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"time"
+)
+
+// WorkerStats is a snapshot of how a worker has spent its time.
+type WorkerStats struct {
+	// WorkerID identifies the worker the snapshot belongs to.
+	WorkerID int
+	// IdleTime is the total time the worker spent waiting for a task.
+	IdleTime time.Duration
+	// BusyTime is the total time the worker spent processing tasks, from receiving a task until its result
+	// was ready. The time spent delivering the results, e.g. blocked on a slow consumer, counts as neither
+	// busy nor idle.
+	BusyTime time.Duration
+	// Processed is the number of tasks the worker has completed.
+	Processed int
+	// Failed is the number of completed tasks whose result failed, for any reason.
+	Failed int
+	// TimedOut is the number of failed tasks that exceeded the processing time limit, see ErrTaskTimeout.
+	TimedOut int
+	// ProcessingTime is the total time the worker spent computing the results of its tasks. Of a task
+	// retried after a failure, see WithRetries, only the last attempt counts. Unlike BusyTime, it
+	// excludes earlier attempts and the backoffs between them.
+	ProcessingTime time.Duration
+}
+
+// AverageProcessingTime returns the average time the worker spent computing the result of a task.
+// Returns 0 if no task has been completed yet.
+func (s WorkerStats) AverageProcessingTime() time.Duration {
+	if s.Processed == 0 {
+		return 0
+	}
+	return s.ProcessingTime / time.Duration(s.Processed)
+}
+
+// Utilization returns the percentage of time the worker spent busy.
+// Returns 0 if no time has been recorded yet.
+func (s WorkerStats) Utilization() float64 {
+	return utilization(s.IdleTime, s.BusyTime)
+}
+
+// PoolStats is a snapshot of the statistics of every worker in a Pool.
+type PoolStats struct {
+	// Workers holds the statistics of each worker, indexed by worker ID.
+	Workers []WorkerStats
+	// Cache holds the statistics of the shared cache. It is nil if the pool has no cache,
+	// or its cache does not count its hits and misses.
+	Cache *CacheStats
+	// RedundantComputations is the number of factorials whose computation started while another worker
+	// was computing the same value, i.e. duplicate work. Note that a shared cache only saves the work on
+	// values whose computation has finished, so it avoids these only if duplicates arrive further apart.
+	RedundantComputations uint64
+}
+
+// Utilization returns the percentage of time the workers of the pool spent busy, taken over all workers.
+// A low value means the workers are starved, i.e. the generator or the queue is the bottleneck.
+func (s PoolStats) Utilization() float64 {
+	var idle, busy time.Duration
+	for _, ws := range s.Workers {
+		idle += ws.IdleTime
+		busy += ws.BusyTime
+	}
+	return utilization(idle, busy)
+}
+
+// EstimatedTimeRemaining estimates how long the pool needs to process the tasks that are queued
+// or in flight. It assumes the remaining tasks take as long on average as the completed ones and
+// are spread evenly over the active workers, see ActiveWorkers, so it is only as good as the tasks are uniform: a batch
+// whose large values come last takes longer than estimated. Tasks that have not reached the pool's
+// incoming channel yet are unknown and not included. Returns 0 if nothing is left to process or
+// no task has been completed yet to base the estimate on.
+func (p *Pool) EstimatedTimeRemaining() time.Duration {
+	var busy time.Duration
+	processed := 0
+	for _, ws := range p.Stats().Workers {
+		busy += ws.BusyTime
+		processed += ws.Processed
+	}
+
+	// Everything received but not completed yet is either queued or in flight.
+	remaining := len(p.incoming) + int(p.received.Load()) - processed
+	if remaining <= 0 || processed == 0 {
+		return 0
+	}
+
+	average := busy / time.Duration(processed)
+	return average * time.Duration(remaining) / time.Duration(p.ActiveWorkers())
+}
+
+// Stats returns a snapshot of the worker's statistics. It is safe to call while the worker is running.
+func (w *Worker) Stats() WorkerStats {
+	w.statsLock.Lock()
+	defer w.statsLock.Unlock()
+	return WorkerStats{
+		WorkerID:       w.ID,
+		IdleTime:       w.idleTime,
+		BusyTime:       w.busyTime,
+		Processed:      w.processed,
+		Failed:         w.failed,
+		TimedOut:       w.timedOut,
+		ProcessingTime: w.processingTime,
+	}
+}
+
+// recordOutcome adds the time it took to compute the result to the worker's processing time and
+// counts the result if it failed.
+func (w *Worker) recordOutcome(r model.Result, d time.Duration) {
+	w.statsLock.Lock()
+	defer w.statsLock.Unlock()
+	w.processingTime += d
+	if failed(r) {
+		w.failed++
+	}
+	if errors.Is(r.Err, ErrTaskTimeout) {
+		w.timedOut++
+	}
+}
+
+// addIdleTime adds d to the time the worker spent waiting for a task.
+func (w *Worker) addIdleTime(d time.Duration) {
+	w.statsLock.Lock()
+	defer w.statsLock.Unlock()
+	w.idleTime += d
+}
+
+// addBusyTime adds d to the time the worker spent processing tasks and counts the completed task.
+func (w *Worker) addBusyTime(d time.Duration) {
+	w.statsLock.Lock()
+	defer w.statsLock.Unlock()
+	w.busyTime += d
+	w.processed++
+}
+
+// utilization returns busy as a percentage of the total of idle and busy.
+func utilization(idle, busy time.Duration) float64 {
+	total := idle + busy
+	if total == 0 {
+		return 0
+	}
+	return float64(busy) / float64(total) * 100
+}
@@ -0,0 +1,302 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerStats_Utilization(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    WorkerStats
+		expected float64
+	}{
+		{"no recorded time", WorkerStats{}, 0},
+		{"always busy", WorkerStats{BusyTime: time.Second}, 100},
+		{"always idle", WorkerStats{IdleTime: time.Second}, 0},
+		{"quarter busy", WorkerStats{IdleTime: 3 * time.Second, BusyTime: time.Second}, 25},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.stats.Utilization(); got != test.expected {
+				t.Errorf("Utilization() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestPoolStats_Utilization(t *testing.T) {
+	stats := PoolStats{Workers: []WorkerStats{
+		{WorkerID: 0, IdleTime: time.Second, BusyTime: time.Second},
+		{WorkerID: 1, IdleTime: 3 * time.Second, BusyTime: 3 * time.Second},
+	}}
+
+	if got := stats.Utilization(); got != 50 {
+		t.Errorf("Utilization() = %v, want 50", got)
+	}
+}
+
+func TestWorker_Stats_IdleAndBusyTime(t *testing.T) {
+	// Simulate a delay in task processing so the worker accumulates busy time.
+	simulateDelay = func() {
+		time.Sleep(20 * time.Millisecond)
+	}
+	defer func() { simulateDelay = nil }()
+
+	taskChannel := make(chan model.Task)
+	resultChannel := make(chan model.Result, 1)
+	quit := make(chan struct{})
+
+	var wg sync.WaitGroup
+	testWorker := New(1, taskChannel, resultChannel, &wg, quit, nil)
+
+	wg.Add(1)
+	go testWorker.Start()
+
+	// Keep the worker waiting before handing it a task so it accumulates idle time.
+	time.Sleep(20 * time.Millisecond)
+	taskChannel <- model.Task{ID: 0, Value: 3}
+	close(taskChannel)
+	wg.Wait()
+
+	stats := testWorker.Stats()
+	if stats.IdleTime < 20*time.Millisecond {
+		t.Errorf("IdleTime = %v, want at least 20ms", stats.IdleTime)
+	}
+	if stats.BusyTime < 20*time.Millisecond {
+		t.Errorf("BusyTime = %v, want at least 20ms", stats.BusyTime)
+	}
+}
+
+func TestWorker_Stats_BusyTimeExcludesDelivery(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// Computing the task takes exactly 10ms on the fake clock.
+	const cost = 10 * time.Millisecond
+	clock := newFakeClock()
+	simulateDelay = func() { clock.Advance(cost) }
+	defer func() { simulateDelay = nil }()
+
+	taskChannel := make(chan model.Task, 1)
+	resultChannel := make(chan model.Result)
+	taskChannel <- model.Task{ID: 0, Value: 3}
+	close(taskChannel)
+
+	var wg sync.WaitGroup
+	testWorker := New(1, taskChannel, resultChannel, &wg, make(chan struct{}), clock)
+	wg.Add(1)
+	go testWorker.Start()
+
+	// The consumer takes an hour to read the result.
+	for testWorker.state.Load() != int32(stateSending) {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Hour)
+	<-resultChannel
+	wg.Wait()
+
+	if stats := testWorker.Stats(); stats.BusyTime != cost {
+		t.Errorf("BusyTime = %v, want %v without the time blocked on the consumer", stats.BusyTime, cost)
+	}
+}
+
+func TestPool_EstimatedTimeRemaining(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Every task takes exactly 100ms on the fake clock and waits for the test to let it finish.
+	clock := newFakeClock()
+	step := make(chan struct{})
+	simulateDelay = func() {
+		clock.Advance(100 * time.Millisecond)
+		<-step
+	}
+	defer func() { simulateDelay = nil }()
+
+	const numTasks = 5
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 3}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 1, Clock: clock})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	if eta := pool.EstimatedTimeRemaining(); eta != 0 {
+		t.Errorf("EstimatedTimeRemaining() = %v before any task completed, want 0", eta)
+	}
+
+	previous := time.Duration(-1)
+	for i := 0; i < numTasks; i++ {
+		step <- struct{}{}
+		<-pool.Unordered()
+
+		// Wait until the worker has recorded the completed task.
+		for pool.Stats().Workers[0].Processed < i+1 {
+			time.Sleep(time.Millisecond)
+		}
+
+		eta := pool.EstimatedTimeRemaining()
+		if previous >= 0 && eta >= previous {
+			t.Errorf("EstimatedTimeRemaining() = %v after %d tasks, want less than %v", eta, i+1, previous)
+		}
+		previous = eta
+	}
+
+	if previous != 0 {
+		t.Errorf("EstimatedTimeRemaining() = %v after the last task, want 0", previous)
+	}
+}
+
+func TestPool_MaxResultBits(t *testing.T) {
+	withoutTimeouts(t)
+
+	// 100000! exceeds the budget and fails, so 1000! is the largest result.
+	values := []int64{10, 1000, 3, 100000, 500}
+
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 3}, WithBitBudget(100000))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	if bits := pool.MaxResultBits(); bits != 0 {
+		t.Errorf("MaxResultBits() = %d before Start, want 0", bits)
+	}
+	pool.Start(tasks)
+
+	for range pool.Unordered() {
+	}
+
+	if expected := utils.CalcFactorial(1000).BitLen(); pool.MaxResultBits() != expected {
+		t.Errorf("MaxResultBits() = %d, want %d, the size of 1000!", pool.MaxResultBits(), expected)
+	}
+}
+
+func TestPool_Stats_RedundantComputations(t *testing.T) {
+	run := func(values []int64) uint64 {
+		t.Helper()
+		tasks := make(chan model.Task, len(values))
+		for i, v := range values {
+			tasks <- model.Task{ID: i, Value: v}
+		}
+		close(tasks)
+
+		pool, err := NewPool(Config{Workers: len(values), ThresholdFactor: 1e9})
+		if err != nil {
+			t.Fatalf("NewPool() returned an error: %v", err)
+		}
+		pool.Start(tasks)
+		for range pool.Unordered() {
+		}
+		return pool.Stats().RedundantComputations
+	}
+
+	// Each worker takes one of the large identical tasks at once, so their computations overlap.
+	if redundant := run([]int64{20000, 20000, 20000, 20000}); redundant == 0 || redundant > 3 {
+		t.Errorf("RedundantComputations = %d for 4 concurrent identical tasks, want 1 to 3", redundant)
+	}
+	if redundant := run([]int64{20000, 20001, 20002, 20003}); redundant != 0 {
+		t.Errorf("RedundantComputations = %d for distinct tasks, want 0", redundant)
+	}
+}
+
+func TestWorker_Stats_Outcomes(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Every task takes 10ms per unit of its value on the fake clock, and task 1 always times out.
+	clock := newFakeClock()
+	cost := func(task model.Task) { clock.Advance(time.Duration(task.Value) * 10 * time.Millisecond) }
+	fixed := TimeoutPolicyFunc(func(task model.Task, _, _ time.Duration) bool {
+		return task.ID == 1
+	})
+
+	pool, err := NewPool(Config{Workers: 1, Clock: clock}, WithTaskHook(cost), WithTimeoutPolicy(fixed), WithBitBudget(25))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	// 12! exceeds the bit budget, the others fit in it.
+	values := []int64{5, 6, 3, 12}
+	tasks := make([]model.Task, len(values))
+	for i, v := range values {
+		tasks[i] = model.Task{ID: i, Value: v}
+	}
+	pool.RunInline(tasks)
+
+	stats := pool.workers[0].Stats()
+	if stats.Processed != 4 || stats.Failed != 2 || stats.TimedOut != 1 {
+		t.Errorf("Stats() = %d processed, %d failed, %d timed out, want 4, 2 and 1", stats.Processed, stats.Failed, stats.TimedOut)
+	}
+	if stats.ProcessingTime < 260*time.Millisecond {
+		t.Errorf("ProcessingTime = %v, want at least 260ms", stats.ProcessingTime)
+	}
+	if avg := stats.AverageProcessingTime(); avg != stats.ProcessingTime/4 {
+		t.Errorf("AverageProcessingTime() = %v, want %v", avg, stats.ProcessingTime/4)
+	}
+}
+
+func TestWorkerStats_AverageProcessingTime(t *testing.T) {
+	if avg := (WorkerStats{}).AverageProcessingTime(); avg != 0 {
+		t.Errorf("AverageProcessingTime() = %v without any task, want 0", avg)
+	}
+}
+
+func TestPool_EstimatedTimeRemaining_ActiveWorkers(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Every task takes exactly 100ms on the fake clock and waits for the test to let it finish.
+	clock := newFakeClock()
+	step := make(chan struct{})
+	simulateDelay = func() {
+		clock.Advance(100 * time.Millisecond)
+		<-step
+	}
+	defer func() { simulateDelay = nil }()
+
+	const numTasks = 3
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 3}
+	}
+	close(tasks)
+
+	// Only one of the four workers is active, and the target depth keeps the autoscaler from adding more.
+	pool, err := NewPool(Config{Clock: clock}, WithAutoscale(1, 4, 1000))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	step <- struct{}{}
+	<-pool.Unordered()
+
+	// The two remaining tasks take 100ms each on the single active worker.
+	const expected = 200 * time.Millisecond
+	deadline := time.Now().Add(time.Second)
+	eta := pool.EstimatedTimeRemaining()
+	for eta != expected && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		eta = pool.EstimatedTimeRemaining()
+	}
+	if eta != expected {
+		t.Errorf("EstimatedTimeRemaining() = %v, want %v", eta, expected)
+	}
+
+	for i := 1; i < numTasks; i++ {
+		step <- struct{}{}
+		<-pool.Unordered()
+	}
+}
@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"container/heap"
+	"github.com/lipcsei/konstruktor/model"
+)
+
+// CollectionStrategy selects how a pool hands its results to the consumer through Pool.Results.
+type CollectionStrategy int
+
+const (
+	// CollectStreaming delivers every result as soon as its task completes, in completion order.
+	// It has the lowest latency and buffers nothing, but the order is nondeterministic.
+	// It is the default.
+	CollectStreaming CollectionStrategy = iota
+	// CollectOrdered delivers the results in the order of their task IDs, which must count up from 0.
+	// A result that completes early is held back until every result before it has been delivered,
+	// so a single slow task delays all later ones, and in the worst case the whole batch is buffered.
+	CollectOrdered
+	// CollectBounded retains at most Config.ResultCapacity results, evicting the oldest ones, and
+	// delivers the retained results in completion order once the run has finished. Its memory is
+	// bounded regardless of the size of the batch, at the cost of waiting for the whole run and of
+	// losing evicted results, which are handed to Config.OnOverflow.
+	CollectBounded
+)
+
+// WithCollectionStrategy selects how the pool delivers its results through Results.
+// CollectBounded also requires a result capacity, see WithResultCapacity.
+func WithCollectionStrategy(strategy CollectionStrategy) Option {
+	return func(c *Config) {
+		c.CollectionStrategy = strategy
+	}
+}
+
+// Results returns the channel on which the pool delivers its results according to its collection
+// strategy. The channel is closed once every result has been delivered. Results can be combined with
+// Done and Stats, but not with the other ways of consuming the results, e.g. Unordered or Ordered.
+func (p *Pool) Results() <-chan model.Result {
+	p.resultsOnce.Do(func() {
+		switch p.cfg.CollectionStrategy {
+		case CollectOrdered:
+			p.strategyResults = OrderedResults(p.Unordered())
+		case CollectBounded:
+			p.strategyResults = p.retainedAfterDone()
+		default:
+			p.strategyResults = p.Unordered()
+		}
+	})
+	return p.strategyResults
+}
+
+// OrderedResults delivers the results of the input channel in the order of their task IDs, starting at 0,
+// as soon as every result before them has arrived, so consumers can process the first results while later
+// ones are still being computed. Only the results that arrived ahead of a missing ID are held back, in a
+// min-heap keyed on the task ID, so the memory used is bounded by the reordering window rather than the
+// whole batch. Results after a missing ID are delivered in ID order once the input has been closed, and
+// results with an ID below the next expected one, e.g. duplicates, are delivered right away.
+// The returned channel is closed after the input has been closed and every result has been delivered.
+func OrderedResults(in <-chan model.Result) <-chan model.Result {
+	out := make(chan model.Result)
+
+	go func() {
+		defer close(out)
+
+		var pending resultHeap
+		next := 0
+		for r := range in {
+			heap.Push(&pending, r)
+			// Deliver everything that has become contiguous.
+			for pending.Len() > 0 && pending[0].Task.ID <= next {
+				r := heap.Pop(&pending).(model.Result)
+				if r.Task.ID == next {
+					next++
+				}
+				out <- r
+			}
+		}
+
+		// Whatever is left follows a gap; deliver it in order anyway.
+		for pending.Len() > 0 {
+			out <- heap.Pop(&pending).(model.Result)
+		}
+	}()
+	return out
+}
+
+// resultHeap implements heap.Interface for OrderedResults, with the lowest task ID on top.
+type resultHeap []model.Result
+
+func (h resultHeap) Len() int { return len(h) }
+
+func (h resultHeap) Less(i, j int) bool { return h[i].Task.ID < h[j].Task.ID }
+
+func (h resultHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *resultHeap) Push(x any) { *h = append(*h, x.(model.Result)) }
+
+func (h *resultHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// retainedAfterDone delivers the results retained by the pool's collector once the run has finished.
+func (p *Pool) retainedAfterDone() <-chan model.Result {
+	out := make(chan model.Result)
+
+	go func() {
+		defer close(out)
+		<-p.Done()
+		for _, r := range p.Retained() {
+			out <- r
+		}
+	}()
+	return out
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPool_Results(t *testing.T) {
+	checkGoroutines(t)
+	values := []int64{3, 5, 7, 10, 12, 15, 20, 25}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		ordered bool
+	}{
+		{"streaming", nil, false},
+		{"ordered", []Option{WithCollectionStrategy(CollectOrdered)}, true},
+		{"bounded", []Option{WithCollectionStrategy(CollectBounded), WithResultCapacity(len(values), nil)}, false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			withoutTimeouts(t)
+
+			tasks := make(chan model.Task, len(values))
+			for i, v := range values {
+				tasks <- model.Task{ID: i, Value: v}
+			}
+			close(tasks)
+
+			pool, err := NewPool(Config{Workers: 3}, test.opts...)
+			if err != nil {
+				t.Fatalf("NewPool() returned an error: %v", err)
+			}
+			pool.Start(tasks)
+
+			var results []model.Result
+			for r := range pool.Results() {
+				results = append(results, r)
+			}
+			if len(results) != len(values) {
+				t.Fatalf("Results() delivered %d results, want %d", len(results), len(values))
+			}
+
+			if !test.ordered {
+				sort.Slice(results, func(i, j int) bool { return results[i].Task.ID < results[j].Task.ID })
+			}
+			for i, r := range results {
+				if r.Task.ID != i || r.Factorial.Cmp(utils.CalcFactorial(values[i])) != 0 {
+					t.Errorf("Result %d is task %d with %v, want task %d with %v", i, r.Task.ID, r.Factorial, i, utils.CalcFactorial(values[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestOrderedResults_Gap(t *testing.T) {
+	values := []int64{3, 5, 7, 10, 12}
+	in := make(chan model.Result, 4)
+	for _, id := range []int{4, 0, 3, 1} {
+		in <- model.Result{Task: model.Task{ID: id, Value: values[id]}}
+	}
+	close(in)
+
+	// Task 2 never completes, so the results after it are delivered once the input is closed.
+	var ids []int
+	for r := range OrderedResults(in) {
+		ids = append(ids, r.Task.ID)
+	}
+	if fmt.Sprint(ids) != fmt.Sprint([]int{0, 1, 3, 4}) {
+		t.Errorf("Expected IDs [0 1 3 4], got %v", ids)
+	}
+}
+
+func TestOrderedResults_Incremental(t *testing.T) {
+	checkGoroutines(t)
+	in := make(chan model.Result)
+	out := OrderedResults(in)
+
+	receive := func() int {
+		select {
+		case r := <-out:
+			return r.Task.ID
+		case <-time.After(time.Second):
+			t.Fatalf("No result was delivered")
+			return -1
+		}
+	}
+
+	// Task 1 is held back until task 0 arrives, then both are delivered while the input is still open.
+	in <- model.Result{Task: model.Task{ID: 1}}
+	in <- model.Result{Task: model.Task{ID: 0}}
+	if first, second := receive(), receive(); first != 0 || second != 1 {
+		t.Errorf("Expected tasks 0 and 1, got %d and %d", first, second)
+	}
+
+	in <- model.Result{Task: model.Task{ID: 2}}
+	if id := receive(); id != 2 {
+		t.Errorf("Expected task 2 right away, got %d", id)
+	}
+
+	close(in)
+	if _, ok := <-out; ok {
+		t.Errorf("Expected the output to be closed after the input")
+	}
+}
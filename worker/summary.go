@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+)
+
+// Summary describes a set of results at a glance.
+type Summary struct {
+	// Total is the number of results.
+	Total int
+	// Succeeded is the number of results with a computed factorial.
+	Succeeded int
+	// Failed is the number of results that timed out, exceeded their budget or failed validation.
+	Failed int
+	// DigitHistogram counts the successful results by the number of digits of their factorial,
+	// bucketed by order of magnitude: the key is the smallest digit count of the bucket, i.e. 1 for
+	// 1 to 9 digits, 10 for 10 to 99 digits, 100 for 100 to 999 digits, and so on.
+	DigitHistogram map[int64]int
+	// MinTrailingZeros, MaxTrailingZeros and MeanTrailingZeros describe the number of trailing decimal
+	// zeros of the factorials of the successful results. They are zero if no result succeeded.
+	MinTrailingZeros  int64
+	MaxTrailingZeros  int64
+	MeanTrailingZeros float64
+}
+
+// Summarize counts the successful and failed results, the distribution of the digit counts of the
+// successful ones and the range of their trailing zeros. Both are derived from the task values, with
+// utils.FactorialDigits and utils.TrailingZeros, so the factorials are never converted to decimal
+// strings, which makes summarizing large results cheap.
+func Summarize(results []model.Result) Summary {
+	summary := Summary{Total: len(results), DigitHistogram: make(map[int64]int)}
+	var totalZeros int64
+	for _, r := range results {
+		if failed(r) {
+			summary.Failed++
+			continue
+		}
+		summary.Succeeded++
+		summary.DigitHistogram[digitBucket(utils.FactorialDigits(r.Task.Value))]++
+
+		zeros := utils.TrailingZeros(r.Task.Value)
+		if summary.Succeeded == 1 || zeros < summary.MinTrailingZeros {
+			summary.MinTrailingZeros = zeros
+		}
+		summary.MaxTrailingZeros = max(summary.MaxTrailingZeros, zeros)
+		totalZeros += zeros
+	}
+	if summary.Succeeded > 0 {
+		summary.MeanTrailingZeros = float64(totalZeros) / float64(summary.Succeeded)
+	}
+	return summary
+}
+
+// FailedTasks returns the tasks of the results that failed, because they timed out, exceeded their
+// budget, failed validation or panicked, in the order of the results. The tasks are returned as they
+// were submitted, so they can be fed into another run, e.g. with a higher threshold factor.
+func FailedTasks(results []model.Result) []model.Task {
+	var tasks []model.Task
+	for _, r := range results {
+		if failed(r) {
+			tasks = append(tasks, r.Task)
+		}
+	}
+	return tasks
+}
+
+// failed reports whether the result carries no factorial, because its task timed out,
+// exceeded its budget, failed validation, panicked or had a negative value.
+func failed(r model.Result) bool {
+	return r.Err != nil || r.Factorial == nil
+}
+
+// digitBucket returns the largest power of ten not greater than digits.
+func digitBucket(digits int64) int64 {
+	bucket := int64(1)
+	for bucket*10 <= digits {
+		bucket *= 10
+	}
+	return bucket
+}
@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	result := func(value int64) model.Result {
+		return model.Result{Task: model.Task{Value: value}, Factorial: utils.CalcFactorial(value)}
+	}
+
+	results := []model.Result{
+		result(3),    // 1 digit
+		result(8),    // 5 digits
+		result(13),   // 10 digits
+		result(20),   // 19 digits
+		result(70),   // 101 digits
+		result(1000), // 2568 digits
+		{Task: model.Task{Value: 500}, Err: ErrTaskTimeout},                 // timed out
+		{Task: model.Task{Value: 30}, Err: errors.New("validation failed")}, // rejected
+	}
+
+	summary := Summarize(results)
+	if summary.Total != 8 || summary.Succeeded != 6 || summary.Failed != 2 {
+		t.Errorf("Summarize() counted %d results, %d succeeded and %d failed, want 8, 6 and 2",
+			summary.Total, summary.Succeeded, summary.Failed)
+	}
+
+	expected := map[int64]int{1: 2, 10: 2, 100: 1, 1000: 1}
+	if !reflect.DeepEqual(summary.DigitHistogram, expected) {
+		t.Errorf("Summarize() digit histogram = %v, want %v", summary.DigitHistogram, expected)
+	}
+}
+
+func TestSummarize_TrailingZeros(t *testing.T) {
+	values := []int64{3, 10, 25, 64, 125}
+	results := []model.Result{{Task: model.Task{Value: 1000}, Err: ErrTaskTimeout}} // timed out
+	for _, v := range values {
+		results = append(results, model.Result{Task: model.Task{Value: v}, Factorial: utils.CalcFactorial(v)})
+	}
+
+	// Count the trailing zeros of the full numbers for comparison.
+	var minZeros, maxZeros, total int64 = math.MaxInt64, 0, 0
+	for _, v := range values {
+		digits := utils.CalcFactorial(v).String()
+		zeros := int64(len(digits) - len(strings.TrimRight(digits, "0")))
+		minZeros, maxZeros, total = min(minZeros, zeros), max(maxZeros, zeros), total+zeros
+	}
+	mean := float64(total) / float64(len(values))
+
+	summary := Summarize(results)
+	if summary.MinTrailingZeros != minZeros || summary.MaxTrailingZeros != maxZeros || summary.MeanTrailingZeros != mean {
+		t.Errorf("Summarize() trailing zeros min %d, max %d, mean %v, want %d, %d, %v",
+			summary.MinTrailingZeros, summary.MaxTrailingZeros, summary.MeanTrailingZeros, minZeros, maxZeros, mean)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	summary := Summarize(nil)
+	if summary.Total != 0 || len(summary.DigitHistogram) != 0 {
+		t.Errorf("Summarize(nil) = %+v, want an empty summary", summary)
+	}
+}
+
+func TestFailedTasks(t *testing.T) {
+	results := []model.Result{
+		{Task: model.Task{ID: 0, Value: 3}, Factorial: big.NewInt(6)},
+		{Task: model.Task{ID: 1, Value: 500}, Err: ErrTaskTimeout},                 // timed out
+		{Task: model.Task{ID: 2, Value: 5}, Factorial: big.NewInt(120)},            // succeeded
+		{Task: model.Task{ID: 3, Value: 30}, Err: errors.New("validation failed")}, // rejected
+		{Task: model.Task{ID: 4, Value: 13, DependsOn: []int{0}}, Err: ErrTaskPanicked},
+	}
+
+	tasks := FailedTasks(results)
+
+	expected := []int{1, 3, 4}
+	if len(tasks) != len(expected) {
+		t.Fatalf("FailedTasks() returned %d tasks, want %d", len(tasks), len(expected))
+	}
+	for i, task := range tasks {
+		if task.ID != expected[i] || task.Value != results[expected[i]].Task.Value {
+			t.Errorf("Failed task %d is %+v, want task %d", i, task, expected[i])
+		}
+	}
+	if len(tasks[2].DependsOn) != 1 {
+		t.Errorf("FailedTasks() dropped the dependencies of task 4")
+	}
+
+	if tasks := FailedTasks(results[:1]); tasks != nil {
+		t.Errorf("FailedTasks() = %v for successful results only, want none", tasks)
+	}
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"errors"
+	"github.com/lipcsei/konstruktor/model"
+	"time"
+)
+
+// ErrTaskTimeout is wrapped by the error of a result the timeout policy timed out.
+var ErrTaskTimeout = errors.New("task exceeded the processing time limit")
+
+// ErrTaskDeadline is wrapped by the error of a result whose computation was aborted by its deadline,
+// see WithTaskDeadline.
+var ErrTaskDeadline = errors.New("task exceeded its deadline")
+
+// TimeoutPolicy decides whether a task took too long, in which case its result is discarded, or the
+// task retried if slow-task retries are enabled. Implementations must be safe for concurrent use, as
+// every worker of a pool consults the same policy.
+type TimeoutPolicy interface {
+	// ShouldTimeout reports whether the task, which took duration to process, timed out. Average is the
+	// average processing time of recent tasks, or zero if no processing time has been recorded yet.
+	ShouldTimeout(task model.Task, duration, average time.Duration) bool
+}
+
+// TimeoutPolicyFunc adapts an ordinary function to a TimeoutPolicy.
+type TimeoutPolicyFunc func(task model.Task, duration, average time.Duration) bool
+
+// ShouldTimeout calls f(task, duration, average).
+func (f TimeoutPolicyFunc) ShouldTimeout(task model.Task, duration, average time.Duration) bool {
+	return f(task, duration, average)
+}
+
+// ThresholdPolicy times a task out if it took longer than Factor times the average processing time.
+// Nothing times out before a processing time has been recorded. It is the default policy, with the
+// pool's ThresholdFactor.
+type ThresholdPolicy struct {
+	// Factor is the multiple of the average processing time a task may take.
+	Factor float64
+}
+
+// ShouldTimeout reports whether duration exceeds Factor times the average.
+func (p ThresholdPolicy) ShouldTimeout(_ model.Task, duration, average time.Duration) bool {
+	return average > 0 && duration > time.Duration(float64(average)*p.Factor)
+}
+
+// WithTimeoutPolicy replaces the default ThresholdPolicy, which compares the processing time of a task
+// against a multiple of the average, with a custom policy, e.g. a fixed limit, a percentile of recent
+// processing times or a limit that grows with the task value.
+func WithTimeoutPolicy(policy TimeoutPolicy) Option {
+	return func(c *Config) {
+		c.TimeoutPolicy = policy
+	}
+}
+
+// WithTaskDeadline gives the computation of every task a deadline of its own: a computation still
+// running after d is aborted, and its result fails with an error wrapping ErrTaskDeadline, rather
+// than taking its full time only to be timed out afterwards. Unlike the timeout policy, which judges a task only
+// once it has completed, the deadline bounds how long a huge task occupies its worker, and it does
+// not depend on the processing times of recent tasks. A retried task gets a fresh deadline.
+// The deadline is measured with the real clock, and the computation checks it every 1000
+// multiplications. A d of 0 disables the deadline, which is the default.
+func WithTaskDeadline(d time.Duration) Option {
+	return func(c *Config) {
+		c.TaskDeadline = d
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lipcsei/konstruktor/model"
+	"testing"
+	"time"
+)
+
+func TestThresholdPolicy(t *testing.T) {
+	tests := []struct {
+		name              string
+		duration, average time.Duration
+		expected          bool
+	}{
+		{"no average yet", time.Second, 0, false},
+		{"below the threshold", 100 * time.Millisecond, 100 * time.Millisecond, false},
+		{"at the threshold", 110 * time.Millisecond, 100 * time.Millisecond, false},
+		{"above the threshold", 111 * time.Millisecond, 100 * time.Millisecond, true},
+	}
+
+	policy := ThresholdPolicy{Factor: 1.1}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d_%s", i, test.name), func(t *testing.T) {
+			if result := policy.ShouldTimeout(model.Task{}, test.duration, test.average); result != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPool_TimeoutPolicy(t *testing.T) {
+	withoutTimeouts(t)
+
+	// Every task takes 10ms per unit of its value on the fake clock.
+	clock := newFakeClock()
+	cost := func(task model.Task) { clock.Advance(time.Duration(task.Value) * 10 * time.Millisecond) }
+
+	// A fixed limit of 150ms, regardless of the average.
+	fixed := TimeoutPolicyFunc(func(_ model.Task, duration, _ time.Duration) bool {
+		return duration > 150*time.Millisecond
+	})
+
+	pool, err := NewPool(Config{Workers: 1, Clock: clock}, WithTaskHook(cost), WithTimeoutPolicy(fixed))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+
+	values := []int64{5, 20, 10, 16}
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+	pool.Start(tasks)
+
+	// 20 and 16 take 200ms and 160ms, the others stay within the limit.
+	expected := []bool{false, true, false, true}
+	for _, r := range pool.Ordered(len(values)) {
+		if timedOut := errors.Is(r.Err, ErrTaskTimeout); timedOut != expected[r.Task.ID] || timedOut != (r.Factorial == nil) {
+			t.Errorf("Task %d (%d!) timed out: %v, want %v", r.Task.ID, r.Task.Value, timedOut, expected[r.Task.ID])
+		}
+	}
+}
+
+func TestPool_TaskDeadline(t *testing.T) {
+	withoutTimeouts(t)
+
+	// A factorial that takes seconds to compute, among small ones.
+	values := []int64{10, 1000000, 20}
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2}, WithTaskDeadline(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	start := time.Now()
+	pool.Start(tasks)
+	results := pool.Ordered(len(values))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("The pool took %v, want the huge task to be aborted shortly after its deadline", elapsed)
+	}
+
+	if r := results[1]; !errors.Is(r.Err, ErrTaskDeadline) || r.Factorial != nil {
+		t.Errorf("Expected the huge task to fail with ErrTaskDeadline, got %v and %v", r.Factorial, r.Err)
+	}
+	for _, i := range []int{0, 2} {
+		if r := results[i]; r.Err != nil || r.Factorial == nil {
+			t.Errorf("Expected task %d to succeed, got %v and %v", i, r.Factorial, r.Err)
+		}
+	}
+}
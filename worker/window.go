@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"sort"
+	"sync"
+)
+
+// WindowCollector retains the results whose task IDs fall within a sliding window ending at the highest
+// ID seen so far, and evicts the others, which bounds the memory of continuous streams whose IDs keep
+// growing. The window covers the size IDs up to and including the highest one, so as soon as a result
+// with a higher ID arrives, the results that fall out of the window are evicted. A result that arrives
+// with an ID already below the window is evicted immediately.
+//
+// Only the results within the window can be retrieved in order: a result that completes so late that
+// the window has moved past its ID is never part of Results, so ordered retrieval of a stream has gaps
+// unless the window is wider than the spread of the completion order.
+type WindowCollector struct {
+	mu sync.Mutex
+	// size is the number of IDs covered by the window.
+	size int
+	// results holds the retained results, keyed by task ID.
+	results map[int]model.Result
+	// highest is the highest task ID seen so far, and seen reports whether there has been any.
+	highest int
+	seen    bool
+	// onEvict is called with every evicted result. It may be nil.
+	onEvict func(model.Result)
+}
+
+// NewWindowCollector returns a collector retaining the results within a window of size IDs and passing
+// every evicted result to onEvict, which may be nil to simply drop them. onEvict is called synchronously
+// from Add, without holding the collector's lock.
+func NewWindowCollector(size int, onEvict func(model.Result)) *WindowCollector {
+	return &WindowCollector{size: size, results: make(map[int]model.Result), onEvict: onEvict}
+}
+
+// Add retains the result if its ID is within the window, which it advances if the ID is the highest so far,
+// and evicts the results that fall out of the window.
+func (c *WindowCollector) Add(result model.Result) {
+	evicted := c.add(result)
+	if c.onEvict != nil {
+		for _, r := range evicted {
+			c.onEvict(r)
+		}
+	}
+}
+
+// add retains the result and returns the evicted results, lowest ID first.
+func (c *WindowCollector) add(result model.Result) []model.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := result.Task.ID
+	if c.seen && id <= c.highest-c.size {
+		// The window has already moved past the result.
+		return []model.Result{result}
+	}
+	c.results[id] = result
+	if c.seen && id <= c.highest {
+		return nil
+	}
+
+	// The window advances; evict what falls out of it.
+	oldLow := c.highest - c.size + 1
+	c.highest, c.seen = id, true
+	low := id - c.size + 1
+
+	var evicted []model.Result
+	if low-oldLow < len(c.results) {
+		// Fewer IDs left the window than there are results, so look them up one by one.
+		for retained := oldLow; retained < low; retained++ {
+			if r, ok := c.results[retained]; ok {
+				evicted = append(evicted, r)
+				delete(c.results, retained)
+			}
+		}
+		return evicted
+	}
+	for retained, r := range c.results {
+		if retained < low {
+			evicted = append(evicted, r)
+			delete(c.results, retained)
+		}
+	}
+	sort.Slice(evicted, func(i, j int) bool { return evicted[i].Task.ID < evicted[j].Task.ID })
+	return evicted
+}
+
+// Collect adds every result received on the channel until it is closed.
+func (c *WindowCollector) Collect(results <-chan model.Result) {
+	for r := range results {
+		c.Add(r)
+	}
+}
+
+// Results returns the retained results, ordered by task ID. IDs within the window whose results have not
+// arrived are absent.
+func (c *WindowCollector) Results() []model.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := make([]model.Result, 0, len(c.results))
+	for _, r := range c.results {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Task.ID < results[j].Task.ID })
+	return results
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"github.com/lipcsei/konstruktor/model"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// resultIDs returns the task IDs of the results.
+func resultIDs(results []model.Result) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.Task.ID
+	}
+	return ids
+}
+
+func TestWindowCollector(t *testing.T) {
+	var evicted []int
+	collector := NewWindowCollector(3, func(r model.Result) {
+		evicted = append(evicted, r.Task.ID)
+	})
+
+	add := func(ids ...int) {
+		for _, id := range ids {
+			collector.Add(model.Result{Task: model.Task{ID: id}})
+		}
+	}
+
+	// Out of order within the window: nothing is evicted, and the results come back ordered.
+	add(1, 0, 2)
+	if ids := resultIDs(collector.Results()); !reflect.DeepEqual(ids, []int{0, 1, 2}) {
+		t.Errorf("Results() returned IDs %v, want [0 1 2]", ids)
+	}
+	if len(evicted) != 0 {
+		t.Errorf("Evicted %v within the window, want nothing", evicted)
+	}
+
+	// The window advances to [2, 4].
+	add(4)
+	if ids := resultIDs(collector.Results()); !reflect.DeepEqual(ids, []int{2, 4}) {
+		t.Errorf("Results() returned IDs %v, want [2 4]", ids)
+	}
+	if !reflect.DeepEqual(evicted, []int{0, 1}) {
+		t.Errorf("Evicted %v, want [0 1]", evicted)
+	}
+
+	// A late result below the window is evicted right away, one within it is retained.
+	add(1, 3)
+	if ids := resultIDs(collector.Results()); !reflect.DeepEqual(ids, []int{2, 3, 4}) {
+		t.Errorf("Results() returned IDs %v, want [2 3 4]", ids)
+	}
+
+	// A jump far ahead evicts everything.
+	add(100)
+	if ids := resultIDs(collector.Results()); !reflect.DeepEqual(ids, []int{100}) {
+		t.Errorf("Results() returned IDs %v, want [100]", ids)
+	}
+	if !reflect.DeepEqual(evicted, []int{0, 1, 1, 2, 3, 4}) {
+		t.Errorf("Evicted %v, want [0 1 1 2 3 4]", evicted)
+	}
+}
+
+func TestWindowCollector_Stream(t *testing.T) {
+	const window, numResults = 50, 10000
+
+	// IDs arrive slightly out of order, as from a pool.
+	ids := make([]int, numResults)
+	for i := range ids {
+		ids[i] = i
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i+1 < len(ids); i++ {
+		if r.Intn(2) == 0 {
+			ids[i], ids[i+1] = ids[i+1], ids[i]
+		}
+	}
+
+	evictions := 0
+	collector := NewWindowCollector(window, func(model.Result) { evictions++ })
+	for _, id := range ids {
+		collector.Add(model.Result{Task: model.Task{ID: id}})
+	}
+
+	retained := collector.Results()
+	if len(retained) != window {
+		t.Errorf("Retained %d results, want the window of %d", len(retained), window)
+	}
+	if retained[0].Task.ID != numResults-window || evictions != numResults-window {
+		t.Errorf("Retained IDs from %d with %d evictions, want %d and %d", retained[0].Task.ID, evictions, numResults-window, numResults-window)
+	}
+}
@@ -1,62 +1,251 @@
 package worker
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/lipcsei/konstruktor/model"
 	"github.com/lipcsei/konstruktor/utils"
+	"log"
 	"math/big"
+	"runtime/debug"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// processingTimes stores the processing times of recent tasks.
-var processingTimes []time.Duration
-
-// processingTimesLock synchronizes access to the processingTimes slice.
-var processingTimeLock sync.Mutex
+// initialProcessingTimes seeds the processing time history of every new worker. It is a variable
+// so tests can keep the adaptive time limit from timing out the tasks of a short run.
+var initialProcessingTimes []time.Duration
 
 // maxProcessingTimesToTrack specifies the length of the slice that stores processing times of tasks.
 // This is used to calculate the average processing time by keeping a limited history of recent processing times.
 const maxProcessingTimesToTrack = 20
 
+// defaultThresholdFactor is the multiple of the average processing time a task may take before it is
+// considered timed out. The default allows tasks to take 10% longer than the average.
+const defaultThresholdFactor = 1.1
+
+// ErrTaskPanicked is wrapped by the error of a result whose processing panicked.
+var ErrTaskPanicked = errors.New("task panicked")
+
+// PanicHandler is called with the worker, the task and the recovered value when processing a task panics.
+type PanicHandler func(workerID int, task model.Task, recovered any)
+
+// TaskObserver is called when a worker starts processing a task, and returns the function the worker
+// calls with the task's result and the time it took once processing has finished, e.g. to trace tasks.
+// The returned function may be nil if the observer is not interested in the result.
+type TaskObserver func(workerID int, task model.Task) func(result model.Result, duration time.Duration)
+
+// MultiObserver returns a TaskObserver that notifies every one of the observers, in order, e.g. to
+// trace tasks and report metrics about them at the same time. Nil observers are skipped.
+func MultiObserver(observers ...TaskObserver) TaskObserver {
+	return func(workerID int, task model.Task) func(model.Result, time.Duration) {
+		var finished []func(model.Result, time.Duration)
+		for _, observer := range observers {
+			if observer == nil {
+				continue
+			}
+			if f := observer(workerID, task); f != nil {
+				finished = append(finished, f)
+			}
+		}
+		if len(finished) == 0 {
+			return nil
+		}
+		return func(result model.Result, duration time.Duration) {
+			for _, f := range finished {
+				f(result, duration)
+			}
+		}
+	}
+}
+
 // simulateDelay is a global variable that allows for simulating a delay in task processing.
 // It can be set to a function that pauses execution, typically used for testing.
 var simulateDelay func()
 
+// throttleSleep pauses a worker for d, unless quit is closed first, and reports whether it paused for
+// the full duration. It is a variable so tests can observe the pauses of the CPU limit and the retry
+// backoff without waiting.
+var throttleSleep = func(d time.Duration, quit <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
 type Worker struct {
 	ID int
 	// tasks is a channel from which the worker receives tasks to process.
 	tasks <-chan model.Task
 	// results is a channel to which the worker sends processed tasks.
 	results chan<- model.Result
-	// quit is a channel used to signal the worker to gracefully shut down.
+	// quit is a channel used to signal the worker to gracefully shut down. It is ctx.Done() for a worker
+	// created by NewWithContext.
 	quit <-chan struct{}
 	// wg is used to signal when the worker has finished processing.
 	wg *sync.WaitGroup
+	// clock is used for every time measurement of the worker.
+	clock Clock
+	// completed, if set, receives the ID of every task after its result has been sent.
+	completed chan<- int
 
 	// maxProcessingTimesToTrack is the maximum number of processing times to consider for calculating the average.
 	maxProcessingTimesToTrack int
+	// processingTimes stores the processing times of the worker's recent tasks.
+	processingTimes []time.Duration
+	// processingTimeEWMA is the exponentially weighted moving average of the processing times, used if
+	// the worker has a smoothing factor. Zero means no processing time has been recorded yet.
+	processingTimeEWMA time.Duration
+	// processingTimeLock synchronizes access to processingTimes and processingTimeEWMA.
+	processingTimeLock sync.Mutex
+	// thresholdFactor is the multiple of the average processing time a task may take before it times out.
+	thresholdFactor float64
+	// timeoutPolicy, if set, decides whether a task timed out instead of the thresholdFactor.
+	timeoutPolicy TimeoutPolicy
+	// taskDeadline, if positive, is how long a single computation may run before it is aborted.
+	taskDeadline time.Duration
+	// smoothing is the smoothing factor of the exponentially weighted moving average of the processing times.
+	// Zero selects the simple moving average of the last maxProcessingTimesToTrack processing times.
+	smoothing float64
+	// retrySlowTasks enables recomputing tasks that exceed the allowed time threshold.
+	retrySlowTasks bool
+	// retryAllowance is the multiple of the threshold a retried task may take. Zero exempts it from the threshold.
+	retryAllowance float64
+	// retries is the number of times a failed task is processed again, waiting retryBackoff before the
+	// first retry and twice as long before every further one.
+	retries      int
+	retryBackoff time.Duration
+	// validate, if set, checks every successfully computed result before it is sent.
+	validate func(model.Result) error
+	// factorize enables attaching the prime factorization to every successful result.
+	factorize bool
+	// limiter, if set, caps the number of tasks the worker starts per second.
+	limiter *rateLimiter
+	// cpuLimit is the fraction of a core's time the worker may spend computing. Zero means no limit.
+	cpuLimit float64
+	// panicHandler is called when processing a task panics.
+	panicHandler PanicHandler
+	// taskHook, if set, is called before every computation of a task.
+	taskHook func(model.Task)
+	// active, if set, reports whether the worker may take tasks, along with a channel that is closed
+	// when that may have changed. A worker that may not is retired by the pool's autoscaler.
+	active func(id int) (bool, <-chan struct{})
+	// observer, if set, is notified when processing of a task starts and finishes.
+	observer TaskObserver
+	// cache, if set, holds previously computed factorials.
+	cache Cache
+	// inflight, if set, tracks the values being computed by all workers of the pool.
+	inflight *inflightTracker
+	// maxBits is the bit-length budget of a single factorial. Zero means no budget.
+	maxBits int
+	// yieldEvery is the number of multiplications after which a computation yields the processor.
+	// Zero never yields.
+	yieldEvery int64
+
+	// intercept, if set, is offered every result before it is sent. If it takes the result,
+	// by returning true, the result is not sent to the results channel.
+	intercept func(model.Result) bool
+	// finished, if set, is called after the result of every task has been sent.
+	finished func()
+	// maxResultBits, if set, is raised to the bit length of every result that exceeds it.
+	// It is shared by all workers of a pool.
+	maxResultBits *atomic.Int64
+
+	// current points to the task being processed, or is nil while the worker is idle.
+	current atomic.Pointer[model.Task]
+	// state holds the workerState describing what the worker is doing right now.
+	state atomic.Int32
+
+	// statsLock synchronizes access to the idle and busy time counters.
+	statsLock sync.Mutex
+	// idleTime is the total time the worker spent waiting for a task.
+	idleTime time.Duration
+	// busyTime is the total time the worker spent processing tasks, up to the delivery of their results.
+	busyTime time.Duration
+	// processed is the number of tasks the worker has completed.
+	processed int
+	// failed and timedOut count the completed tasks that failed, and those among them that timed out.
+	failed   int
+	timedOut int
+	// processingTime is the total time the worker spent computing results.
+	processingTime time.Duration
 }
 
 // New initializes and returns a new Worker instance.
-func New(id int, tasks <-chan model.Task, results chan<- model.Result, wg *sync.WaitGroup, quit <-chan struct{}) *Worker {
+// The worker measures processing times with the given clock, or with the real clock if it is nil.
+func New(id int, tasks <-chan model.Task, results chan<- model.Result, wg *sync.WaitGroup, quit <-chan struct{}, clock Clock) *Worker {
+	if clock == nil {
+		clock = RealClock()
+	}
 	return &Worker{
 		ID:                        id,
 		tasks:                     tasks,
 		results:                   results,
 		quit:                      quit,
 		wg:                        wg,
+		clock:                     clock,
 		maxProcessingTimesToTrack: maxProcessingTimesToTrack,
+		processingTimes:           append([]time.Duration(nil), initialProcessingTimes...),
+		thresholdFactor:           defaultThresholdFactor,
+		panicHandler:              logPanic,
 	}
 }
 
+// NewWithContext is like New, but the worker is stopped by cancelling ctx instead of closing a quit
+// channel, so it can be bounded with context.WithTimeout or tied to the context of a request. Once ctx
+// is cancelled, the worker picks up no further task, abandons sending a result nobody receives, and
+// Start returns; a computation already in progress is finished first.
+func NewWithContext(ctx context.Context, id int, tasks <-chan model.Task, results chan<- model.Result, wg *sync.WaitGroup, clock Clock) *Worker {
+	return New(id, tasks, results, wg, ctx.Done(), clock)
+}
+
 // Start is the main method of the Worker, where it begins processing tasks from the tasks channel.
 // It listens for tasks to process and quit signals for shutdown, utilizing a select statement to handle
 // both concurrently. If a quit signal is received, the worker stops processing and exits.
+// Closing the tasks channel is enough to end the worker once it has processed every task; quit is
+// only needed to abandon work early. Closing quit after the WaitGroup reports that every worker has
+// returned, as the Pool does, is therefore always safe. Once quit is closed, the worker does not
+// pick up another task, even if it was closed before the worker started.
 func (w *Worker) Start() {
 	defer w.wg.Done()
+	defer w.setState(stateStopped)
 
 	for {
+		// A select picks randomly among ready cases, so check quit on its own first,
+		// to keep a closed quit channel from losing against a pending task.
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+
+		// A retired worker waits until it is activated again, without taking tasks.
+		var rescaled <-chan struct{}
+		if w.active != nil {
+			active, changed := w.active(w.ID)
+			if !active {
+				w.setState(stateRetired)
+				select {
+				case <-changed:
+					continue
+				case <-w.quit:
+					return
+				}
+			}
+			rescaled = changed
+		}
+
+		// Record when the worker started waiting, to account for the time it spends idle.
+		waitStart := w.clock.Now()
+		w.setState(stateWaiting)
+
 		select {
 		// Attempt to receive a task from the tasks channel.
 		case task, ok := <-w.tasks:
@@ -65,36 +254,74 @@ func (w *Worker) Start() {
 				return
 			}
 
+			// Wait for the worker's rate limit to allow another task; the wait counts as idle time.
+			if w.limiter != nil {
+				w.setState(stateRateLimited)
+				if !w.limiter.wait(w.clock.Now(), w.quit) {
+					return
+				}
+			}
+
 			// Record the start time of the task processing to measure its duration.
-			startTime := time.Now()
+			startTime := w.clock.Now()
+			w.addIdleTime(startTime.Sub(waitStart))
+			w.current.Store(&task)
+			w.setState(stateProcessing)
 
-			if simulateDelay != nil {
-				// If a delay function is defined, invoke it. Useful for testing.
-				simulateDelay()
+			var observed func(model.Result, time.Duration)
+			if w.observer != nil {
+				observed = w.observer(w.ID, task)
 			}
+			// Only the last attempt counts as the task's processing time, without earlier attempts and backoffs.
+			r, attemptStart := w.processWithRetries(task, startTime)
+			computeTime := w.clock.Now().Sub(attemptStart)
+			w.recordOutcome(r, computeTime)
+			if observed != nil {
+				observed(r, computeTime)
+			}
+			w.recordResultBits(r)
 
-			// Calculate the factorial of the task's value.
-			result := utils.CalcFactorial(task.Value)
-
-			// Determine the total processing time for the task.
-			processingTime := time.Since(startTime)
+			// The worker is busy until the result is ready; blocking on a slow consumer does not count.
+			busyTime := w.clock.Now().Sub(startTime)
 
-			// Calculate the current average processing time of recent tasks.
-			averageTime := w.calculateAverageProcessingTime()
+			// Send the result (either the calculated factorial or 0) to the results channel.
+			// If the consumer has stopped reading, a quit signal abandons the send instead of blocking forever.
+			if w.intercept != nil && w.intercept(r) {
+				w.current.Store(nil)
+			} else {
+				w.setState(stateSending)
+				select {
+				case w.results <- r:
+					w.current.Store(nil)
+				case <-w.quit:
+					w.current.Store(nil)
+					return
+				}
+			}
 
-			// Update the processingTimes slice.
-			w.updateProcessingTimes(processingTime)
+			if w.completed != nil {
+				// Let the dispatcher release the tasks that depend on this one.
+				w.setState(stateReporting)
+				w.completed <- task.ID
+			}
+			if w.finished != nil {
+				w.finished()
+			}
 
-			// Calculate the allowed time threshold as 10% above the average time
-			allowedTimeThreshold := averageTime + (averageTime / 10)
+			w.addBusyTime(busyTime)
 
-			// Check if the processing time exceeds the allowed time threshold
-			if processingTime > 0 && averageTime > 0 && processingTime > allowedTimeThreshold {
-				result = big.NewInt(0) // Override the factorial result with 0.
+			// Pause in proportion to the computation to stay within the CPU limit; the pause counts as idle time.
+			if w.cpuLimit > 0 && w.cpuLimit < 1 {
+				w.setState(stateThrottled)
+				pauseStart := w.clock.Now()
+				if !throttleSleep(time.Duration(float64(computeTime)*(1/w.cpuLimit-1)), w.quit) {
+					return
+				}
+				w.addIdleTime(w.clock.Now().Sub(pauseStart))
 			}
-
-			// Send the result (either the calculated factorial or 0) to the results channel.
-			w.results <- model.Result{Task: task, Factorial: result, WorkerID: w.ID}
+		case <-rescaled:
+			// Check whether the worker has been retired before waiting for another task.
+			w.addIdleTime(w.clock.Now().Sub(waitStart))
 		case <-w.quit:
 			// If a quit signal is received, exit the loop and end the goroutine.
 			return
@@ -102,48 +329,238 @@ func (w *Worker) Start() {
 	}
 }
 
-// updateProcessingTimes updates the slice of processing times with the latest task processing time.
+// setState records what the worker is doing right now, for Pool.DumpState.
+func (w *Worker) setState(state workerState) {
+	w.state.Store(int32(state))
+}
+
+// CurrentTask returns the task the worker is processing right now.
+// The boolean is false if the worker is idle. It is safe to call while the worker is running.
+func (w *Worker) CurrentTask() (model.Task, bool) {
+	task := w.current.Load()
+	if task == nil {
+		return model.Task{}, false
+	}
+	return *task, true
+}
+
+// processSafely is like process, but recovers from a panic while processing the task, so the worker
+// survives it. The panic is reported to the panic handler and turns into a failed result whose error
+// wraps ErrTaskPanicked. A panic in the handler itself is not recovered.
+func (w *Worker) processSafely(task model.Task, startTime time.Time) (r model.Result) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			w.panicHandler(w.ID, task, recovered)
+			r = model.Result{
+				Task:     task,
+				WorkerID: w.ID,
+				Err:      fmt.Errorf("%w: %v", ErrTaskPanicked, recovered),
+			}
+		}
+	}()
+	return w.process(task, startTime)
+}
+
+// recordResultBits raises the shared maximum result size to the bit length of the result's factorial.
+func (w *Worker) recordResultBits(r model.Result) {
+	if w.maxResultBits == nil || r.Factorial == nil {
+		return
+	}
+	bits := int64(r.Factorial.BitLen())
+	for {
+		current := w.maxResultBits.Load()
+		if bits <= current || w.maxResultBits.CompareAndSwap(current, bits) {
+			return
+		}
+	}
+}
+
+// logPanic is the default PanicHandler. It logs the panic along with the stack of the worker.
+func logPanic(workerID int, task model.Task, recovered any) {
+	log.Printf("worker %d panicked processing task %d (%d!): %v\n%s", workerID, task.ID, task.Value, recovered, debug.Stack())
+}
+
+// process computes the result of a task whose processing started at startTime. It enforces the
+// processing time limit, retrying or failing slow tasks, and validates the result.
+func (w *Worker) process(task model.Task, startTime time.Time) model.Result {
+	// Calculate the factorial of the task's value.
+	result, err := w.calculate(task)
+	if err != nil {
+		// The computation was aborted; its processing time says nothing about the average.
+		return model.Result{Task: task, WorkerID: w.ID, Err: err}
+	}
+
+	// Determine the total processing time for the task.
+	processingTime := w.clock.Now().Sub(startTime)
+
+	// Calculate the current average processing time of recent tasks.
+	averageTime := w.calculateAverageProcessingTime()
+
+	// Update the processingTimes slice.
+	w.updateProcessingTimes(processingTime)
+
+	// By default, a task may take a multiple of the average time (10% above it by default).
+	policy := w.timeoutPolicy
+	if policy == nil {
+		policy = ThresholdPolicy{Factor: w.thresholdFactor}
+	}
+
+	// Check if the processing time exceeds what the policy allows.
+	retried := false
+	if processingTime > 0 && policy.ShouldTimeout(task, processingTime, averageTime) {
+		if w.retrySlowTasks {
+			// Give the task a second chance with an extended allowance instead of failing it outright.
+			retried = true
+			result, err = w.retry(task, policy, averageTime)
+		} else {
+			// Discard the factorial result.
+			result, err = nil, fmt.Errorf("%w: took %v", ErrTaskTimeout, processingTime)
+		}
+	}
+
+	r := model.Result{Task: task, Factorial: result, WorkerID: w.ID, Retried: retried, Err: err}
+	if err != nil {
+		return r
+	}
+	if w.factorize {
+		r.Factorization = utils.FactorialFactorization(task.Value)
+	}
+	if w.validate != nil {
+		if err := w.validate(r); err != nil {
+			// Mark the result as failed with the validation error.
+			r.Factorial = nil
+			r.Factorization = nil
+			r.Err = err
+		}
+	}
+	return r
+}
+
+// calculate computes the factorial of the task's value, aborting if it outgrows the bit budget or
+// runs past the task deadline. Values found in the cache are returned without computing them again.
+// An aborted computation yields no factorial and an error wrapping utils.ErrBitBudgetExceeded or
+// ErrTaskDeadline.
+func (w *Worker) calculate(task model.Task) (*big.Int, error) {
+	if simulateDelay != nil {
+		// If a delay function is defined, invoke it. Useful for testing.
+		simulateDelay()
+	}
+	if w.taskHook != nil {
+		w.taskHook(task)
+	}
+
+	if w.cache != nil {
+		if result, ok := w.cache.Get(task.Value); ok {
+			return result, nil
+		}
+	}
+
+	if w.inflight != nil {
+		defer w.inflight.begin(task.Value)()
+	}
+	opts := utils.ComputeOptions{MaxBits: w.maxBits, YieldEvery: w.yieldEvery}
+	if w.taskDeadline > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), w.taskDeadline)
+		defer cancel()
+		opts.Context = ctx
+	}
+	result, err := utils.CalcFactorialWith(task.Value, opts)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %v", ErrTaskDeadline, w.taskDeadline)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if w.cache != nil {
+		w.cache.Put(task.Value, result)
+	}
+	return result, nil
+}
+
+// retry recomputes a task the timeout policy timed out. The retry is allowed retryAllowance times
+// the time the policy allows, or any amount of time if retryAllowance is 0: its duration is scaled
+// down by retryAllowance before the policy is consulted again with the same average.
+// It returns an error wrapping ErrTaskTimeout if the retry exceeds its allowance as well, and the
+// error of calculate if the retry was aborted. The retry's duration is not added to the processing
+// times, so a single large task does not skew the average.
+func (w *Worker) retry(task model.Task, policy TimeoutPolicy, averageTime time.Duration) (*big.Int, error) {
+	startTime := w.clock.Now()
+	result, err := w.calculate(task)
+	if err != nil {
+		return result, err
+	}
+	processingTime := w.clock.Now().Sub(startTime)
+
+	if w.retryAllowance > 0 && policy.ShouldTimeout(task, time.Duration(float64(processingTime)/w.retryAllowance), averageTime) {
+		return nil, fmt.Errorf("%w: took %v when retried", ErrTaskTimeout, processingTime)
+	}
+	return result, nil
+}
+
+// updateProcessingTimes updates the worker's slice of processing times with the latest task processing time.
 // It ensures that the slice does not exceed the maximum number of processing times to track.
 // Older processing times are removed to maintain the size limit.
 func (w *Worker) updateProcessingTimes(processingTime time.Duration) {
-	processingTimeLock.Lock()
-	defer processingTimeLock.Unlock()
+	w.processingTimeLock.Lock()
+	defer w.processingTimeLock.Unlock()
 	// Check if the processing times slice has reached its maximum capacity.
-	if len(processingTimes) >= w.maxProcessingTimesToTrack {
+	if len(w.processingTimes) >= w.maxProcessingTimesToTrack {
 		// Remove the oldest processing time to make room for the new one.
-		processingTimes = processingTimes[1:]
+		w.processingTimes = w.processingTimes[1:]
 	}
 
 	// Add the new processing time to the end of the slice.
-	processingTimes = append(processingTimes, processingTime)
+	w.processingTimes = append(w.processingTimes, processingTime)
+
+	// Fold the new processing time into the exponentially weighted moving average.
+	// The first processing time seeds the average.
+	if w.smoothing > 0 {
+		if w.processingTimeEWMA == 0 {
+			w.processingTimeEWMA = processingTime
+		} else {
+			w.processingTimeEWMA = time.Duration(w.smoothing*float64(processingTime) + (1-w.smoothing)*float64(w.processingTimeEWMA))
+		}
+	}
 }
 
-// calculateAverageProcessingTime computes the average processing time of the most recent tasks,
-// up to the number specified by maxProcessingTimesToTrack.
+// calculateAverageProcessingTime computes the average processing time of the worker's most recent tasks,
+// up to the number specified by maxProcessingTimesToTrack, or the exponentially weighted moving
+// average if the worker has a smoothing factor.
 // It locks the processingTimes slice during calculation to ensure thread-safe access.
 // Returns 0 if there are no recorded processing times.
 func (w *Worker) calculateAverageProcessingTime() time.Duration {
-	processingTimeLock.Lock()
-	defer processingTimeLock.Unlock()
+	w.processingTimeLock.Lock()
+	defer w.processingTimeLock.Unlock()
+	if w.smoothing > 0 {
+		return w.processingTimeEWMA
+	}
+
 	var sum time.Duration
 	// Sum up all recorded processing times.
-	for _, t := range processingTimes {
+	for _, t := range w.processingTimes {
 		sum += t
 	}
 
 	// Avoid division by zero if no processing times are recorded.
-	if len(processingTimes) == 0 {
+	if len(w.processingTimes) == 0 {
 		return 0
 	}
 
 	// Calculate and return the average processing time.
-	return sum / time.Duration(len(processingTimes))
+	return sum / time.Duration(len(w.processingTimes))
 }
 
 // SortResults sorts the results based on their task ID and returns a slice of sorted results.
-func SortResults(results chan model.Result, length int) []model.Result {
-	sortedResult := make([]model.Result, length)
+// The slice has room for length results. Results with a task ID that is negative, or length or higher,
+// cannot be placed and are dropped with a logged warning.
+func SortResults(results <-chan model.Result, length int) []model.Result {
+	sortedResult := make([]model.Result, max(length, 0))
 	for r := range results {
+		if r.Task.ID < 0 || r.Task.ID >= len(sortedResult) {
+			log.Printf("SortResults: dropping the result of task %d with an ID outside [0, %d)", r.Task.ID, len(sortedResult))
+			continue
+		}
 		sortedResult[r.Task.ID] = r
 	}
 
@@ -152,3 +569,36 @@ func SortResults(results chan model.Result, length int) []model.Result {
 	})
 	return sortedResult
 }
+
+// SortResultsContext is like SortResults, but stops collecting as soon as ctx is cancelled and returns
+// the results received so far, sorted by task ID, along with the context's error. Results are never
+// abandoned on the channel: after a cancellation, a goroutine keeps draining it until it is closed and
+// discards what arrives, so workers blocked on sending a late result are released instead of hanging.
+// The channel must therefore be closed eventually, as the channels of a Pool are.
+func SortResultsContext(ctx context.Context, results <-chan model.Result, length int) ([]model.Result, error) {
+	collected := make([]model.Result, 0, length)
+	var err error
+collect:
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				break collect
+			}
+			collected = append(collected, r)
+		case <-ctx.Done():
+			// Discard the late results, so nobody blocks on sending them.
+			go func() {
+				for range results {
+				}
+			}()
+			err = ctx.Err()
+			break collect
+		}
+	}
+
+	sort.SliceStable(collected, func(i, j int) bool {
+		return collected[i].Task.ID < collected[j].Task.ID
+	})
+	return collected, err
+}
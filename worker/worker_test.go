@@ -1,7 +1,10 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"github.com/lipcsei/konstruktor/model"
+	"github.com/lipcsei/konstruktor/utils"
 	"math/big"
 	"sync"
 	"testing"
@@ -11,13 +14,12 @@ import (
 func TestWorker_Start_TaskProcessingTimeLimit(t *testing.T) {
 	// Setup tasks with a single task value. In this case, the task will be forced to exceed processing time limits.
 	tasks := []int64{3}
-	expectedResults := []*big.Int{
-		big.NewInt(0), // Expecting a result of 0 due to processing time limit exceeded.
-	}
 
 	// Simulate a delay in task processing to trigger the processing time limit.
+	// The fake clock makes the task appear to take 500ms without actually waiting.
+	clock := newFakeClock()
 	simulateDelay = func() {
-		time.Sleep(500 * time.Millisecond)
+		clock.Advance(500 * time.Millisecond)
 	}
 	defer func() { simulateDelay = nil }()
 
@@ -26,10 +28,10 @@ func TestWorker_Start_TaskProcessingTimeLimit(t *testing.T) {
 	quit := make(chan struct{})
 
 	var wg sync.WaitGroup
-	testWorker := New(1, taskChannel, resultChannel, &wg, quit)
+	testWorker := New(1, taskChannel, resultChannel, &wg, quit, clock)
 
 	testWorker.maxProcessingTimesToTrack = 3
-	processingTimes = []time.Duration{
+	testWorker.processingTimes = []time.Duration{
 		time.Millisecond * 100,
 		time.Millisecond * 200,
 		time.Millisecond * 300,
@@ -49,10 +51,11 @@ func TestWorker_Start_TaskProcessingTimeLimit(t *testing.T) {
 		close(quit)
 	}()
 
-	for i, expectedResult := range expectedResults {
+	// Expecting no result due to processing time limit exceeded.
+	for i := range tasks {
 		result := <-resultChannel
-		if result.Factorial.Cmp(expectedResult) != 0 {
-			t.Errorf("Task %d expected result %v, got %v", tasks[i], expectedResult, result.Factorial)
+		if result.Factorial != nil || !errors.Is(result.Err, ErrTaskTimeout) {
+			t.Errorf("Task %d expected no result and ErrTaskTimeout, got %v and %v", tasks[i], result.Factorial, result.Err)
 		}
 	}
 }
@@ -71,7 +74,7 @@ func TestWorker_Start_TaskProcessingOrder(t *testing.T) {
 	quit := make(chan struct{})
 
 	var wg sync.WaitGroup
-	testWorker := New(1, taskChannel, resultChannel, &wg, quit)
+	testWorker := New(1, taskChannel, resultChannel, &wg, quit, nil)
 	// override
 	testWorker.maxProcessingTimesToTrack = 5
 
@@ -98,19 +101,79 @@ func TestWorker_Start_TaskProcessingOrder(t *testing.T) {
 	}
 }
 
+// TestWorker_Start_RecoversFromPanic tests that a worker recovers from a panic while processing a task,
+// reports it as a failed result and goes on with the remaining tasks.
+func TestWorker_Start_RecoversFromPanic(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	// Processing the first task panics, the others succeed.
+	calls := 0
+	simulateDelay = func() {
+		calls++
+		if calls == 1 {
+			panic("bad task")
+		}
+	}
+	defer func() { simulateDelay = nil }()
+
+	values := []int64{5, 6, 7}
+	taskChannel := make(chan model.Task, len(values))
+	resultChannel := make(chan model.Result, len(values))
+	for i, v := range values {
+		taskChannel <- model.Task{ID: i, Value: v}
+	}
+	close(taskChannel)
+
+	var wg sync.WaitGroup
+	testWorker := New(1, taskChannel, resultChannel, &wg, make(chan struct{}), nil)
+	var recovered []any
+	testWorker.panicHandler = func(_ int, _ model.Task, r any) {
+		recovered = append(recovered, r)
+	}
+
+	wg.Add(1)
+	go testWorker.Start()
+	wg.Wait()
+	close(resultChannel)
+
+	if len(recovered) != 1 || recovered[0] != "bad task" {
+		t.Errorf("Panic handler recovered %v, want a single \"bad task\"", recovered)
+	}
+
+	// The worker survives the panic and processes the remaining tasks.
+	var results []model.Result
+	for result := range resultChannel {
+		results = append(results, result)
+	}
+	if len(results) != len(values) {
+		t.Fatalf("Expected %d results, got %d", len(values), len(results))
+	}
+	if failed := results[0]; failed.Task.ID != 0 || failed.Factorial != nil || !errors.Is(failed.Err, ErrTaskPanicked) {
+		t.Errorf("Task 0 = %v with error %v, want no factorial and an error wrapping ErrTaskPanicked", failed.Factorial, failed.Err)
+	}
+	for _, result := range results[1:] {
+		if expected := utils.CalcFactorial(result.Task.Value); result.Err != nil || result.Factorial.Cmp(expected) != 0 {
+			t.Errorf("Task %d expected result %v, got %v with error %v", result.Task.ID, expected, result.Factorial, result.Err)
+		}
+	}
+	if stats := testWorker.Stats(); stats.Processed != len(values) || stats.Failed != 1 {
+		t.Errorf("Stats() = %d processed and %d failed, want %d and 1", stats.Processed, stats.Failed, len(values))
+	}
+}
+
 // TestCalculateAverageProcessingTime tests the calculateAverageProcessingTime function to ensure
 // it correctly calculates the average processing time from a set of durations.
 func TestCalculateAverageProcessingTime(t *testing.T) {
-	testWorker := New(1, nil, nil, nil, nil)
+	testWorker := New(1, nil, nil, nil, nil, nil)
 
-	// Setup: Clear and then set predefined processing times for testing
-	processingTimes = []time.Duration{} // Clear existing processing times
+	// Setup: Set predefined processing times for testing
 	testDurations := []time.Duration{
 		time.Millisecond * 100,
 		time.Millisecond * 200,
 		time.Millisecond * 300,
 	}
-	processingTimes = append(processingTimes, testDurations...)
+	testWorker.processingTimes = append(testWorker.processingTimes, testDurations...)
 
 	// Expected average calculation
 	var expectedSum time.Duration
@@ -127,3 +190,299 @@ func TestCalculateAverageProcessingTime(t *testing.T) {
 		t.Errorf("calculateAverageProcessingTime() = %v, want %v", average, expectedAverage)
 	}
 }
+
+func TestCalculateAverageProcessingTime_PerWorker(t *testing.T) {
+	slow := New(1, nil, nil, nil, nil, nil)
+	fast := New(2, nil, nil, nil, nil, nil)
+
+	slow.updateProcessingTimes(time.Second)
+	fast.updateProcessingTimes(10 * time.Millisecond)
+
+	// Every worker averages its own tasks only.
+	if average := slow.calculateAverageProcessingTime(); average != time.Second {
+		t.Errorf("Slow worker average = %v, want 1s", average)
+	}
+	if average := fast.calculateAverageProcessingTime(); average != 10*time.Millisecond {
+		t.Errorf("Fast worker average = %v, want 10ms", average)
+	}
+	if average := New(3, nil, nil, nil, nil, nil).calculateAverageProcessingTime(); average != 0 {
+		t.Errorf("New worker average = %v, want 0", average)
+	}
+}
+
+func TestWorker_Start_SlowTaskRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		retryAllowance float64
+		expected       *big.Int
+	}{
+		{"exempt from the threshold", 0, big.NewInt(6)}, // 3!, the retry always completes.
+		{"same allowance as the original", 1, nil},      // The retry is as slow as the first attempt.
+	}
+
+	// Simulate a delay in task processing to trigger the processing time limit.
+	// The fake clock makes every attempt appear to take 500ms without actually waiting.
+	clock := newFakeClock()
+	simulateDelay = func() {
+		clock.Advance(500 * time.Millisecond)
+	}
+	defer func() { simulateDelay = nil }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taskChannel := make(chan model.Task, 1)
+			resultChannel := make(chan model.Result, 1)
+			quit := make(chan struct{})
+
+			var wg sync.WaitGroup
+			testWorker := New(1, taskChannel, resultChannel, &wg, quit, clock)
+			testWorker.retrySlowTasks = true
+			testWorker.retryAllowance = test.retryAllowance
+
+			testWorker.maxProcessingTimesToTrack = 3
+			testWorker.processingTimes = []time.Duration{
+				time.Millisecond * 100,
+				time.Millisecond * 200,
+				time.Millisecond * 300,
+			}
+
+			wg.Add(1)
+			go testWorker.Start()
+
+			taskChannel <- model.Task{ID: 0, Value: 3}
+			close(taskChannel)
+			wg.Wait()
+
+			result := <-resultChannel
+			if !result.Retried {
+				t.Errorf("Result is not marked as retried")
+			}
+			if test.expected == nil {
+				if result.Factorial != nil || !errors.Is(result.Err, ErrTaskTimeout) {
+					t.Errorf("Task 3 expected no result and ErrTaskTimeout, got %v and %v", result.Factorial, result.Err)
+				}
+			} else if result.Factorial == nil || result.Factorial.Cmp(test.expected) != 0 {
+				t.Errorf("Task 3 expected result %v, got %v", test.expected, result.Factorial)
+			}
+		})
+	}
+}
+
+func TestWorker_Start_QuitWhileSendingResult(t *testing.T) {
+	checkGoroutines(t)
+	taskChannel := make(chan model.Task, 1)
+	resultChannel := make(chan model.Result) // Unbuffered and never read, so the send blocks.
+	quit := make(chan struct{})
+
+	var wg sync.WaitGroup
+	testWorker := New(1, taskChannel, resultChannel, &wg, quit, nil)
+
+	wg.Add(1)
+	go testWorker.Start()
+
+	taskChannel <- model.Task{ID: 0, Value: 3}
+	// Give the worker time to compute the result and block on sending it.
+	time.Sleep(50 * time.Millisecond)
+	close(quit)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Worker did not exit after quit while blocked on sending a result")
+	}
+}
+
+// TestCalculateAverageProcessingTime_EWMA feeds a step change in processing times and checks
+// that the exponentially weighted moving average converges to the new level as expected.
+func TestCalculateAverageProcessingTime_EWMA(t *testing.T) {
+	const smoothing = 0.5
+	testWorker := New(1, nil, nil, nil, nil, nil)
+	testWorker.smoothing = smoothing
+
+	for i := 0; i < 10; i++ {
+		testWorker.updateProcessingTimes(100 * time.Millisecond)
+	}
+	if average := testWorker.calculateAverageProcessingTime(); average != 100*time.Millisecond {
+		t.Fatalf("calculateAverageProcessingTime() = %v before the step, want 100ms", average)
+	}
+
+	// After k samples at the new level, the remaining gap is (1 - smoothing)^k of the step.
+	gap := 100 * time.Millisecond
+	for k := 1; k <= 5; k++ {
+		testWorker.updateProcessingTimes(200 * time.Millisecond)
+		gap = time.Duration(float64(gap) * (1 - smoothing))
+
+		expected := 200*time.Millisecond - gap
+		if average := testWorker.calculateAverageProcessingTime(); average != expected {
+			t.Errorf("calculateAverageProcessingTime() = %v after %d samples at 200ms, want %v", average, k, expected)
+		}
+	}
+
+	// The simple moving average of the same history lags far behind.
+	testWorker.smoothing = 0
+	if average := testWorker.calculateAverageProcessingTime(); average >= 200*time.Millisecond-gap {
+		t.Errorf("simple moving average = %v, expected it to adapt slower than the EWMA", average)
+	}
+}
+
+func TestWorker_Start_QuitClosedBeforeStart(t *testing.T) {
+	taskChannel := make(chan model.Task, 3)
+	for i := 0; i < cap(taskChannel); i++ {
+		taskChannel <- model.Task{ID: i, Value: 5}
+	}
+	close(taskChannel)
+
+	// Nobody reads the results, so a worker that picked up a task would block until quit.
+	resultChannel := make(chan model.Result)
+	quit := make(chan struct{})
+	close(quit)
+
+	var wg sync.WaitGroup
+	testWorker := New(1, taskChannel, resultChannel, &wg, quit, nil)
+
+	wg.Add(1)
+	go testWorker.Start()
+	wg.Wait()
+
+	if processed := testWorker.Stats().Processed; processed != 0 {
+		t.Errorf("Worker processed %d tasks after quit was closed, want 0", processed)
+	}
+	if len(taskChannel) != cap(taskChannel) {
+		t.Errorf("Worker took %d tasks after quit was closed, want 0", cap(taskChannel)-len(taskChannel))
+	}
+}
+
+func TestNewWithContext_Cancel(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	taskChannel := make(chan model.Task, 3)
+	resultChannel := make(chan model.Result, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	testWorker := NewWithContext(ctx, 1, taskChannel, resultChannel, &wg, nil)
+
+	wg.Add(1)
+	go testWorker.Start()
+
+	// The worker processes tasks until the context is cancelled.
+	taskChannel <- model.Task{ID: 0, Value: 5}
+	if r := <-resultChannel; r.Factorial.Cmp(big.NewInt(120)) != 0 {
+		t.Errorf("Expected 120, got %v", r.Factorial)
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Worker did not exit after its context was cancelled")
+	}
+
+	// Tasks sent after the cancellation are left alone, although the channel is still open.
+	taskChannel <- model.Task{ID: 1, Value: 5}
+	if len(taskChannel) != 1 || testWorker.Stats().Processed != 1 {
+		t.Errorf("Worker took a task after its context was cancelled")
+	}
+}
+
+func TestSortResults_IDOutOfRange(t *testing.T) {
+	const length = 3
+	results := make(chan model.Result, 6)
+	for _, id := range []int{0, 1, 2, length, 1 << 40, -1} {
+		results <- model.Result{Task: model.Task{ID: id, Value: int64(id + 10)}}
+	}
+	close(results)
+
+	// The results with an ID of length or higher, or a negative one, are dropped.
+	sorted := SortResults(results, length)
+	if len(sorted) != length {
+		t.Fatalf("Expected %d results, got %d", length, len(sorted))
+	}
+	for i, r := range sorted {
+		if r.Task.ID != i || r.Task.Value != int64(i+10) {
+			t.Errorf("Expected result %d to be task %d, got task %d (%d!)", i, i, r.Task.ID, r.Task.Value)
+		}
+	}
+}
+
+func TestSortResultsContext(t *testing.T) {
+	withoutTimeouts(t)
+
+	values := []int64{3, 5, 7, 10, 12}
+	tasks := make(chan model.Task, len(values))
+	for i, v := range values {
+		tasks <- model.Task{ID: i, Value: v}
+	}
+	close(tasks)
+
+	pool, err := NewPool(Config{Workers: 2})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	results, err := SortResultsContext(context.Background(), pool.Unordered(), len(values))
+	if err != nil {
+		t.Fatalf("SortResultsContext() returned an error: %v", err)
+	}
+	if len(results) != len(values) {
+		t.Fatalf("Expected %d results, got %d", len(values), len(results))
+	}
+	for i, r := range results {
+		if r.Task.ID != i {
+			t.Errorf("Expected result %d to be task %d, got task %d", i, i, r.Task.ID)
+		}
+	}
+}
+
+func TestSortResultsContext_DrainsLateResults(t *testing.T) {
+	checkGoroutines(t)
+	withoutTimeouts(t)
+
+	const numTasks = 20
+	tasks := make(chan model.Task, numTasks)
+	for i := 0; i < numTasks; i++ {
+		tasks <- model.Task{ID: i, Value: 10}
+	}
+	close(tasks)
+
+	// An unbuffered results channel: every result needs a reader.
+	pool, err := NewPool(Config{Workers: 4})
+	if err != nil {
+		t.Fatalf("NewPool() returned an error: %v", err)
+	}
+	pool.Start(tasks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := pool.Unordered()
+	<-results
+	cancel()
+
+	partial, err := SortResultsContext(ctx, results, numTasks)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(partial) >= numTasks {
+		t.Errorf("Expected a partial result, got all %d results", len(partial))
+	}
+
+	// The workers must be able to deliver the remaining results and finish.
+	select {
+	case <-pool.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Workers hang on sending the late results:\n%s", pool.DumpState())
+	}
+}